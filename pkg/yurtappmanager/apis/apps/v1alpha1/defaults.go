@@ -23,22 +23,49 @@ import (
 )
 
 const (
-	defaultIngressControllerImage     string = "registry.k8s.io/ingress-nginx/controller:v0.48.1"
-	defaultIngressWebhookCertGenImage string = "docker.io/jettech/kube-webhook-certgen:v1.5.1"
+	defaultIngressControllerImage        string = "registry.k8s.io/ingress-nginx/controller:v0.48.1"
+	defaultIngressWebhookCertGenImage    string = "docker.io/jettech/kube-webhook-certgen:v1.5.1"
+	defaultTraefikIngressControllerImage string = "docker.io/library/traefik:v2.6.1"
+	defaultNginxIngressNamespace         string = "ingress-nginx"
+	defaultTraefikIngressNamespace       string = "ingress-traefik"
 )
 
 // SetDefaultsYurtIngress set default values for YurtIngress.
 func SetDefaultsYurtIngress(obj *YurtIngress) {
 
-	if obj.Spec.IngressControllerImage == "" {
-		obj.Spec.IngressControllerImage = defaultIngressControllerImage
+	if obj.Spec.Provider == "" {
+		obj.Spec.Provider = NginxIngressProvider
 	}
-	if obj.Spec.IngressWebhookCertGenImage == "" {
-		obj.Spec.IngressWebhookCertGenImage = defaultIngressWebhookCertGenImage
+	switch obj.Spec.Provider {
+	case NginxIngressProvider:
+		if obj.Spec.Namespace == "" {
+			obj.Spec.Namespace = defaultNginxIngressNamespace
+		}
+		if obj.Spec.IngressControllerImage == "" {
+			obj.Spec.IngressControllerImage = defaultIngressControllerImage
+		}
+		if obj.Spec.IngressWebhookCertGenImage == "" {
+			obj.Spec.IngressWebhookCertGenImage = defaultIngressWebhookCertGenImage
+		}
+		if obj.Spec.WebhookCertManagement == "" {
+			obj.Spec.WebhookCertManagement = JobWebhookCertManagement
+		}
+	case TraefikIngressProvider:
+		if obj.Spec.Namespace == "" {
+			obj.Spec.Namespace = defaultTraefikIngressNamespace
+		}
+		if obj.Spec.IngressControllerImage == "" {
+			obj.Spec.IngressControllerImage = defaultTraefikIngressControllerImage
+		}
 	}
 	if obj.Spec.Replicas == 0 {
 		obj.Spec.Replicas = 1
 	}
+	for i := range obj.Spec.Pools {
+		if exposure := obj.Spec.Pools[i].Exposure; exposure != nil && exposure.Mode == "" {
+			exposure.Mode = NodePortExposeMode
+		}
+	}
 
 }
 
@@ -62,6 +89,9 @@ func SetDefaultsYurtAppDaemon(obj *YurtAppDaemon) {
 	if obj.Spec.WorkloadTemplate.DeploymentTemplate != nil {
 		SetDefaultPodSpec(&obj.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec)
 	}
+	if obj.Spec.WorkloadTemplate.DaemonSetTemplate != nil {
+		SetDefaultPodSpec(&obj.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.Template.Spec)
+	}
 
 }
 
@@ -85,6 +115,9 @@ func SetDefaultsYurtAppSet(obj *YurtAppSet) {
 	if obj.Spec.WorkloadTemplate.DeploymentTemplate != nil {
 		SetDefaultPodSpec(&obj.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec)
 	}
+	if obj.Spec.WorkloadTemplate.DaemonSetTemplate != nil {
+		SetDefaultPodSpec(&obj.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.Template.Spec)
+	}
 
 }
 
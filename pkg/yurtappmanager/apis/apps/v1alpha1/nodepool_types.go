@@ -30,6 +30,10 @@ const (
 	NodePoolTypeLabelKey = "openyurt.io/node-pool-type"
 )
 
+// NodePoolFinalizer is used to prevent the NodePool from being deleted while
+// it is still referenced by YurtAppSet, YurtAppDaemon or YurtIngress
+const NodePoolFinalizer string = "nodepool.openyurt.io/protection"
+
 // NodePoolSpec defines the desired state of NodePool
 type NodePoolSpec struct {
 	// The type of the NodePool
@@ -40,6 +44,12 @@ type NodePoolSpec struct {
 	// +optional
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 
+	// If AdoptBySelector is true, nodes that match the Selector and do not
+	// specify the desired nodepool will be added to the pool automatically.
+	// A node that matches more than one pool will not be adopted.
+	// +optional
+	AdoptBySelector bool `json:"adoptBySelector,omitempty"`
+
 	// If specified, the Labels will be added to all nodes.
 	// NOTE: existing labels with samy keys on the nodes will be overwritten.
 	// +optional
@@ -55,6 +65,33 @@ type NodePoolSpec struct {
 	Taints []v1.Taint `json:"taints,omitempty"`
 }
 
+// NodePool condition types
+const (
+	// NodePoolReady means all nodes in the pool are ready
+	NodePoolReady = "Ready"
+
+	// NodePoolDegraded means some nodes in the pool are not ready, cordoned
+	// or under resource pressure
+	NodePoolDegraded = "Degraded"
+
+	// NodePoolAllNodesReachable means all nodes in the pool are still
+	// reporting their status
+	NodePoolAllNodesReachable = "AllNodesReachable"
+
+	// NodePoolSelectorConflict means some nodes selected by the pool are
+	// selected by other pools too
+	NodePoolSelectorConflict = "SelectorConflict"
+
+	// NodePoolDeletionBlocked means the pool is being deleted but is still
+	// referenced by some workloads
+	NodePoolDeletionBlocked = "DeletionBlocked"
+
+	// NodePoolPartitioned means all nodes in the pool have lost connection
+	// with the cloud, workloads controllers should avoid rescheduling the
+	// pods out of the pool
+	NodePoolPartitioned = "Partitioned"
+)
+
 // NodePoolStatus defines the observed state of NodePool
 type NodePoolStatus struct {
 	// Total number of ready nodes in the pool.
@@ -65,9 +102,79 @@ type NodePoolStatus struct {
 	// +optional
 	UnreadyNodeNum int32 `json:"unreadyNodeNum"`
 
+	// Total number of unready nodes in the pool that are autonomous and
+	// have lost connection with the cloud, the pods on these nodes are
+	// supposed to be still running.
+	// +optional
+	AutonomousNodeNum int32 `json:"autonomousNodeNum,omitempty"`
+
+	// Capacity is the sum of the capacity of the nodes in the pool.
+	// +optional
+	Capacity v1.ResourceList `json:"capacity,omitempty"`
+
+	// Allocatable is the sum of the allocatable resources of the nodes in
+	// the pool.
+	// +optional
+	Allocatable v1.ResourceList `json:"allocatable,omitempty"`
+
+	// Requested is the sum of the cpu and memory requested by the pods
+	// running on the nodes in the pool, and the number of these pods.
+	// +optional
+	Requested v1.ResourceList `json:"requested,omitempty"`
+
 	// The list of nodes' names in the pool
 	// +optional
 	Nodes []string `json:"nodes,omitempty"`
+
+	// Conditions represent the latest available observations of the pool's state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// NodeSummaries describes the health of every node in the pool
+	// +optional
+	NodeSummaries []NodeSummary `json:"nodeSummaries,omitempty"`
+
+	// ConflictedNodes is the list of nodes that match the Selector of the
+	// pool and the Selector of other pools at the same time
+	// +optional
+	ConflictedNodes []string `json:"conflictedNodes,omitempty"`
+}
+
+// NodeSummary describes the health of a node in the pool
+type NodeSummary struct {
+	// Name of the node
+	Name string `json:"name"`
+
+	// Ready is the status of the NodeReady condition of the node, Unknown
+	// means the node stops reporting its status
+	// +optional
+	Ready v1.ConditionStatus `json:"ready,omitempty"`
+
+	// LastHeartbeatTime is the last time the node reported its ready condition,
+	// it is only refreshed when the summary of the node changes
+	// +optional
+	LastHeartbeatTime metav1.Time `json:"lastHeartbeatTime,omitempty"`
+
+	// Unschedulable indicates the node has been cordoned
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
+
+	// PressureConditions lists the pressure conditions(MemoryPressure,
+	// DiskPressure, PIDPressure) that are currently true on the node
+	// +optional
+	PressureConditions []v1.NodeConditionType `json:"pressureConditions,omitempty"`
+
+	// Autonomy indicates the node is annotated with autonomy, its pods will
+	// keep running even if the node loses connection with the cloud
+	// +optional
+	Autonomy bool `json:"autonomy,omitempty"`
+
+	// LeaseStale indicates the node lease has not been renewed in time, the
+	// node may have lost connection with the cloud
+	// +optional
+	LeaseStale bool `json:"leaseStale,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -75,6 +182,12 @@ type NodePoolStatus struct {
 // +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="The type of nodepool"
 // +kubebuilder:printcolumn:name="ReadyNodes",type="integer",JSONPath=".status.readyNodeNum",description="The number of ready nodes in the pool"
 // +kubebuilder:printcolumn:name="NotReadyNodes",type="integer",JSONPath=".status.unreadyNodeNum"
+// +kubebuilder:printcolumn:name="AutonomousNodes",type="integer",JSONPath=".status.autonomousNodeNum",priority=1
+// +kubebuilder:printcolumn:name="CPU",type="string",JSONPath=".status.allocatable.cpu",description="The allocatable cpu of the pool"
+// +kubebuilder:printcolumn:name="RequestedCPU",type="string",JSONPath=".status.requested.cpu",description="The cpu requested by the pods in the pool"
+// +kubebuilder:printcolumn:name="Memory",type="string",JSONPath=".status.allocatable.memory",priority=1,description="The allocatable memory of the pool"
+// +kubebuilder:printcolumn:name="RequestedMemory",type="string",JSONPath=".status.requested.memory",priority=1,description="The memory requested by the pods in the pool"
+// +kubebuilder:printcolumn:name="Pods",type="string",JSONPath=".status.requested.pods",priority=1,description="The number of pods running in the pool"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
 // +genclient:nonNamespaced
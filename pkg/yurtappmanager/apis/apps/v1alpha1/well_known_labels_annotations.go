@@ -35,6 +35,11 @@ const (
 	AnnotationPatchKey = "apps.openyurt.io/patch"
 
 	AnnotationRefNodePool = "apps.openyurt.io/ref-nodepool"
+
+	// AnnotationRolloutResume resumes the rollout of the YurtAppSet that is
+	// paused after the wave named by the value, it will be removed once the
+	// rollout is resumed.
+	AnnotationRolloutResume = "apps.openyurt.io/rollout-resume"
 )
 
 // NodePool related labels and annotations
@@ -50,14 +55,52 @@ const (
 	// belonging to
 	LabelCurrentYurtAppDaemon = "apps.openyurt.io/yurtappdaemon"
 
+	// AnnotationPrevAttrs records the attributes the nodepool added to the
+	// node. Deprecated: the labels and annotations are tracked by the
+	// managed fields now, and the taints are tracked by AnnotationPoolTaints.
 	AnnotationPrevAttrs = "nodepool.openyurt.io/previous-attributes"
 
+	// AnnotationPoolTaints records the taints the nodepool added to the node
+	AnnotationPoolTaints = "nodepool.openyurt.io/taints"
+
+	// AnnotationAdoptedBy indicates which nodepool adopted the node by
+	// the nodepool selector
+	AnnotationAdoptedBy = "nodepool.openyurt.io/adopted-by"
+
+	// AnnotationMigrationPhase records the phase of the node that is
+	// migrating out of its current nodepool
+	AnnotationMigrationPhase = "nodepool.openyurt.io/migration-phase"
+
+	// AnnotationMigrationFrom records the nodepool that the node is
+	// migrating out of
+	AnnotationMigrationFrom = "nodepool.openyurt.io/migration-from"
+
+	// AnnotationMigrationTo records the nodepool that the node is
+	// migrating into, it is empty if the node is just leaving the pool
+	AnnotationMigrationTo = "nodepool.openyurt.io/migration-to"
+
+	// AnnotationMigrationPendingPods records the number of pool-scoped pods
+	// that are still running on the migrating node
+	AnnotationMigrationPendingPods = "nodepool.openyurt.io/migration-pending-pods"
+
+	// AnnotationMigrationCordoned indicates the node is cordoned by the
+	// migration and will be uncordoned once the migration is completed
+	AnnotationMigrationCordoned = "nodepool.openyurt.io/migration-cordoned"
+
+	// MigrationPhaseDraining means the node is cordoned and the pool-scoped
+	// pods on it are being evicted
+	MigrationPhaseDraining = "Draining"
+
 	// DefaultCloudNodePoolName defines the name of the default cloud nodepool
 	DefaultCloudNodePoolName = "default-nodepool"
 
 	// DefaultEdgeNodePoolName defines the name of the default edge nodepool
 	DefaultEdgeNodePoolName = "default-edge-nodepool"
 
+	// AnnotationNodeAutonomy indicates the pods on the node will keep
+	// running even if the node loses connection with the cloud
+	AnnotationNodeAutonomy = "node.beta.openyurt.io/autonomy"
+
 	// ServiceTopologyKey is the toplogy key that will be attached to node,
 	// the value will be the name of the nodepool
 	ServiceTopologyKey = "topology.kubernetes.io/zone"
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// default field paths of the workload described by a WorkloadDefinition
+	DefaultReplicasPath           = "spec.replicas"
+	DefaultSelectorPath           = "spec.selector"
+	DefaultPodTemplatePath        = "spec.template"
+	DefaultReadyReplicasPath      = "status.readyReplicas"
+	DefaultUpdatedReplicasPath    = "status.updatedReplicas"
+	DefaultObservedGenerationPath = "status.observedGeneration"
+)
+
+// WorkloadDefinitionSpec describes a workload kind which can be distributed
+// by YurtAppSet, and where the fields YurtAppSet reads and writes are located
+// in the workload. The paths are dot separated, e.g. spec.replicas.
+// The manager needs to be granted the permissions on the workload kind.
+type WorkloadDefinitionSpec struct {
+	// APIVersion of the workload, e.g. apps.kruise.io/v1alpha1
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the workload, e.g. CloneSet
+	Kind string `json:"kind"`
+
+	// ReplicasPath is the path of the desired replicas of the workload.
+	// Defaults to spec.replicas.
+	// +optional
+	ReplicasPath string `json:"replicasPath,omitempty"`
+
+	// SelectorPath is the path of the label selector of the workload.
+	// Defaults to spec.selector.
+	// +optional
+	SelectorPath string `json:"selectorPath,omitempty"`
+
+	// PodTemplatePath is the path of the pod template of the workload.
+	// Defaults to spec.template.
+	// +optional
+	PodTemplatePath string `json:"podTemplatePath,omitempty"`
+
+	// ReadyReplicasPath is the path of the ready replicas in the workload status.
+	// Defaults to status.readyReplicas.
+	// +optional
+	ReadyReplicasPath string `json:"readyReplicasPath,omitempty"`
+
+	// UpdatedReplicasPath is the path of the updated replicas in the workload status.
+	// Defaults to status.updatedReplicas.
+	// +optional
+	UpdatedReplicasPath string `json:"updatedReplicasPath,omitempty"`
+
+	// ObservedGenerationPath is the path of the observed generation in the workload status.
+	// Defaults to status.observedGeneration.
+	// +optional
+	ObservedGenerationPath string `json:"observedGenerationPath,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=workloaddefinitions,shortName=wd,categories=all
+// +kubebuilder:printcolumn:name="APIVersion",type="string",JSONPath=".spec.apiVersion",description="The api version of the workload"
+// +kubebuilder:printcolumn:name="Kind",type="string",JSONPath=".spec.kind",description="The kind of the workload"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// WorkloadDefinition registers a third-party workload kind for YurtAppSet.
+type WorkloadDefinition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkloadDefinitionSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkloadDefinitionList contains a list of WorkloadDefinition
+type WorkloadDefinitionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkloadDefinition `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkloadDefinition{}, &WorkloadDefinitionList{})
+}
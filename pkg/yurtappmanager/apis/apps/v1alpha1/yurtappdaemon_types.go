@@ -20,6 +20,7 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // YurtAppDaemonConditionType indicates valid conditions type of a YurtAppDaemon.
@@ -48,10 +49,111 @@ type YurtAppDaemonSpec struct {
 	// It must match the nodepool's labels.
 	NodePoolSelector *metav1.LabelSelector `json:"nodepoolSelector"`
 
+	// NodePoolPolicy further restricts the nodepools selected by NodePoolSelector
+	// to those which can actually run the workload.
+	// +optional
+	NodePoolPolicy *NodePoolPolicy `json:"nodePoolPolicy,omitempty"`
+
 	// Indicates the number of histories to be conserved.
 	// If unspecified, defaults to 10.
 	// +optional
 	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// ReplicaPolicy derives the replicas of the workload in each nodepool
+	// from the size of the nodepool. If unspecified, the replicas of the
+	// workload template are used.
+	// +optional
+	ReplicaPolicy *ReplicaPolicy `json:"replicaPolicy,omitempty"`
+
+	// Overrides customize the workloads of some nodepools. The patches of the
+	// overrides matching a nodepool are applied to its workload in order.
+	// +optional
+	Overrides []WorkloadOverride `json:"overrides,omitempty"`
+}
+
+// NodePoolPolicy describes which of the selected nodepools get a workload.
+type NodePoolPolicy struct {
+	// ExcludedNodePools are the names of the nodepools which never get a workload.
+	// +optional
+	ExcludedNodePools []string `json:"excludedNodePools,omitempty"`
+
+	// Types are the types of the nodepools which get a workload. If empty,
+	// nodepools of all types get a workload.
+	// +optional
+	Types []NodePoolType `json:"types,omitempty"`
+
+	// MinReadyNodes is the minimum number of ready nodes a nodepool must have
+	// before its workload is provisioned. The autonomous nodes that have lost
+	// connection with the cloud are counted as ready. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReadyNodes int32 `json:"minReadyNodes,omitempty"`
+
+	// TeardownGracePeriodSeconds is how long the workload of a nodepool is kept
+	// after the ready nodes of the nodepool drop below MinReadyNodes. If
+	// unspecified, the workload is never torn down for lack of ready nodes.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TeardownGracePeriodSeconds *int32 `json:"teardownGracePeriodSeconds,omitempty"`
+}
+
+// ReplicaPolicyType defines how the replicas of the workload in a nodepool
+// are derived.
+type ReplicaPolicyType string
+
+const (
+	// ReplicaPolicyFixed uses the replicas of the workload template
+	ReplicaPolicyFixed ReplicaPolicyType = "Fixed"
+	// ReplicaPolicyNodeRatio runs one replica per NodesPerReplica nodes of
+	// the nodepool, rounding up
+	ReplicaPolicyNodeRatio ReplicaPolicyType = "NodeRatio"
+	// ReplicaPolicyReadyNodes runs as many replicas as the ready nodes of
+	// the nodepool, including the autonomous nodes that have lost connection
+	// with the cloud
+	ReplicaPolicyReadyNodes ReplicaPolicyType = "ReadyNodes"
+)
+
+// ReplicaPolicy describes how to derive the replicas of the workload in a
+// nodepool from the node counts in the status of the nodepool. The replica
+// policy takes precedence over the replicas set by the overrides.
+type ReplicaPolicy struct {
+	// Type is the type of the replica policy, defaults to Fixed.
+	// +kubebuilder:validation:Enum=Fixed;NodeRatio;ReadyNodes
+	// +optional
+	Type ReplicaPolicyType `json:"type,omitempty"`
+
+	// NodesPerReplica is the number of nodes served by one replica, only
+	// used by the NodeRatio policy. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NodesPerReplica *int32 `json:"nodesPerReplica,omitempty"`
+
+	// MinReplicas is the lower bound of the replicas in each nodepool.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper bound of the replicas in each nodepool.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
+
+// WorkloadOverride describes a patch applied to the workloads of the
+// nodepools matched by name or by labels.
+type WorkloadOverride struct {
+	// NodePools are the names of the nodepools to be patched.
+	// +optional
+	NodePools []string `json:"nodePools,omitempty"`
+
+	// NodePoolSelector is a label query over the nodepools to be patched.
+	// +optional
+	NodePoolSelector *metav1.LabelSelector `json:"nodePoolSelector,omitempty"`
+
+	// Patch is a strategic merge patch applied to the workload, see
+	// https://kubernetes.io/docs/tasks/manage-kubernetes-objects/update-api-object-kubectl-patch/#notes-on-the-strategic-merge-patch
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Patch runtime.RawExtension `json:"patch"`
 }
 
 // YurtAppDaemonStatus defines the observed state of YurtAppDaemon.
@@ -79,6 +181,59 @@ type YurtAppDaemonStatus struct {
 
 	// NodePools indicates the list of node pools selected by YurtAppDaemon
 	NodePools []string `json:"nodepools,omitempty"`
+
+	// Replicas is the total number of desired replicas of the workloads in all node pools.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ReadyReplicas is the total number of ready replicas of the workloads in all node pools.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// UpdatedReplicas is the total number of replicas of the workloads in all node pools
+	// which are running the expected revision.
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// WorkloadStatuses records the status of the workload in each selected node pool.
+	// +optional
+	WorkloadStatuses []NodePoolWorkloadStatus `json:"workloadStatuses,omitempty"`
+}
+
+// NodePoolWorkloadStatus describes the observed state of the workload in one node pool.
+type NodePoolWorkloadStatus struct {
+	// NodePool is the name of the node pool.
+	NodePool string `json:"nodePool"`
+
+	// WorkloadName is the name of the workload created for the node pool.
+	// +optional
+	WorkloadName string `json:"workloadName,omitempty"`
+
+	// Revision is the revision of the workload.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Replicas is the number of desired replicas of the workload. For a DaemonSet,
+	// it is the number of nodes the DaemonSet should be scheduled to.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ReadyReplicas is the number of ready replicas of the workload.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// UpdatedReplicas is the number of replicas of the workload which are running the expected revision.
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// LastError is the last error met when creating or updating the workload.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// NotReadySince is the time since when the nodepool has had less ready nodes
+	// than the MinReadyNodes of the NodePoolPolicy.
+	// +optional
+	NotReadySince *metav1.Time `json:"notReadySince,omitempty"`
 }
 
 // YurtAppDaemonCondition describes current state of a YurtAppDaemon.
@@ -104,6 +259,9 @@ type YurtAppDaemonCondition struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=yad
 // +kubebuilder:printcolumn:name="WorkloadTemplate",type="string",JSONPath=".status.templateType",description="The WorkloadTemplate Type."
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas",description="The desired number of pods in all node pools."
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="The number of pods ready in all node pools."
+// +kubebuilder:printcolumn:name="Updated",type="integer",JSONPath=".status.updatedReplicas",description="The number of pods updated to the expected revision in all node pools."
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="CreationTimestamp is a timestamp representing the server time when this object was created. It is not guaranteed to be set in happens-before order across separate operations. Clients may not set this value. It is represented in RFC3339 form and is in UTC."
 
 // YurtAppDaemon is the Schema for the YurtAppDaemon API
@@ -23,6 +23,7 @@ package v1alpha1
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,6 +34,20 @@ type TemplateType string
 const (
 	StatefulSetTemplateType TemplateType = "StatefulSet"
 	DeploymentTemplateType  TemplateType = "Deployment"
+	DaemonSetTemplateType   TemplateType = "DaemonSet"
+	CustomTemplateType      TemplateType = "Custom"
+)
+
+// PatchType indicates how the patch of a pool is applied to the pool workload.
+type PatchType string
+
+const (
+	// StrategicMergePatchType applies the patch as a strategic merge patch.
+	StrategicMergePatchType PatchType = "StrategicMerge"
+	// JSONPatchType applies the patch as a RFC 6902 JSON patch.
+	JSONPatchType PatchType = "JSONPatch"
+	// MergePatchType applies the patch as a RFC 7386 JSON merge patch.
+	MergePatchType PatchType = "Merge"
 )
 
 // YurtAppSetConditionType indicates valid conditions type of a YurtAppSet.
@@ -45,10 +60,41 @@ const (
 	PoolUpdated YurtAppSetConditionType = "PoolUpdated"
 	// PoolFailure is added to a YurtAppSet when one of its pools has failure during its own reconciling.
 	PoolFailure YurtAppSetConditionType = "PoolFailure"
+	// RolloutPaused means the rollout is paused after a wave and waits to be resumed.
+	RolloutPaused YurtAppSetConditionType = "RolloutPaused"
+	// RolloutHalted means the rollout is halted as the pools of a wave fail to become ready.
+	RolloutHalted YurtAppSetConditionType = "RolloutHalted"
+)
+
+// RolloutPhase indicates the phase of the rollout of a YurtAppSet.
+type RolloutPhase string
+
+const (
+	// RolloutProgressing means the pools are being updated to the new revision.
+	RolloutProgressing RolloutPhase = "Progressing"
+	// RolloutPausedPhase means the rollout is paused after a wave.
+	RolloutPausedPhase RolloutPhase = "Paused"
+	// RolloutHaltedPhase means the rollout is halted because of the unready pools.
+	RolloutHaltedPhase RolloutPhase = "Halted"
+	// RolloutCompleted means all the pools have been updated and are ready.
+	RolloutCompleted RolloutPhase = "Completed"
+
+	// DefaultRolloutWaveName is the name of the last wave that contains the
+	// pools not listed in any wave of the rollout strategy.
+	DefaultRolloutWaveName = "default"
 )
 
 // YurtAppSetSpec defines the desired state of YurtAppSet.
 type YurtAppSetSpec struct {
+	// Replicas is the total number of the pods to be distributed between the
+	// pools. If it is set, the replicas of each pool will be calculated
+	// according to the fixed replicas, the percentage, the weight and the
+	// replicas bounds of the pools. Otherwise, the replicas of each pool is
+	// determined only by the Replicas of the pool. The autoscaled pools are
+	// not counted, their replicas are owned by their autoscalers.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
 	// Selector is a label query over pods that should match the replica count.
 	// It must match the pod template's labels.
 	Selector *metav1.LabelSelector `json:"selector"`
@@ -65,11 +111,54 @@ type YurtAppSetSpec struct {
 	// If unspecified, defaults to 10.
 	// +optional
 	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// RolloutStrategy indicates how the pools are updated to a new revision.
+	// If it is not set, all the pools are updated at once.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+// RolloutStrategy defines the staged rollout of a new revision across pools.
+type RolloutStrategy struct {
+	// Waves are the ordered groups of pools to be updated one after another,
+	// a wave starts only when all the pools of the previous waves are updated
+	// and ready. The pools not listed in any wave are updated in the last wave.
+	// +optional
+	Waves []RolloutWave `json:"waves,omitempty"`
+
+	// MaxUnavailablePools is the maximum number of pools that can be
+	// unavailable during the rollout, including the pools being updated.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxUnavailablePools *int32 `json:"maxUnavailablePools,omitempty"`
+
+	// ProgressDeadlineSeconds is the maximum time in seconds for the rollout
+	// to make progress, the rollout is halted if the updated pools fail to
+	// become ready in time. Defaults to 600.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+}
+
+// RolloutWave defines a group of pools updated together.
+type RolloutWave struct {
+	// Name is the name of the wave, it should be unique in the rollout strategy.
+	Name string `json:"name"`
+
+	// Pools are the names of the pools in the wave.
+	Pools []string `json:"pools"`
+
+	// Pause indicates the rollout pauses once the pools of this wave are
+	// updated and ready, until the YurtAppSet is annotated with
+	// `apps.openyurt.io/rollout-resume: <wave name>`.
+	// +optional
+	Pause bool `json:"pause,omitempty"`
 }
 
 // WorkloadTemplate defines the pool template under the YurtAppSet.
 // YurtAppSet will provision every pool based on one workload templates in WorkloadTemplate.
-// WorkloadTemplate now support statefulset and deployment
+// WorkloadTemplate now support statefulset, deployment and daemonset
 // Only one of its members may be specified.
 type WorkloadTemplate struct {
 	// StatefulSet template
@@ -79,6 +168,15 @@ type WorkloadTemplate struct {
 	// Deployment template
 	// +optional
 	DeploymentTemplate *DeploymentTemplateSpec `json:"deploymentTemplate,omitempty"`
+
+	// DaemonSet template
+	// +optional
+	DaemonSetTemplate *DaemonSetTemplateSpec `json:"daemonSetTemplate,omitempty"`
+
+	// Custom workload template, the kind of the workload is registered by a WorkloadDefinition.
+	// It is only supported by YurtAppSet.
+	// +optional
+	CustomTemplate *CustomTemplateSpec `json:"customTemplate,omitempty"`
 }
 
 // StatefulSetTemplateSpec defines the pool template of StatefulSet.
@@ -101,6 +199,30 @@ type DeploymentTemplateSpec struct {
 	Spec appsv1.DeploymentSpec `json:"spec"`
 }
 
+// DaemonSetTemplateSpec defines the pool template of DaemonSet.
+type DaemonSetTemplateSpec struct {
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Spec appsv1.DaemonSetSpec `json:"spec"`
+}
+
+// CustomTemplateSpec defines the pool template of a workload registered by a WorkloadDefinition.
+type CustomTemplateSpec struct {
+	// Definition is the name of the WorkloadDefinition of the workload.
+	Definition string `json:"definition"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Spec is the spec of the workload. The replicas, selector and the pod template
+	// located by the WorkloadDefinition are managed by YurtAppSet.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Spec runtime.RawExtension `json:"spec"`
+}
+
 // Topology defines the spread detail of each pool under YurtAppSet.
 // A YurtAppSet manages multiple homogeneous workloads which are called pool.
 // Each of pools under the YurtAppSet is described in Topology.
@@ -109,6 +231,27 @@ type Topology struct {
 	// which will be provisioned and managed by YurtAppSet.
 	// +optional
 	Pools []Pool `json:"pools,omitempty"`
+
+	// NodePoolSelector is a label query over the nodepools that the
+	// YurtAppSet will be deployed to. Each selected nodepool that is not
+	// listed in Pools will be provisioned as a pool with the DefaultReplicas,
+	// the pool listed in Pools with the same name overrides the default
+	// configuration of the selected nodepool.
+	// +optional
+	NodePoolSelector *metav1.LabelSelector `json:"nodePoolSelector,omitempty"`
+
+	// DefaultReplicas indicates the number of the pod to be created under
+	// each pool selected by the NodePoolSelector and not listed in Pools.
+	// If it is not set, the selected pools are weighted equally when the
+	// replicas of the YurtAppSet is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	DefaultReplicas *int32 `json:"defaultReplicas,omitempty"`
+
+	// DefaultAutoscaler indicates the autoscaler of each pool selected by
+	// the NodePoolSelector and not listed in Pools.
+	// +optional
+	DefaultAutoscaler *PoolAutoscaler `json:"defaultAutoscaler,omitempty"`
 }
 
 // Pool defines the detail of a pool.
@@ -134,12 +277,86 @@ type Pool struct {
 	// +required
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// Indicates the percentage of the YurtAppSet replicas to be created under
+	// this pool, the result is rounded down. It only takes effect when the
+	// replicas of the YurtAppSet is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percentage *int32 `json:"percentage,omitempty"`
+
+	// Indicates the relative weight of this pool when distributing the
+	// replicas that are left after the fixed and percentage pools. The pools
+	// that specify none of replicas, percentage, weight and elastic are
+	// regarded as weight 1. It only takes effect when the replicas of the
+	// YurtAppSet is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Weight *int32 `json:"weight,omitempty"`
+
+	// Indicates the lower bound of the replicas of this pool.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// Indicates the upper bound of the replicas of this pool.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// Indicates this pool takes the replicas that can not be placed in the
+	// weighted pools because of their upper bounds. At most one pool can be
+	// elastic.
+	// +optional
+	Elastic bool `json:"elastic,omitempty"`
+
 	// Indicates the patch for the templateSpec
-	// Now support strategic merge path :https://kubernetes.io/docs/tasks/manage-kubernetes-objects/update-api-object-kubectl-patch/#notes-on-the-strategic-merge-patch
+	// The patch is a strategic merge patch by default, see PatchType :https://kubernetes.io/docs/tasks/manage-kubernetes-objects/update-api-object-kubectl-patch/#notes-on-the-strategic-merge-patch
 	// Patch takes precedence over Replicas fields
 	// If the Patch also modifies the Replicas, use the Replicas value in the Patch
 	// +optional
 	Patch *runtime.RawExtension `json:"patch,omitempty"`
+
+	// Indicates how the Patch is applied to the pool workload, one of
+	// StrategicMerge, JSONPatch (RFC 6902) and Merge (RFC 7386).
+	// The workloads without strategic merge metadata, e.g. the custom
+	// workloads, are patched by Merge if StrategicMerge is specified.
+	// Defaults to StrategicMerge.
+	// +optional
+	// +kubebuilder:validation:Enum=StrategicMerge;JSONPatch;Merge
+	PatchType PatchType `json:"patchType,omitempty"`
+
+	// Indicates the HorizontalPodAutoscaler generated for the workload of
+	// this pool. The replicas of an autoscaled pool are owned by the
+	// autoscaler instead of the YurtAppSet controller, the pool takes no
+	// share of the Replicas of the YurtAppSet, and starts with its own
+	// Replicas bounded by the autoscaler when it is created. The Patch
+	// should not modify the replicas.
+	// +optional
+	Autoscaler *PoolAutoscaler `json:"autoscaler,omitempty"`
+}
+
+// PoolAutoscaler describes the HorizontalPodAutoscaler of the workload of a pool.
+type PoolAutoscaler struct {
+	// MinReplicas is the lower limit for the number of replicas of the pool.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit for the number of replicas of the pool.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// Metrics contains the specifications used to calculate the desired
+	// replicas of the pool. If not set, the default metric of the
+	// HorizontalPodAutoscaler, 80% average CPU utilization, is used.
+	// +optional
+	Metrics []autoscalingv2beta2.MetricSpec `json:"metrics,omitempty"`
+
+	// Behavior configures the scaling behavior of the pool in both Up and Down directions.
+	// +optional
+	Behavior *autoscalingv2beta2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
 }
 
 // YurtAppSetStatus defines the observed state of YurtAppSet.
@@ -173,8 +390,47 @@ type YurtAppSetStatus struct {
 	// Replicas is the most recently observed number of replicas.
 	Replicas int32 `json:"replicas"`
 
+	// LabelSelector is the label selector of the pods of the YurtAppSet in
+	// string form, which is used by the scale subresource.
+	// +optional
+	LabelSelector string `json:"labelSelector,omitempty"`
+
 	// TemplateType indicates the type of PoolTemplate
 	TemplateType TemplateType `json:"templateType"`
+
+	// Rollout records the progress of rolling out the latest revision.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+}
+
+// RolloutStatus records the progress of the rollout of a YurtAppSet.
+type RolloutStatus struct {
+	// Revision is the revision being rolled out.
+	Revision string `json:"revision"`
+
+	// Phase is the phase of the rollout.
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+
+	// CurrentWave is the name of the wave being rolled out or paused after.
+	// +optional
+	CurrentWave string `json:"currentWave,omitempty"`
+
+	// CompletedPools are the pools that have been updated and are ready.
+	// +optional
+	CompletedPools []string `json:"completedPools,omitempty"`
+
+	// ResumedWaves are the waves whose pause has been resumed.
+	// +optional
+	ResumedWaves []string `json:"resumedWaves,omitempty"`
+
+	// LastProgressTime is the last time the rollout made progress.
+	// +optional
+	LastProgressTime *metav1.Time `json:"lastProgressTime,omitempty"`
+
+	// Message is a human readable message about the rollout.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // YurtAppSetCondition describes current state of a YurtAppSet.
@@ -198,8 +454,10 @@ type YurtAppSetCondition struct {
 // +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.labelSelector
 // +kubebuilder:resource:shortName=yas
 // +kubebuilder:printcolumn:name="READY",type="integer",JSONPath=".status.readyReplicas",description="The number of pods ready."
+// +kubebuilder:printcolumn:name="ROLLOUT",type="string",JSONPath=".status.rollout.phase",description="The phase of the rollout."
 // +kubebuilder:printcolumn:name="WorkloadTemplate",type="string",JSONPath=".status.templateType",description="The WorkloadTemplate Type."
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="CreationTimestamp is a timestamp representing the server time when this object was created. It is not guaranteed to be set in happens-before order across separate operations. Clients may not set this value. It is represented in RFC3339 form and is in UTC."
 
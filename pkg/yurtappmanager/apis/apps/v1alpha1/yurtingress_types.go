@@ -17,12 +17,31 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // YurtIngressFinalizer is used to cleanup ingress resources when YurtIngress CR is deleted
 const YurtIngressFinalizer string = "ingress.operator.openyurt.io"
 
+// IngressProvider indicates the ingress controller implementation deployed by YurtIngress.
+type IngressProvider string
+
+const (
+	NginxIngressProvider   IngressProvider = "nginx"
+	TraefikIngressProvider IngressProvider = "traefik"
+)
+
+// WebhookCertManagement indicates how the certificates of the ingress admission webhooks are managed.
+type WebhookCertManagement string
+
+const (
+	// JobWebhookCertManagement generates the certificates by the certgen jobs.
+	JobWebhookCertManagement WebhookCertManagement = "Job"
+	// ControllerWebhookCertManagement generates and rotates the certificates by yurt-app-manager.
+	ControllerWebhookCertManagement WebhookCertManagement = "Controller"
+)
+
 type IngressNotReadyType string
 
 const (
@@ -37,6 +56,97 @@ type IngressPool struct {
 
 	// IngressIPs is a list of IP addresses for which nodes will also accept traffic for this service.
 	IngressIPs []string `json:"ingressIPs,omitempty"`
+
+	// Indicates the number of the ingress controllers deployed on the pool,
+	// it overrides the replicas per pool of YurtIngress.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Indicates the ingress controller image url used on the pool,
+	// it overrides the ingress controller image of YurtIngress.
+	// +optional
+	IngressControllerImage string `json:"ingressControllerImage,omitempty"`
+
+	// Indicates the compute resources of the ingress controller on the pool.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Indicates the extra node selector of the ingress controller on the pool,
+	// the ingress controller is always scheduled to the nodes of the pool.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Indicates the tolerations of the ingress controller on the pool,
+	// the ingress controller tolerates all the taints by default.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Indicates the configuration of the ingress controller on the pool,
+	// it is merged with the default ConfigMap of ingress-nginx and only
+	// supported by the nginx provider.
+	// +optional
+	ConfigMapData map[string]string `json:"configMapData,omitempty"`
+
+	// Indicates the extra args appended to the ingress controller on the pool.
+	// +optional
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+
+	// Indicates how the ingress controller on the pool is exposed,
+	// the ingress controller is exposed by a NodePort Service by default.
+	// +optional
+	Exposure *IngressPoolExposure `json:"exposure,omitempty"`
+}
+
+// IngressExposeMode indicates how the ingress controller on the pool is exposed.
+type IngressExposeMode string
+
+const (
+	// NodePortExposeMode exposes the ingress controller by a NodePort Service.
+	NodePortExposeMode IngressExposeMode = "NodePort"
+	// LoadBalancerExposeMode exposes the ingress controller by a LoadBalancer Service.
+	LoadBalancerExposeMode IngressExposeMode = "LoadBalancer"
+	// HostNetworkExposeMode runs the ingress controller as a DaemonSet in the host network.
+	HostNetworkExposeMode IngressExposeMode = "HostNetwork"
+	// HostPortExposeMode runs the ingress controller as a DaemonSet listening on the host ports 80 and 443.
+	HostPortExposeMode IngressExposeMode = "HostPort"
+)
+
+// IngressPoolExposure defines how the ingress controller on the pool is exposed.
+type IngressPoolExposure struct {
+	// Indicates the expose mode, one of NodePort, LoadBalancer, HostNetwork and HostPort.
+	// Defaults to NodePort.
+	// +optional
+	// +kubebuilder:validation:Enum=NodePort;LoadBalancer;HostNetwork;HostPort
+	Mode IngressExposeMode `json:"mode,omitempty"`
+
+	// Indicates the fixed node port of http, it is allocated by kubernetes if not set.
+	// Only used by the NodePort and LoadBalancer modes.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	HTTPNodePort int32 `json:"httpNodePort,omitempty"`
+
+	// Indicates the fixed node port of https, it is allocated by kubernetes if not set.
+	// Only used by the NodePort and LoadBalancer modes.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	HTTPSNodePort int32 `json:"httpsNodePort,omitempty"`
+
+	// Indicates the ip requested from the load balancer, only used by the LoadBalancer mode.
+	// +optional
+	LoadBalancerIP string `json:"loadBalancerIP,omitempty"`
+
+	// Indicates the annotations added to the Service, e.g. the configuration of the load balancer.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Indicates whether the external traffic is routed to node-local or cluster-wide
+	// endpoints, one of Cluster and Local. Only used by the NodePort and LoadBalancer modes.
+	// +optional
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
 }
 
 // IngressNotReadyConditionInfo defines the details info of an ingress not ready Pool
@@ -65,6 +175,18 @@ type IngressNotReadyPool struct {
 
 // YurtIngressSpec defines the desired state of YurtIngress
 type YurtIngressSpec struct {
+	// Indicates the ingress controller implementation to be deployed, one of nginx and traefik.
+	// Defaults to nginx. Provider is not allowed to be updated.
+	// +optional
+	// +kubebuilder:validation:Enum=nginx;traefik
+	Provider IngressProvider `json:"provider,omitempty"`
+
+	// Indicates the namespace in which the ingress controllers are deployed, YurtIngress
+	// instances of different providers can not share a namespace. Defaults to ingress-nginx
+	// for nginx and ingress-traefik for traefik. Namespace is not allowed to be updated.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
 	// Indicates the number of the ingress controllers to be deployed under all the specified nodepools.
 	// +optional
 	Replicas int32 `json:"ingressControllerReplicasPerPool,omitempty"`
@@ -73,10 +195,18 @@ type YurtIngressSpec struct {
 	// +optional
 	IngressControllerImage string `json:"ingressControllerImage,omitempty"`
 
-	// Indicates the ingress webhook image url.
+	// Indicates the ingress webhook image url, it is only used by the nginx provider.
 	// +optional
 	IngressWebhookCertGenImage string `json:"ingressWebhookCertGenImage,omitempty"`
 
+	// Indicates how the certificates of the admission webhooks are managed, one of Job and Controller.
+	// Job generates the certificates by the jobs running the ingress webhook image, Controller generates
+	// and rotates the certificates by yurt-app-manager itself without pulling any image.
+	// Defaults to Job, it is only used by the nginx provider.
+	// +optional
+	// +kubebuilder:validation:Enum=Job;Controller
+	WebhookCertManagement WebhookCertManagement `json:"webhookCertManagement,omitempty"`
+
 	// Indicates all the nodepools on which to enable ingress.
 	// +optional
 	Pools []IngressPool `json:"pools,omitempty"`
@@ -109,6 +239,10 @@ type YurtIngressStatus struct {
 	// +optional
 	IngressWebhookCertGenImage string `json:"ingressWebhookCertGenImage"`
 
+	// Indicates how the certificates of the admission webhooks are managed.
+	// +optional
+	WebhookCertManagement WebhookCertManagement `json:"webhookCertManagement,omitempty"`
+
 	// Total number of ready pools on which ingress is enabled.
 	// +optional
 	ReadyNum int32 `json:"readyNum"`
@@ -120,6 +254,7 @@ type YurtIngressStatus struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster,path=yurtingresses,shortName=ying,categories=all
+// +kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider",description="The ingress controller implementation"
 // +kubebuilder:printcolumn:name="Replicas-Per-Pool",type="integer",JSONPath=".status.ingress_controller_replicas_per_pool",description="The nginx ingress controller replicas per pool"
 // +kubebuilder:printcolumn:name="ReadyNum",type="integer",JSONPath=".status.readyNum",description="The number of pools on which ingress is enabled"
 // +kubebuilder:printcolumn:name="NotReadyNum",type="integer",JSONPath=".status.unreadyNum",description="The number of pools on which ingress is enabling or enable failed"
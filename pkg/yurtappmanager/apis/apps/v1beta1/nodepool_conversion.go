@@ -39,12 +39,31 @@ func (src *NodePool) ConvertTo(dstRaw conversion.Hub) error {
 
 	dst.Spec.Type = v1alpha1.NodePoolType(src.Spec.Type)
 	dst.Spec.Selector = src.Spec.Selector
+	dst.Spec.AdoptBySelector = src.Spec.AdoptBySelector
 	dst.Spec.Annotations = src.Spec.Annotations
 	dst.Spec.Taints = src.Spec.Taints
 
 	dst.Status.ReadyNodeNum = src.Status.ReadyNodeNum
 	dst.Status.UnreadyNodeNum = src.Status.UnreadyNodeNum
+	dst.Status.AutonomousNodeNum = src.Status.AutonomousNodeNum
+	dst.Status.Capacity = src.Status.Capacity
+	dst.Status.Allocatable = src.Status.Allocatable
+	dst.Status.Requested = src.Status.Requested
 	dst.Status.Nodes = src.Status.Nodes
+	dst.Status.Conditions = src.Status.Conditions
+	dst.Status.ConflictedNodes = src.Status.ConflictedNodes
+	dst.Status.NodeSummaries = nil
+	for _, ns := range src.Status.NodeSummaries {
+		dst.Status.NodeSummaries = append(dst.Status.NodeSummaries, v1alpha1.NodeSummary{
+			Name:               ns.Name,
+			Ready:              ns.Ready,
+			LastHeartbeatTime:  ns.LastHeartbeatTime,
+			Unschedulable:      ns.Unschedulable,
+			PressureConditions: ns.PressureConditions,
+			Autonomy:           ns.Autonomy,
+			LeaseStale:         ns.LeaseStale,
+		})
+	}
 
 	klog.Infof("convert from v1beta1 to v1alpha1 for %s", dst.Name)
 
@@ -58,12 +77,31 @@ func (dst *NodePool) ConvertFrom(srcRaw conversion.Hub) error {
 
 	dst.Spec.Type = NodePoolType(src.Spec.Type)
 	dst.Spec.Selector = src.Spec.Selector
+	dst.Spec.AdoptBySelector = src.Spec.AdoptBySelector
 	dst.Spec.Annotations = src.Spec.Annotations
 	dst.Spec.Taints = src.Spec.Taints
 
 	dst.Status.ReadyNodeNum = src.Status.ReadyNodeNum
 	dst.Status.UnreadyNodeNum = src.Status.UnreadyNodeNum
+	dst.Status.AutonomousNodeNum = src.Status.AutonomousNodeNum
+	dst.Status.Capacity = src.Status.Capacity
+	dst.Status.Allocatable = src.Status.Allocatable
+	dst.Status.Requested = src.Status.Requested
 	dst.Status.Nodes = src.Status.Nodes
+	dst.Status.Conditions = src.Status.Conditions
+	dst.Status.ConflictedNodes = src.Status.ConflictedNodes
+	dst.Status.NodeSummaries = nil
+	for _, ns := range src.Status.NodeSummaries {
+		dst.Status.NodeSummaries = append(dst.Status.NodeSummaries, NodeSummary{
+			Name:               ns.Name,
+			Ready:              ns.Ready,
+			LastHeartbeatTime:  ns.LastHeartbeatTime,
+			Unschedulable:      ns.Unschedulable,
+			PressureConditions: ns.PressureConditions,
+			Autonomy:           ns.Autonomy,
+			LeaseStale:         ns.LeaseStale,
+		})
+	}
 
 	klog.Infof("convert from v1alpha1 to v1beta1 for %s", dst.Name)
 	return nil
@@ -28,6 +28,7 @@ type AppsV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	NodePoolsGetter
 	UnitedDeploymentsGetter
+	WorkloadDefinitionsGetter
 	YurtAppDaemonsGetter
 	YurtAppSetsGetter
 	YurtIngressesGetter
@@ -46,6 +47,10 @@ func (c *AppsV1alpha1Client) UnitedDeployments(namespace string) UnitedDeploymen
 	return newUnitedDeployments(c, namespace)
 }
 
+func (c *AppsV1alpha1Client) WorkloadDefinitions() WorkloadDefinitionInterface {
+	return newWorkloadDefinitions(c)
+}
+
 func (c *AppsV1alpha1Client) YurtAppDaemons(namespace string) YurtAppDaemonInterface {
 	return newYurtAppDaemons(c, namespace)
 }
@@ -36,6 +36,10 @@ func (c *FakeAppsV1alpha1) UnitedDeployments(namespace string) v1alpha1.UnitedDe
 	return &FakeUnitedDeployments{c, namespace}
 }
 
+func (c *FakeAppsV1alpha1) WorkloadDefinitions() v1alpha1.WorkloadDefinitionInterface {
+	return &FakeWorkloadDefinitions{c}
+}
+
 func (c *FakeAppsV1alpha1) YurtAppDaemons(namespace string) v1alpha1.YurtAppDaemonInterface {
 	return &FakeYurtAppDaemons{c, namespace}
 }
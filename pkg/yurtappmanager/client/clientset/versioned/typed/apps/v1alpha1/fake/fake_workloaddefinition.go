@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeWorkloadDefinitions implements WorkloadDefinitionInterface
+type FakeWorkloadDefinitions struct {
+	Fake *FakeAppsV1alpha1
+}
+
+var workloaddefinitionsResource = schema.GroupVersionResource{Group: "apps.openyurt.io", Version: "v1alpha1", Resource: "workloaddefinitions"}
+
+var workloaddefinitionsKind = schema.GroupVersionKind{Group: "apps.openyurt.io", Version: "v1alpha1", Kind: "WorkloadDefinition"}
+
+// Get takes name of the workloadDefinition, and returns the corresponding workloadDefinition object, and an error if there is any.
+func (c *FakeWorkloadDefinitions) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.WorkloadDefinition, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(workloaddefinitionsResource, name), &v1alpha1.WorkloadDefinition{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadDefinition), err
+}
+
+// List takes label and field selectors, and returns the list of WorkloadDefinitions that match those selectors.
+func (c *FakeWorkloadDefinitions) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.WorkloadDefinitionList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(workloaddefinitionsResource, workloaddefinitionsKind, opts), &v1alpha1.WorkloadDefinitionList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.WorkloadDefinitionList{ListMeta: obj.(*v1alpha1.WorkloadDefinitionList).ListMeta}
+	for _, item := range obj.(*v1alpha1.WorkloadDefinitionList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested workloadDefinitions.
+func (c *FakeWorkloadDefinitions) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(workloaddefinitionsResource, opts))
+}
+
+// Create takes the representation of a workloadDefinition and creates it.  Returns the server's representation of the workloadDefinition, and an error, if there is any.
+func (c *FakeWorkloadDefinitions) Create(ctx context.Context, workloadDefinition *v1alpha1.WorkloadDefinition, opts v1.CreateOptions) (result *v1alpha1.WorkloadDefinition, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(workloaddefinitionsResource, workloadDefinition), &v1alpha1.WorkloadDefinition{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadDefinition), err
+}
+
+// Update takes the representation of a workloadDefinition and updates it. Returns the server's representation of the workloadDefinition, and an error, if there is any.
+func (c *FakeWorkloadDefinitions) Update(ctx context.Context, workloadDefinition *v1alpha1.WorkloadDefinition, opts v1.UpdateOptions) (result *v1alpha1.WorkloadDefinition, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(workloaddefinitionsResource, workloadDefinition), &v1alpha1.WorkloadDefinition{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadDefinition), err
+}
+
+// Delete takes name of the workloadDefinition and deletes it. Returns an error if one occurs.
+func (c *FakeWorkloadDefinitions) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(workloaddefinitionsResource, name), &v1alpha1.WorkloadDefinition{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeWorkloadDefinitions) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(workloaddefinitionsResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.WorkloadDefinitionList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched workloadDefinition.
+func (c *FakeWorkloadDefinitions) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.WorkloadDefinition, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(workloaddefinitionsResource, name, pt, data, subresources...), &v1alpha1.WorkloadDefinition{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadDefinition), err
+}
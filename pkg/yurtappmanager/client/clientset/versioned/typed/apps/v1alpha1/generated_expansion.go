@@ -22,6 +22,8 @@ type NodePoolExpansion interface{}
 
 type UnitedDeploymentExpansion interface{}
 
+type WorkloadDefinitionExpansion interface{}
+
 type YurtAppDaemonExpansion interface{}
 
 type YurtAppSetExpansion interface{}
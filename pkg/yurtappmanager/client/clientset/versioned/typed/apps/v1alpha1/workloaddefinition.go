@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	scheme "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// WorkloadDefinitionsGetter has a method to return a WorkloadDefinitionInterface.
+// A group's client should implement this interface.
+type WorkloadDefinitionsGetter interface {
+	WorkloadDefinitions() WorkloadDefinitionInterface
+}
+
+// WorkloadDefinitionInterface has methods to work with WorkloadDefinition resources.
+type WorkloadDefinitionInterface interface {
+	Create(ctx context.Context, workloadDefinition *v1alpha1.WorkloadDefinition, opts v1.CreateOptions) (*v1alpha1.WorkloadDefinition, error)
+	Update(ctx context.Context, workloadDefinition *v1alpha1.WorkloadDefinition, opts v1.UpdateOptions) (*v1alpha1.WorkloadDefinition, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.WorkloadDefinition, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.WorkloadDefinitionList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.WorkloadDefinition, err error)
+	WorkloadDefinitionExpansion
+}
+
+// workloadDefinitions implements WorkloadDefinitionInterface
+type workloadDefinitions struct {
+	client rest.Interface
+}
+
+// newWorkloadDefinitions returns a WorkloadDefinitions
+func newWorkloadDefinitions(c *AppsV1alpha1Client) *workloadDefinitions {
+	return &workloadDefinitions{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the workloadDefinition, and returns the corresponding workloadDefinition object, and an error if there is any.
+func (c *workloadDefinitions) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.WorkloadDefinition, err error) {
+	result = &v1alpha1.WorkloadDefinition{}
+	err = c.client.Get().
+		Resource("workloaddefinitions").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of WorkloadDefinitions that match those selectors.
+func (c *workloadDefinitions) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.WorkloadDefinitionList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.WorkloadDefinitionList{}
+	err = c.client.Get().
+		Resource("workloaddefinitions").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested workloadDefinitions.
+func (c *workloadDefinitions) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("workloaddefinitions").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a workloadDefinition and creates it.  Returns the server's representation of the workloadDefinition, and an error, if there is any.
+func (c *workloadDefinitions) Create(ctx context.Context, workloadDefinition *v1alpha1.WorkloadDefinition, opts v1.CreateOptions) (result *v1alpha1.WorkloadDefinition, err error) {
+	result = &v1alpha1.WorkloadDefinition{}
+	err = c.client.Post().
+		Resource("workloaddefinitions").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(workloadDefinition).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a workloadDefinition and updates it. Returns the server's representation of the workloadDefinition, and an error, if there is any.
+func (c *workloadDefinitions) Update(ctx context.Context, workloadDefinition *v1alpha1.WorkloadDefinition, opts v1.UpdateOptions) (result *v1alpha1.WorkloadDefinition, err error) {
+	result = &v1alpha1.WorkloadDefinition{}
+	err = c.client.Put().
+		Resource("workloaddefinitions").
+		Name(workloadDefinition.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(workloadDefinition).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the workloadDefinition and deletes it. Returns an error if one occurs.
+func (c *workloadDefinitions) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("workloaddefinitions").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *workloadDefinitions) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("workloaddefinitions").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched workloadDefinition.
+func (c *workloadDefinitions) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.WorkloadDefinition, err error) {
+	result = &v1alpha1.WorkloadDefinition{}
+	err = c.client.Patch(pt).
+		Resource("workloaddefinitions").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
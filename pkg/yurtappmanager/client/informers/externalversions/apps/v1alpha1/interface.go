@@ -28,6 +28,8 @@ type Interface interface {
 	NodePools() NodePoolInformer
 	// UnitedDeployments returns a UnitedDeploymentInformer.
 	UnitedDeployments() UnitedDeploymentInformer
+	// WorkloadDefinitions returns a WorkloadDefinitionInformer.
+	WorkloadDefinitions() WorkloadDefinitionInformer
 	// YurtAppDaemons returns a YurtAppDaemonInformer.
 	YurtAppDaemons() YurtAppDaemonInformer
 	// YurtAppSets returns a YurtAppSetInformer.
@@ -57,6 +59,11 @@ func (v *version) UnitedDeployments() UnitedDeploymentInformer {
 	return &unitedDeploymentInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// WorkloadDefinitions returns a WorkloadDefinitionInformer.
+func (v *version) WorkloadDefinitions() WorkloadDefinitionInformer {
+	return &workloadDefinitionInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // YurtAppDaemons returns a YurtAppDaemonInformer.
 func (v *version) YurtAppDaemons() YurtAppDaemonInformer {
 	return &yurtAppDaemonInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
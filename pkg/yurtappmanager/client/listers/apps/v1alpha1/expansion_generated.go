@@ -30,6 +30,10 @@ type UnitedDeploymentListerExpansion interface{}
 // UnitedDeploymentNamespaceLister.
 type UnitedDeploymentNamespaceListerExpansion interface{}
 
+// WorkloadDefinitionListerExpansion allows custom methods to be added to
+// WorkloadDefinitionLister.
+type WorkloadDefinitionListerExpansion interface{}
+
 // YurtAppDaemonListerExpansion allows custom methods to be added to
 // YurtAppDaemonLister.
 type YurtAppDaemonListerExpansion interface{}
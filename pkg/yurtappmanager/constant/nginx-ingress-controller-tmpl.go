@@ -21,7 +21,7 @@ const (
 apiVersion: v1
 kind: Namespace
 metadata:
-  name: ingress-nginx
+  name: {{.namespace}}
   labels:
     app.kubernetes.io/name: ingress-nginx
     app.kubernetes.io/instance: ingress-nginx
@@ -124,7 +124,7 @@ metadata:
     app.kubernetes.io/instance: ingress-nginx
     app.kubernetes.io/component: controller
   name: ingress-nginx
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
 automountServiceAccountToken: true
 `
 	NginxIngressControllerConfigMap = `
@@ -137,7 +137,21 @@ metadata:
     app.kubernetes.io/instance: ingress-nginx
     app.kubernetes.io/component: controller
   name: ingress-nginx-controller
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
+data:
+  allow-snippet-annotations: 'true'
+`
+	NginxIngressControllerNodePoolConfigMap = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/instance: ingress-nginx
+    app.kubernetes.io/component: controller
+    yurtingress.io/nodepool: {{.nodepool_name}}
+  name: {{.nodepool_name}}-ingress-nginx-controller
+  namespace: {{.namespace}}
 data:
   allow-snippet-annotations: 'true'
 `
@@ -149,7 +163,7 @@ metadata:
   labels:
     app.kubernetes.io/name: ingress-nginx
     app.kubernetes.io/instance: ingress-nginx
-  name: ingress-nginx
+  name: ingress-nginx-{{.namespace}}
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
@@ -157,7 +171,7 @@ roleRef:
 subjects:
   - kind: ServiceAccount
     name: ingress-nginx
-    namespace: ingress-nginx
+    namespace: {{.namespace}}
 `
 	NginxIngressControllerRole = `
 # Source: ingress-nginx/templates/controller-role.yaml
@@ -169,7 +183,7 @@ metadata:
     app.kubernetes.io/instance: ingress-nginx
     app.kubernetes.io/component: controller
   name: ingress-nginx
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
 rules:
   - apiGroups:
       - ''
@@ -249,7 +263,7 @@ metadata:
     app.kubernetes.io/instance: ingress-nginx
     app.kubernetes.io/component: controller
   name: ingress-nginx
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: Role
@@ -257,7 +271,7 @@ roleRef:
 subjects:
   - kind: ServiceAccount
     name: ingress-nginx
-    namespace: ingress-nginx
+    namespace: {{.namespace}}
 `
 	NginxIngressAdmissionWebhookService = `
 # Source: ingress-nginx/templates/controller-service-webhook.yaml
@@ -269,7 +283,7 @@ metadata:
     app.kubernetes.io/instance: ingress-nginx-webhook
     app.kubernetes.io/component: controller-webhook
   name: {{.nodepool_name}}-ingress-nginx-controller-admission
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
 spec:
   type: ClusterIP
   ports:
@@ -293,7 +307,7 @@ metadata:
     app.kubernetes.io/instance: ingress-nginx
     app.kubernetes.io/component: controller
   name: {{.nodepool_name}}-ingress-nginx-controller
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
 spec:
   type: NodePort
   ipFamilyPolicy: SingleStack
@@ -325,7 +339,7 @@ metadata:
     app.kubernetes.io/component: controller
     yurtingress.io/nodepool: {{.nodepool_name}}
   name: {{.nodepool_name}}-ingress-nginx-controller
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
 spec:
   selector:
     matchLabels:
@@ -430,7 +444,7 @@ metadata:
     app.kubernetes.io/instance: ingress-nginx-webhook
     app.kubernetes.io/component: controller-webhook
   name: {{.nodepool_name}}-ingress-nginx-admission-webhook
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
 spec:
   selector:
     matchLabels:
@@ -571,7 +585,7 @@ webhooks:
       - v1
     clientConfig:
       service:
-        namespace: ingress-nginx
+        namespace: {{.namespace}}
         name: {{.nodepool_name}}-ingress-nginx-controller-admission
         path: /networking/v1/ingresses
 `
@@ -581,7 +595,7 @@ apiVersion: v1
 kind: ServiceAccount
 metadata:
   name: ingress-nginx-admission
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
   annotations:
     helm.sh/hook: pre-install,pre-upgrade,post-install,post-upgrade
     helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
@@ -595,7 +609,7 @@ metadata:
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: ingress-nginx-admission
+  name: ingress-nginx-admission-{{.namespace}}
   annotations:
     helm.sh/hook: pre-install,pre-upgrade,post-install,post-upgrade
     helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
@@ -610,7 +624,7 @@ roleRef:
 subjects:
   - kind: ServiceAccount
     name: ingress-nginx-admission
-    namespace: ingress-nginx
+    namespace: {{.namespace}}
 `
 	NginxIngressAdmissionWebhookRole = `
 # Source: ingress-nginx/templates/admission-webhooks/job-patch/role.yaml
@@ -618,7 +632,7 @@ apiVersion: rbac.authorization.k8s.io/v1
 kind: Role
 metadata:
   name: ingress-nginx-admission
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
   annotations:
     helm.sh/hook: pre-install,pre-upgrade,post-install,post-upgrade
     helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
@@ -641,7 +655,7 @@ apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
 metadata:
   name: ingress-nginx-admission
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
   annotations:
     helm.sh/hook: pre-install,pre-upgrade,post-install,post-upgrade
     helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
@@ -656,7 +670,7 @@ roleRef:
 subjects:
   - kind: ServiceAccount
     name: ingress-nginx-admission
-    namespace: ingress-nginx
+    namespace: {{.namespace}}
 `
 	NginxIngressAdmissionWebhookJob = `
 # Source: ingress-nginx/templates/admission-webhooks/job-patch/job-createSecret.yaml
@@ -664,7 +678,7 @@ apiVersion: batch/v1
 kind: Job
 metadata:
   name: {{.nodepool_name}}-ingress-nginx-admission-create
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
   annotations:
     helm.sh/hook: pre-install,pre-upgrade
     helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
@@ -716,7 +730,7 @@ apiVersion: batch/v1
 kind: Job
 metadata:
   name: {{.nodepool_name}}-ingress-nginx-admission-patch
-  namespace: ingress-nginx
+  namespace: {{.namespace}}
   annotations:
     helm.sh/hook: post-install,post-upgrade
     helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package constant
+
+const (
+	TraefikIngressControllerNamespace = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{.namespace}}
+  labels:
+    app.kubernetes.io/name: traefik
+    app.kubernetes.io/instance: ingress-traefik
+`
+	TraefikIngressControllerServiceAccount = `
+# Source: traefik/templates/rbac/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  labels:
+    app.kubernetes.io/name: traefik
+    app.kubernetes.io/instance: ingress-traefik
+  name: ingress-traefik
+  namespace: {{.namespace}}
+automountServiceAccountToken: true
+`
+	TraefikIngressControllerClusterRole = `
+# Source: traefik/templates/rbac/clusterrole.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  labels:
+    app.kubernetes.io/name: traefik
+    app.kubernetes.io/instance: ingress-traefik
+  name: ingress-traefik
+rules:
+  - apiGroups:
+      - ""
+    resources:
+      - services
+      - endpoints
+      - secrets
+    verbs:
+      - get
+      - list
+      - watch
+  - apiGroups:
+      - extensions
+      - networking.k8s.io
+    resources:
+      - ingresses
+      - ingressclasses
+    verbs:
+      - get
+      - list
+      - watch
+  - apiGroups:
+      - extensions
+      - networking.k8s.io
+    resources:
+      - ingresses/status
+    verbs:
+      - update
+`
+	TraefikIngressControllerClusterRoleBinding = `
+# Source: traefik/templates/rbac/clusterrolebinding.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  labels:
+    app.kubernetes.io/name: traefik
+    app.kubernetes.io/instance: ingress-traefik
+  name: traefik-{{.namespace}}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: ingress-traefik
+subjects:
+  - kind: ServiceAccount
+    name: ingress-traefik
+    namespace: {{.namespace}}
+`
+	TraefikIngressControllerService = `
+# Source: traefik/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app.kubernetes.io/name: traefik
+    app.kubernetes.io/instance: ingress-traefik
+    app.kubernetes.io/component: controller
+  name: {{.nodepool_name}}-ingress-traefik-controller
+  namespace: {{.namespace}}
+spec:
+  type: NodePort
+  ipFamilyPolicy: SingleStack
+  ipFamilies:
+    - IPv4
+  ports:
+    - name: http
+      port: 80
+      protocol: TCP
+      targetPort: web
+    - name: https
+      port: 443
+      protocol: TCP
+      targetPort: websecure
+  selector:
+    app.kubernetes.io/name: traefik
+    app.kubernetes.io/instance: ingress-traefik
+    app.kubernetes.io/component: controller
+    yurtingress.io/nodepool: {{.nodepool_name}}
+`
+	TraefikIngressControllerNodePoolDeployment = `
+# Source: traefik/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: traefik
+    app.kubernetes.io/instance: ingress-traefik
+    app.kubernetes.io/component: controller
+    yurtingress.io/nodepool: {{.nodepool_name}}
+  name: {{.nodepool_name}}-ingress-traefik-controller
+  namespace: {{.namespace}}
+spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: traefik
+      app.kubernetes.io/instance: ingress-traefik
+      app.kubernetes.io/component: controller
+      yurtingress.io/nodepool: {{.nodepool_name}}
+  revisionHistoryLimit: 10
+  minReadySeconds: 0
+  strategy:
+    type: Recreate
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: traefik
+        app.kubernetes.io/instance: ingress-traefik
+        app.kubernetes.io/component: controller
+        yurtingress.io/nodepool: {{.nodepool_name}}
+    spec:
+      containers:
+        - name: traefik
+          imagePullPolicy: IfNotPresent
+          args:
+            - --entrypoints.traefik.address=:9000/tcp
+            - --entrypoints.web.address=:8000/tcp
+            - --entrypoints.websecure.address=:8443/tcp
+            - --api.dashboard=false
+            - --ping=true
+            - --providers.kubernetesingress
+            - --providers.kubernetesingress.ingressclass={{.nodepool_name}}
+            - --providers.kubernetesingress.ingressendpoint.publishedservice={{.namespace}}/{{.nodepool_name}}-ingress-traefik-controller
+          securityContext:
+            capabilities:
+              drop:
+                - ALL
+            readOnlyRootFilesystem: true
+            runAsGroup: 65532
+            runAsNonRoot: true
+            runAsUser: 65532
+          livenessProbe:
+            failureThreshold: 3
+            httpGet:
+              path: /ping
+              port: 9000
+              scheme: HTTP
+            initialDelaySeconds: 10
+            periodSeconds: 10
+            successThreshold: 1
+            timeoutSeconds: 2
+          readinessProbe:
+            failureThreshold: 1
+            httpGet:
+              path: /ping
+              port: 9000
+              scheme: HTTP
+            initialDelaySeconds: 10
+            periodSeconds: 10
+            successThreshold: 1
+            timeoutSeconds: 2
+          ports:
+            - name: traefik
+              containerPort: 9000
+              protocol: TCP
+            - name: web
+              containerPort: 8000
+              protocol: TCP
+            - name: websecure
+              containerPort: 8443
+              protocol: TCP
+          resources:
+            requests:
+              cpu: 100m
+              memory: 50Mi
+          volumeMounts:
+            - name: data
+              mountPath: /data
+            - name: tmp
+              mountPath: /tmp
+      volumes:
+        - name: data
+          emptyDir: {}
+        - name: tmp
+          emptyDir: {}
+      nodeSelector:
+        kubernetes.io/os: linux
+        apps.openyurt.io/nodepool: {{.nodepool_name}}
+      serviceAccountName: ingress-traefik
+      terminationGracePeriodSeconds: 60
+      tolerations:
+      - operator: Exists
+`
+)
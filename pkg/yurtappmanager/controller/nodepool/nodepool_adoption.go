@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// conciliateAdoptedNodes adopts the nodes that match the selector of the
+// nodepool and releases the nodes that have been adopted by the nodepool
+// but don't match the selector anymore. The nodes that match the selector
+// of more than one nodepool will be returned as conflicted nodes.
+func (r *NodePoolReconciler) conciliateAdoptedNodes(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool) (conflicts []string, err error) {
+	var selector labels.Selector
+	if nodePool.Spec.AdoptBySelector && nodePool.Spec.Selector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(nodePool.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var selectedNodeList corev1.NodeList
+	if selector != nil {
+		if err := r.List(ctx, &selectedNodeList,
+			client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+	}
+
+	var poolList appsv1alpha1.NodePoolList
+	if selector != nil {
+		if err := r.List(ctx, &poolList); err != nil {
+			return nil, err
+		}
+	}
+	otherSelectors, err := getAdoptionSelectors(poolList.Items, nodePool.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	conflicted := make(map[string]bool)
+	for i := range selectedNodeList.Items {
+		node := &selectedNodeList.Items[i]
+		if pools := matchedPools(node, otherSelectors); len(pools) != 0 {
+			klog.V(4).Infof("node(%s) is selected by nodepool(%s) and nodepool(%s)",
+				node.GetName(), nodePool.GetName(), strings.Join(pools, ","))
+			conflicts = append(conflicts, node.GetName())
+			conflicted[node.GetName()] = true
+			continue
+		}
+		selected[node.GetName()] = true
+
+		// the node that specifies the desired nodepool explicitly will
+		// not be adopted
+		if _, exist := node.Labels[appsv1alpha1.LabelDesiredNodePool]; exist {
+			continue
+		}
+		adoptNode(node, nodePool.GetName())
+		klog.V(4).Infof("nodepool(%s) adopts node(%s) by selector",
+			nodePool.GetName(), node.GetName())
+		if err := r.Update(ctx, node); err != nil {
+			return nil, err
+		}
+	}
+
+	var desiredNodeList corev1.NodeList
+	if err := r.List(ctx, &desiredNodeList, client.MatchingLabels(map[string]string{
+		appsv1alpha1.LabelDesiredNodePool: nodePool.GetName(),
+	})); err != nil {
+		return nil, err
+	}
+	for i := range desiredNodeList.Items {
+		node := &desiredNodeList.Items[i]
+		// the adopted node stays in the nodepool while its selectors are
+		// in conflict, it is only reported in the status
+		if node.Annotations[appsv1alpha1.AnnotationAdoptedBy] != nodePool.GetName() ||
+			selected[node.GetName()] || conflicted[node.GetName()] {
+			continue
+		}
+		releaseNode(node)
+		klog.V(4).Infof("nodepool(%s) releases node(%s) as it is not selected anymore",
+			nodePool.GetName(), node.GetName())
+		if err := r.Update(ctx, node); err != nil {
+			return nil, err
+		}
+	}
+
+	return conflicts, nil
+}
+
+// getAdoptionSelectors returns the selectors of the nodepools that adopt
+// nodes by selector, except the nodepool named `exclude`
+func getAdoptionSelectors(pools []appsv1alpha1.NodePool,
+	exclude string) (map[string]labels.Selector, error) {
+	selectors := make(map[string]labels.Selector)
+	for _, np := range pools {
+		if np.GetName() == exclude ||
+			!np.Spec.AdoptBySelector ||
+			np.Spec.Selector == nil {
+			continue
+		}
+		s, err := metav1.LabelSelectorAsSelector(np.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		selectors[np.GetName()] = s
+	}
+	return selectors, nil
+}
+
+// matchedPools returns the sorted names of the nodepools whose selector
+// matches the node
+func matchedPools(node *corev1.Node, selectors map[string]labels.Selector) []string {
+	var pools []string
+	for name, s := range selectors {
+		if s.Matches(labels.Set(node.Labels)) {
+			pools = append(pools, name)
+		}
+	}
+	sort.Strings(pools)
+	return pools
+}
+
+// adoptNode adds the node to the nodepool `npName` by setting the desired
+// nodepool label
+func adoptNode(node *corev1.Node, npName string) {
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Labels[appsv1alpha1.LabelDesiredNodePool] = npName
+	node.Annotations[appsv1alpha1.AnnotationAdoptedBy] = npName
+}
+
+// releaseNode removes the node from the nodepool that adopted it
+func releaseNode(node *corev1.Node) {
+	delete(node.Labels, appsv1alpha1.LabelDesiredNodePool)
+	delete(node.Annotations, appsv1alpha1.AnnotationAdoptedBy)
+}
+
+// conciliateSelectorConflicts will update the conflicted nodes and the
+// SelectorConflict condition of the nodepool if necessary
+func conciliateSelectorConflicts(conflicts []string,
+	nodePool *appsv1alpha1.NodePool) (needUpdate bool) {
+	sort.Strings(conflicts)
+	if !reflect.DeepEqual(conflicts, nodePool.Status.ConflictedNodes) {
+		nodePool.Status.ConflictedNodes = conflicts
+		needUpdate = true
+	}
+
+	if !nodePool.Spec.AdoptBySelector {
+		if meta.FindStatusCondition(nodePool.Status.Conditions,
+			appsv1alpha1.NodePoolSelectorConflict) != nil {
+			meta.RemoveStatusCondition(&nodePool.Status.Conditions,
+				appsv1alpha1.NodePoolSelectorConflict)
+			needUpdate = true
+		}
+		return needUpdate
+	}
+
+	cond := metav1.Condition{
+		Type:    appsv1alpha1.NodePoolSelectorConflict,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoConflict",
+		Message: "no node is selected by other pools",
+	}
+	if len(conflicts) != 0 {
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "NodesMatchMultiplePools"
+		cond.Message = fmt.Sprintf("nodes selected by other pools: %s",
+			strings.Join(conflicts, ","))
+	}
+	if setNodePoolCondition(nodePool, cond) {
+		needUpdate = true
+	}
+	return needUpdate
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newAdoptionPool(name string, matchLabels map[string]string) *appsv1alpha1.NodePool {
+	return &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: appsv1alpha1.NodePoolSpec{
+			Selector:        &metav1.LabelSelector{MatchLabels: matchLabels},
+			AdoptBySelector: true,
+		},
+	}
+}
+
+func newLabeledNode(name string, labels, annotations map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestConciliateAdoptedNodes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	appsv1alpha1.AddToScheme(scheme)
+
+	tests := []struct {
+		name            string
+		pool            *appsv1alpha1.NodePool
+		objs            []client.Object
+		expectConflicts []string
+		expectDesired   map[string]string
+	}{
+		{
+			"adopt the selected node",
+			newAdoptionPool("hangzhou", map[string]string{"region": "hangzhou"}),
+			[]client.Object{
+				newLabeledNode("node1", map[string]string{"region": "hangzhou"}, nil),
+				newLabeledNode("node2", map[string]string{"region": "beijing"}, nil),
+			},
+			nil,
+			map[string]string{"node1": "hangzhou", "node2": ""},
+		},
+		{
+			"node specified desired nodepool is not adopted",
+			newAdoptionPool("hangzhou", map[string]string{"region": "hangzhou"}),
+			[]client.Object{
+				newLabeledNode("node1", map[string]string{
+					"region":                          "hangzhou",
+					appsv1alpha1.LabelDesiredNodePool: "other",
+				}, nil),
+			},
+			nil,
+			map[string]string{"node1": "other"},
+		},
+		{
+			"node matches multiple pools",
+			newAdoptionPool("hangzhou", map[string]string{"region": "hangzhou"}),
+			[]client.Object{
+				newAdoptionPool("gpu", map[string]string{"hardware": "gpu"}),
+				newLabeledNode("node1", map[string]string{
+					"region":   "hangzhou",
+					"hardware": "gpu",
+				}, nil),
+				newLabeledNode("node2", map[string]string{"region": "hangzhou"}, nil),
+			},
+			[]string{"node1"},
+			map[string]string{"node1": "", "node2": "hangzhou"},
+		},
+		{
+			"keep the adopted node selected by another pool",
+			newAdoptionPool("hangzhou", map[string]string{"region": "hangzhou"}),
+			[]client.Object{
+				newAdoptionPool("gpu", map[string]string{"hardware": "gpu"}),
+				newLabeledNode("node1", map[string]string{
+					"region":                          "hangzhou",
+					"hardware":                        "gpu",
+					appsv1alpha1.LabelDesiredNodePool: "hangzhou",
+				}, map[string]string{appsv1alpha1.AnnotationAdoptedBy: "hangzhou"}),
+			},
+			[]string{"node1"},
+			map[string]string{"node1": "hangzhou"},
+		},
+		{
+			"release the node that is not selected anymore",
+			newAdoptionPool("hangzhou", map[string]string{"region": "hangzhou"}),
+			[]client.Object{
+				newLabeledNode("node1", map[string]string{
+					"region":                          "beijing",
+					appsv1alpha1.LabelDesiredNodePool: "hangzhou",
+				}, map[string]string{appsv1alpha1.AnnotationAdoptedBy: "hangzhou"}),
+				newLabeledNode("node2", map[string]string{
+					"region":                          "beijing",
+					appsv1alpha1.LabelDesiredNodePool: "hangzhou",
+				}, nil),
+			},
+			nil,
+			map[string]string{"node1": "", "node2": "hangzhou"},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				cl := fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(append(st.objs, st.pool)...).
+					Build()
+				r := &NodePoolReconciler{Client: cl, Scheme: scheme}
+				conflicts, err := r.conciliateAdoptedNodes(context.TODO(), st.pool)
+				if err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				if !reflect.DeepEqual(conflicts, st.expectConflicts) {
+					t.Fatalf("\t%s\texpect conflicts %v, but get %v", failed, st.expectConflicts, conflicts)
+				}
+				for name, expect := range st.expectDesired {
+					var node corev1.Node
+					if err := cl.Get(context.TODO(), types.NamespacedName{Name: name}, &node); err != nil {
+						t.Fatalf("\t%s\tfail to get node %s, %v", failed, name, err)
+					}
+					get := node.Labels[appsv1alpha1.LabelDesiredNodePool]
+					if get != expect {
+						t.Fatalf("\t%s\texpect node %s in pool %q, but get %q", failed, name, expect, get)
+					}
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expectConflicts, conflicts)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestConciliateSelectorConflicts(t *testing.T) {
+	tests := []struct {
+		name         string
+		conflicts    []string
+		nodePool     *appsv1alpha1.NodePool
+		expect       bool
+		expectStatus metav1.ConditionStatus
+	}{
+		{
+			"report conflicted nodes",
+			[]string{"node2", "node1"},
+			newAdoptionPool("hangzhou", nil),
+			true,
+			metav1.ConditionTrue,
+		},
+		{
+			"no conflict",
+			nil,
+			newAdoptionPool("hangzhou", nil),
+			true,
+			metav1.ConditionFalse,
+		},
+		{
+			"adoption is disabled",
+			nil,
+			&appsv1alpha1.NodePool{},
+			false,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := conciliateSelectorConflicts(st.conflicts, st.nodePool)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				var status metav1.ConditionStatus
+				cond := meta.FindStatusCondition(st.nodePool.Status.Conditions,
+					appsv1alpha1.NodePoolSelectorConflict)
+				if cond != nil {
+					status = cond.Status
+				}
+				if status != st.expectStatus {
+					t.Fatalf("\t%s\texpect condition %q, but get %q", failed, st.expectStatus, status)
+				}
+				// the second round should not update the status again
+				if conciliateSelectorConflicts(st.conflicts, st.nodePool) {
+					t.Fatalf("\t%s\tstatus should not be updated twice", failed)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// nodePoolFieldManager is the field manager that applies the nodepool
+// related labels and annotations to the nodes, so that the nodepool only
+// owns the fields it applied and leaves the others untouched.
+const nodePoolFieldManager = "nodepool-controller"
+
+// conciliateNodeAttrs makes the node carry the labels, annotations and taints
+// of the nodepool. The labels and annotations are server-side applied, while
+// the taints are patched with optimistic lock, as the node taints is an
+// atomic list that can not be shared by multiple field managers.
+func (r *NodePoolReconciler) conciliateNodeAttrs(ctx context.Context, node *corev1.Node,
+	nodePool *appsv1alpha1.NodePool) error {
+	// 1. patch the taints and clean up the legacy bookkeeping
+	origin := node.DeepCopy()
+	changed, err := conciliateNodeTaints(node, nodePool)
+	if err != nil {
+		return err
+	}
+	if changed {
+		klog.V(4).Infof("patch taints of node(%s) for nodepool(%s)",
+			node.GetName(), nodePool.GetName())
+		if err := r.Patch(ctx, node, client.MergeFromWithOptions(origin,
+			client.MergeFromWithOptimisticLock{})); err != nil {
+			return err
+		}
+	}
+
+	// 2. apply the labels and annotations
+	nodeApply, err := newNodeApplyConfiguration(node.GetName(), nodePool)
+	if err != nil {
+		return err
+	}
+	if !nodeNeedApply(node, nodeApply) {
+		return nil
+	}
+	klog.V(4).Infof("apply attributes of nodepool(%s) to node(%s)",
+		nodePool.GetName(), node.GetName())
+	_, err = r.kubeClient.CoreV1().Nodes().Apply(ctx, nodeApply,
+		metav1.ApplyOptions{FieldManager: nodePoolFieldManager, Force: true})
+	return err
+}
+
+// newNodeApplyConfiguration returns the apply configuration that only
+// contains the labels and annotations of the nodepool. The taints of the
+// nodepool are recorded in the annotation, so that they can be removed
+// once they are removed from the nodepool.
+func newNodeApplyConfiguration(nodeName string,
+	nodePool *appsv1alpha1.NodePool) (*corev1ac.NodeApplyConfiguration, error) {
+	taints, err := json.Marshal(nodePool.Spec.Taints)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := mergeMap(nil, nodePool.Spec.Labels)
+	labels[appsv1alpha1.LabelCurrentNodePool] = nodePool.GetName()
+	annotations := mergeMap(nil, nodePool.Spec.Annotations)
+	annotations[appsv1alpha1.AnnotationPoolTaints] = string(taints)
+
+	return corev1ac.Node(nodeName).
+		WithLabels(labels).
+		WithAnnotations(annotations), nil
+}
+
+// nodeNeedApply checks if the node has not carried the labels and
+// annotations of the apply configuration, or still carries the labels and
+// annotations that have been removed from the nodepool
+func nodeNeedApply(node *corev1.Node, nodeApply *corev1ac.NodeApplyConfiguration) bool {
+	for k, v := range nodeApply.Labels {
+		if nv, exist := node.Labels[k]; !exist || nv != v {
+			return true
+		}
+	}
+	for k, v := range nodeApply.Annotations {
+		if nv, exist := node.Annotations[k]; !exist || nv != v {
+			return true
+		}
+	}
+
+	ownedLabels, ownedAnnos := getOwnedFields(node)
+	for _, k := range ownedLabels {
+		if _, exist := nodeApply.Labels[k]; !exist {
+			return true
+		}
+	}
+	for _, k := range ownedAnnos {
+		if _, exist := nodeApply.Annotations[k]; !exist {
+			return true
+		}
+	}
+	return false
+}
+
+// conciliateNodeTaints replaces the taints owned by the nodepool with the
+// latest taints of the nodepool. The labels and annotations recorded in
+// the legacy `nodepool.openyurt.io/previous-attributes` annotation that have
+// been removed from the nodepool will be removed too, and the legacy
+// annotation will be dropped.
+func conciliateNodeTaints(node *corev1.Node, nodePool *appsv1alpha1.NodePool) (bool, error) {
+	origin := node.DeepCopy()
+	ownedTaints, err := getOwnedTaints(node)
+	if err != nil {
+		return false, err
+	}
+
+	if preAttrs, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]; exist {
+		var preNpra NodePoolRelatedAttributes
+		if err := json.Unmarshal([]byte(preAttrs), &preNpra); err != nil {
+			return false, err
+		}
+		for k, v := range preNpra.Labels {
+			if _, exist := nodePool.Spec.Labels[k]; !exist &&
+				k != appsv1alpha1.LabelCurrentNodePool && node.Labels[k] == v {
+				delete(node.Labels, k)
+			}
+		}
+		for k, v := range preNpra.Annotations {
+			if _, exist := nodePool.Spec.Annotations[k]; !exist && node.Annotations[k] == v {
+				delete(node.Annotations, k)
+			}
+		}
+		delete(node.Annotations, appsv1alpha1.AnnotationPrevAttrs)
+	}
+
+	node.Spec.Taints = mergeTaints(node.Spec.Taints, ownedTaints, nodePool.Spec.Taints)
+	return !reflect.DeepEqual(origin, node), nil
+}
+
+// removePoolRelatedAttrs removes attributes(label/annotation/taint) that
+// relate to nodepool
+func removePoolRelatedAttrs(node *corev1.Node) error {
+	ownedTaints, err := getOwnedTaints(node)
+	if err != nil {
+		return err
+	}
+
+	if preAttrs, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]; exist {
+		var npra NodePoolRelatedAttributes
+		if err := json.Unmarshal([]byte(preAttrs), &npra); err != nil {
+			return err
+		}
+		for lk, lv := range npra.Labels {
+			if node.Labels[lk] == lv {
+				delete(node.Labels, lk)
+			}
+		}
+		for ak, av := range npra.Annotations {
+			if node.Annotations[ak] == av {
+				delete(node.Annotations, ak)
+			}
+		}
+	}
+
+	ownedLabels, ownedAnnos := getOwnedFields(node)
+	for _, k := range ownedLabels {
+		delete(node.Labels, k)
+	}
+	for _, k := range ownedAnnos {
+		delete(node.Annotations, k)
+	}
+
+	node.Spec.Taints = mergeTaints(node.Spec.Taints, ownedTaints, nil)
+	delete(node.Annotations, appsv1alpha1.AnnotationPrevAttrs)
+	delete(node.Annotations, appsv1alpha1.AnnotationPoolTaints)
+	delete(node.Labels, appsv1alpha1.LabelCurrentNodePool)
+
+	return nil
+}
+
+// getOwnedTaints returns the taints that have been added to the node by the
+// nodepool, the legacy `nodepool.openyurt.io/previous-attributes` annotation
+// will be used if the node has not been migrated.
+func getOwnedTaints(node *corev1.Node) ([]corev1.Taint, error) {
+	var taints []corev1.Taint
+	if owned, exist := node.Annotations[appsv1alpha1.AnnotationPoolTaints]; exist {
+		if err := json.Unmarshal([]byte(owned), &taints); err != nil {
+			return nil, err
+		}
+	}
+	if preAttrs, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]; exist {
+		var npra NodePoolRelatedAttributes
+		if err := json.Unmarshal([]byte(preAttrs), &npra); err != nil {
+			return nil, err
+		}
+		taints = append(taints, npra.Taints...)
+	}
+	return taints, nil
+}
+
+// mergeTaints removes the `owned` taints and the taints that conflict with
+// the `desired` taints from `taints`, and appends the `desired` taints
+func mergeTaints(taints, owned, desired []corev1.Taint) []corev1.Taint {
+	var merged []corev1.Taint
+	for _, t := range taints {
+		if _, exist := containTaint(t, owned); exist {
+			continue
+		}
+		if _, exist := containTaint(t, desired); exist {
+			continue
+		}
+		merged = append(merged, t)
+	}
+	return append(merged, desired...)
+}
+
+// getOwnedFields returns the keys of the labels and annotations that are
+// owned by the nodepool field manager
+func getOwnedFields(node *corev1.Node) (labels, annotations []string) {
+	for _, mf := range node.GetManagedFields() {
+		if mf.Manager != nodePoolFieldManager ||
+			mf.Operation != metav1.ManagedFieldsOperationApply ||
+			mf.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Metadata struct {
+				Labels      map[string]interface{} `json:"f:labels"`
+				Annotations map[string]interface{} `json:"f:annotations"`
+			} `json:"f:metadata"`
+		}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			klog.Errorf("fail to parse managed fields of node(%s), %v", node.GetName(), err)
+			continue
+		}
+		for k := range fields.Metadata.Labels {
+			if strings.HasPrefix(k, "f:") {
+				labels = append(labels, strings.TrimPrefix(k, "f:"))
+			}
+		}
+		for k := range fields.Metadata.Annotations {
+			if strings.HasPrefix(k, "f:") {
+				annotations = append(annotations, strings.TrimPrefix(k, "f:"))
+			}
+		}
+	}
+	return labels, annotations
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+var testPool = &appsv1alpha1.NodePool{
+	ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+	Spec: appsv1alpha1.NodePoolSpec{
+		Labels:      map[string]string{"foo": "bar"},
+		Annotations: map[string]string{"buz": "qux"},
+		Taints:      testTaints,
+	},
+}
+
+// managedFieldsFor returns the managed fields of the nodepool field manager
+// that owns the given labels and annotations
+func managedFieldsFor(labels, annotations []string) []metav1.ManagedFieldsEntry {
+	fieldSet := func(keys []string) map[string]interface{} {
+		m := make(map[string]interface{})
+		for _, k := range keys {
+			m["f:"+k] = map[string]interface{}{}
+		}
+		return m
+	}
+	raw, _ := json.Marshal(map[string]interface{}{
+		"f:metadata": map[string]interface{}{
+			"f:labels":      fieldSet(labels),
+			"f:annotations": fieldSet(annotations),
+		},
+	})
+	return []metav1.ManagedFieldsEntry{
+		{
+			Manager:   nodePoolFieldManager,
+			Operation: metav1.ManagedFieldsOperationApply,
+			FieldsV1:  &metav1.FieldsV1{Raw: raw},
+		},
+		{
+			Manager:   "kubelet",
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			FieldsV1:  &metav1.FieldsV1{Raw: raw},
+		},
+	}
+}
+
+func TestNewNodeApplyConfiguration(t *testing.T) {
+	get, err := newNodeApplyConfiguration("node1", testPool)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error %v", failed, err)
+	}
+	expectLabels := map[string]string{
+		"foo":                             "bar",
+		appsv1alpha1.LabelCurrentNodePool: "hangzhou",
+	}
+	if !reflect.DeepEqual(get.Labels, expectLabels) {
+		t.Fatalf("\t%s\texpect labels %v, but get %v", failed, expectLabels, get.Labels)
+	}
+	var taints []corev1.Taint
+	if err := json.Unmarshal([]byte(get.Annotations[appsv1alpha1.AnnotationPoolTaints]), &taints); err != nil {
+		t.Fatalf("\t%s\tunexpected error %v", failed, err)
+	}
+	if !reflect.DeepEqual(taints, testTaints) || get.Annotations["buz"] != "qux" {
+		t.Fatalf("\t%s\tunexpected annotations %v", failed, get.Annotations)
+	}
+	// the spec of the nodepool should not be changed
+	if _, exist := testPool.Spec.Labels[appsv1alpha1.LabelCurrentNodePool]; exist {
+		t.Fatalf("\t%s\tthe labels of the nodepool should not be changed", failed)
+	}
+	t.Logf("\t%s\tget %v", succeed, get.Labels)
+}
+
+func TestNodeNeedApply(t *testing.T) {
+	nodeApply, _ := newNodeApplyConfiguration("node1", testPool)
+
+	tests := []struct {
+		name   string
+		node   *corev1.Node
+		expect bool
+	}{
+		{
+			"node carries the attributes",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:        mergeMap(map[string]string{"user": "label"}, nodeApply.Labels),
+					Annotations:   nodeApply.Annotations,
+					ManagedFields: managedFieldsFor([]string{"foo"}, []string{"buz"}),
+				},
+			},
+			false,
+		},
+		{
+			"node misses the label",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: nodeApply.Annotations,
+				},
+			},
+			true,
+		},
+		{
+			"node carries the label removed from the pool",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:        mergeMap(map[string]string{"removed": "label"}, nodeApply.Labels),
+					Annotations:   nodeApply.Annotations,
+					ManagedFields: managedFieldsFor([]string{"foo", "removed"}, nil),
+				},
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := nodeNeedApply(st.node, nodeApply)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestConciliateNodeTaints(t *testing.T) {
+	ownedTaints, _ := json.Marshal([]corev1.Taint{
+		{Key: "old", Effect: corev1.TaintEffectNoSchedule},
+	})
+	legacyAttrs, _ := json.Marshal(NodePoolRelatedAttributes{
+		Labels:      map[string]string{"legacy": "label", "foo": "bar"},
+		Annotations: map[string]string{"legacy": "annotation"},
+		Taints:      []corev1.Taint{{Key: "legacy", Effect: corev1.TaintEffectNoSchedule}},
+	})
+	userTaint := corev1.Taint{Key: "user", Effect: corev1.TaintEffectNoSchedule}
+
+	tests := []struct {
+		name              string
+		node              *corev1.Node
+		expect            bool
+		expectTaints      []corev1.Taint
+		expectLabels      map[string]string
+		expectAnnotations map[string]string
+	}{
+		{
+			"taints are up to date",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						appsv1alpha1.AnnotationPoolTaints: string(ownedTaints),
+					},
+				},
+				Spec: corev1.NodeSpec{
+					Taints: append([]corev1.Taint{userTaint}, testTaints...),
+				},
+			},
+			false,
+			append([]corev1.Taint{userTaint}, testTaints...),
+			nil,
+			map[string]string{
+				appsv1alpha1.AnnotationPoolTaints: string(ownedTaints),
+			},
+		},
+		{
+			"replace the owned taints",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						appsv1alpha1.AnnotationPoolTaints: string(ownedTaints),
+					},
+				},
+				Spec: corev1.NodeSpec{
+					Taints: []corev1.Taint{
+						userTaint,
+						{Key: "old", Effect: corev1.TaintEffectNoSchedule},
+					},
+				},
+			},
+			true,
+			append([]corev1.Taint{userTaint}, testTaints...),
+			nil,
+			map[string]string{
+				appsv1alpha1.AnnotationPoolTaints: string(ownedTaints),
+			},
+		},
+		{
+			"migrate from the legacy annotation",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"legacy": "label",
+						"foo":    "bar",
+						"user":   "label",
+					},
+					Annotations: map[string]string{
+						"legacy":                         "annotation",
+						appsv1alpha1.AnnotationPrevAttrs: string(legacyAttrs),
+					},
+				},
+				Spec: corev1.NodeSpec{
+					Taints: []corev1.Taint{
+						userTaint,
+						{Key: "legacy", Effect: corev1.TaintEffectNoSchedule},
+					},
+				},
+			},
+			true,
+			append([]corev1.Taint{userTaint}, testTaints...),
+			map[string]string{
+				"foo":  "bar",
+				"user": "label",
+			},
+			map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get, err := conciliateNodeTaints(st.node, testPool)
+				if err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				if !reflect.DeepEqual(st.node.Spec.Taints, st.expectTaints) {
+					t.Fatalf("\t%s\texpect taints %v, but get %v", failed, st.expectTaints, st.node.Spec.Taints)
+				}
+				if !reflect.DeepEqual(st.node.Labels, st.expectLabels) {
+					t.Fatalf("\t%s\texpect labels %v, but get %v", failed, st.expectLabels, st.node.Labels)
+				}
+				if !reflect.DeepEqual(st.node.Annotations, st.expectAnnotations) {
+					t.Fatalf("\t%s\texpect annotations %v, but get %v", failed, st.expectAnnotations, st.node.Annotations)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestGetOwnedFields(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			ManagedFields: managedFieldsFor([]string{"foo", "bar"}, []string{"buz"}),
+		},
+	}
+	labels, annotations := getOwnedFields(node)
+	sort.Strings(labels)
+	if !reflect.DeepEqual(labels, []string{"bar", "foo"}) {
+		t.Fatalf("\t%s\tunexpected owned labels %v", failed, labels)
+	}
+	if !reflect.DeepEqual(annotations, []string{"buz"}) {
+		t.Fatalf("\t%s\tunexpected owned annotations %v", failed, annotations)
+	}
+	t.Logf("\t%s\tget %v, %v", succeed, labels, annotations)
+}
+
+func TestRemoveAppliedAttrs(t *testing.T) {
+	ownedTaints, _ := json.Marshal(testTaints)
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"foo":                             "bar",
+				"user":                            "label",
+				appsv1alpha1.LabelCurrentNodePool: "hangzhou",
+			},
+			Annotations: map[string]string{
+				"buz":                             "qux",
+				appsv1alpha1.AnnotationPoolTaints: string(ownedTaints),
+			},
+			ManagedFields: managedFieldsFor(
+				[]string{"foo", appsv1alpha1.LabelCurrentNodePool},
+				[]string{"buz", appsv1alpha1.AnnotationPoolTaints}),
+		},
+		Spec: corev1.NodeSpec{
+			Taints: append([]corev1.Taint{{Key: "user", Effect: corev1.TaintEffectNoSchedule}}, testTaints...),
+		},
+	}
+
+	if err := removePoolRelatedAttrs(node); err != nil {
+		t.Fatalf("\t%s\tunexpected error %v", failed, err)
+	}
+	if !reflect.DeepEqual(node.Labels, map[string]string{"user": "label"}) {
+		t.Fatalf("\t%s\tunexpected labels %v", failed, node.Labels)
+	}
+	if len(node.Annotations) != 0 {
+		t.Fatalf("\t%s\tunexpected annotations %v", failed, node.Annotations)
+	}
+	if len(node.Spec.Taints) != 1 || node.Spec.Taints[0].Key != "user" {
+		t.Fatalf("\t%s\tunexpected taints %v", failed, node.Spec.Taints)
+	}
+	t.Logf("\t%s\tget %v", succeed, node.Labels)
+}
+
+func TestConciliateNodeAttrs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	appsv1alpha1.AddToScheme(scheme)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node1",
+			Labels: map[string]string{"user": "label"},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(node).Build()
+	kubeClient := fakeclientset.NewSimpleClientset()
+	var applied []clienttesting.PatchAction
+	kubeClient.PrependReactor("patch", "nodes", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		applied = append(applied, action.(clienttesting.PatchAction))
+		return true, &corev1.Node{}, nil
+	})
+	r := &NodePoolReconciler{Client: cl, Scheme: scheme, kubeClient: kubeClient}
+
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "node1"}, node); err != nil {
+		t.Fatalf("\t%s\tfail to get node, %v", failed, err)
+	}
+	if err := r.conciliateNodeAttrs(context.TODO(), node, testPool); err != nil {
+		t.Fatalf("\t%s\tunexpected error %v", failed, err)
+	}
+
+	// the taints are patched
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "node1"}, node); err != nil {
+		t.Fatalf("\t%s\tfail to get node, %v", failed, err)
+	}
+	if !reflect.DeepEqual(node.Spec.Taints, testTaints) {
+		t.Fatalf("\t%s\texpect taints %v, but get %v", failed, testTaints, node.Spec.Taints)
+	}
+
+	// the labels and annotations are applied by the nodepool field manager
+	if len(applied) != 1 {
+		t.Fatalf("\t%s\texpect 1 apply, but get %d", failed, len(applied))
+	}
+	var applyNode corev1.Node
+	if err := json.Unmarshal(applied[0].GetPatch(), &applyNode); err != nil {
+		t.Fatalf("\t%s\tunexpected error %v", failed, err)
+	}
+	if _, exist := applyNode.Labels["user"]; exist || applyNode.Labels["foo"] != "bar" {
+		t.Fatalf("\t%s\tunexpected applied labels %v", failed, applyNode.Labels)
+	}
+	if len(applyNode.Spec.Taints) != 0 {
+		t.Fatalf("\t%s\ttaints should not be applied", failed)
+	}
+	t.Logf("\t%s\tapplied %v", succeed, applyNode.Labels)
+}
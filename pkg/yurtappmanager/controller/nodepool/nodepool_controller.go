@@ -18,17 +18,22 @@ package nodepool
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	kubeclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
@@ -36,18 +41,24 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	yurtclient "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/client"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/constant"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/gate"
 )
 
 const controllerName = "nodepool-controller"
 
+// nodeLeaseGracePeriod is the duration that the node lease is allowed to go
+// without being renewed before the node is regarded as disconnected
+var nodeLeaseGracePeriod = 40 * time.Second
+
 var concurrentReconciles = 3
 
 // NodePoolReconciler reconciles a NodePool object
@@ -56,9 +67,12 @@ type NodePoolReconciler struct {
 	Scheme *runtime.Scheme
 
 	recorder          record.EventRecorder
+	kubeClient        kubeclientset.Interface
 	createDefaultPool bool
 }
 
+// NodePoolRelatedAttributes is the legacy format of the attributes recorded
+// in the `nodepool.openyurt.io/previous-attributes` annotation
 type NodePoolRelatedAttributes struct {
 	Labels      map[string]string `json:"labels,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
@@ -86,6 +100,7 @@ func newReconciler(mgr manager.Manager, createDefaultPool bool) reconcile.Reconc
 		Client:            mgr.GetClient(),
 		Scheme:            mgr.GetScheme(),
 		recorder:          mgr.GetEventRecorderFor(controllerName),
+		kubeClient:        yurtclient.GetGenericClient().KubeClient,
 		createDefaultPool: createDefaultPool,
 	}
 }
@@ -122,6 +137,22 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to Node that may be selected by NodePool
+	err = c.Watch(&source.Kind{
+		Type: &corev1.Node{}},
+		&EnqueueNodePoolForSelectedNode{client: mgr.GetClient()})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to Pod that occupy the resources of the pool
+	err = c.Watch(&source.Kind{
+		Type: &corev1.Pod{}},
+		&EnqueueNodePoolForPod{client: mgr.GetClient()})
+	if err != nil {
+		return err
+	}
+
 	if npr.createDefaultPool {
 		// register a node controller with the underlying informer of the manager
 		go createDefaultNodePool(mgr.GetClient())
@@ -171,6 +202,9 @@ func createDefaultNodePool(client client.Client) {
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch
 
 func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -180,6 +214,23 @@ func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// protect the nodepool from being deleted while it is still referenced
+	if !nodePool.DeletionTimestamp.IsZero() {
+		return r.handleNodePoolDeletion(ctx, &nodePool)
+	}
+	if !controllerutil.ContainsFinalizer(&nodePool, appsv1alpha1.NodePoolFinalizer) {
+		controllerutil.AddFinalizer(&nodePool, appsv1alpha1.NodePoolFinalizer)
+		if err := r.Update(ctx, &nodePool); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// 0. adopt or release nodes based on the nodepool selector
+	conflicts, err := r.conciliateAdoptedNodes(ctx, &nodePool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	var desiredNodeList corev1.NodeList
 	if err := r.List(ctx, &desiredNodeList, client.MatchingLabels(map[string]string{
 		appsv1alpha1.LabelDesiredNodePool: nodePool.GetName(),
@@ -196,14 +247,16 @@ func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctr
 
 	// 1. handle the event of removing node out of the pool
 	// nodes in currentNodeList but not in the desiredNodeList, will be
-	// removed from the pool
+	// migrated out of the pool
+	var migrating bool
 	removedNodes := getRemovedNodes(&currentNodeList, &desiredNodeList)
 	for _, rNode := range removedNodes {
-		if err := removePoolRelatedAttrs(&rNode); err != nil {
+		completed, err := r.migrateNode(ctx, &rNode, &nodePool)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
-		if err := r.Update(ctx, &rNode); err != nil {
-			return ctrl.Result{}, err
+		if !completed {
+			migrating = true
 		}
 	}
 
@@ -213,90 +266,73 @@ func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctr
 		readyNode    int32
 		notReadyNode int32
 		nodes        []string
+		summaries    []appsv1alpha1.NodeSummary
+		resources    poolResources
+		staleAfter   time.Duration
 	)
 
 	for _, node := range desiredNodeList.Items {
 		// prepare nodepool status
 		nodes = append(nodes, node.GetName())
+		summary := getNodeSummary(node)
+		var leaseStaleAfter time.Duration
+		if summary.LeaseStale, leaseStaleAfter, err = r.isNodeLeaseStale(ctx, node.GetName()); err != nil {
+			return ctrl.Result{}, err
+		}
+		// no node event is sent when the lease of a not ready node goes
+		// stale, check the node again by then
+		if summary.Ready != corev1.ConditionTrue && leaseStaleAfter > 0 &&
+			(staleAfter == 0 || leaseStaleAfter < staleAfter) {
+			staleAfter = leaseStaleAfter
+		}
+		summaries = append(summaries, summary)
+		pods, err := r.getNodePods(ctx, node.GetName())
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		resources.addNode(&node, pods)
 		if isNodeReady(node) {
 			readyNode += 1
 		} else {
 			notReadyNode += 1
 		}
 
-		// update node status according to nodepool
-		updated, err := concilateNode(&node, nodePool)
+		// wait for the node to be migrated out of its current pool
+		migratingIn, err := r.isMigratingIn(ctx, &node, &nodePool)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
-		if updated {
-			if err := r.Update(ctx, &node); err != nil {
-				klog.Errorf("Update Node %s error %v", node.Name, err)
-				return ctrl.Result{}, err
-			}
+		if migratingIn {
+			continue
+		}
+
+		// update node attributes according to nodepool
+		if err := r.conciliateNodeAttrs(ctx, &node, &nodePool); err != nil {
+			klog.Errorf("Update Node %s error %v", node.Name, err)
+			return ctrl.Result{}, err
 		}
 	}
 
 	// 3. always update the node pool status if necessary
-	needUpdate := conciliateNodePoolStatus(readyNode, notReadyNode, nodes, &nodePool)
-	if needUpdate {
-		return ctrl.Result{}, r.Status().Update(ctx, &nodePool)
+	needUpdate := conciliateNodePoolStatus(readyNode, notReadyNode, nodes, summaries, &nodePool)
+	if conciliateNodePoolResources(resources, &nodePool) {
+		needUpdate = true
 	}
-	return ctrl.Result{}, nil
-}
-
-// conciliatePoolRelatedAttrs will update the node's attributes that related to
-// the nodepool
-func concilateNode(node *corev1.Node, nodePool appsv1alpha1.NodePool) (attrUpdated bool, err error) {
-	// update node attr
-	npra := NodePoolRelatedAttributes{
-		Labels:      nodePool.Spec.Labels,
-		Annotations: nodePool.Spec.Annotations,
-		Taints:      nodePool.Spec.Taints,
-	}
-
-	if preAttrs, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]; !exist {
-		node.Labels = mergeMap(node.Labels, npra.Labels)
-		node.Annotations = mergeMap(node.Annotations, npra.Annotations)
-		for _, npt := range npra.Taints {
-			for i, nt := range node.Spec.Taints {
-				if npt.Effect == nt.Effect && npt.Key == nt.Key {
-					node.Spec.Taints = append(node.Spec.Taints[:i], node.Spec.Taints[i+1:]...)
-					break
-				}
-			}
-			node.Spec.Taints = append(node.Spec.Taints, npt)
-		}
-		if err := cachePrevPoolAttrs(node, npra); err != nil {
-			return attrUpdated, err
-		}
-		attrUpdated = true
-	} else {
-		var preNpra NodePoolRelatedAttributes
-		if err := json.Unmarshal([]byte(preAttrs), &preNpra); err != nil {
-			return attrUpdated, err
-		}
-		if !reflect.DeepEqual(preNpra, npra) {
-			// pool related attributes will be updated
-			conciliateLabels(node, preNpra.Labels, npra.Labels)
-			conciliateAnnotations(node, preNpra.Annotations, npra.Annotations)
-			conciliateTaints(node, preNpra.Taints, npra.Taints)
-			if err := cachePrevPoolAttrs(node, npra); err != nil {
-				return attrUpdated, err
-			}
-			attrUpdated = true
-		}
+	if conciliateSelectorConflicts(conflicts, &nodePool) {
+		needUpdate = true
 	}
-
-	// update ownerLabel
-	if node.Labels[appsv1alpha1.LabelCurrentNodePool] != nodePool.GetName() {
-		if len(node.Labels) == 0 {
-			node.Labels = make(map[string]string)
-		}
-		node.Labels[appsv1alpha1.LabelCurrentNodePool] = nodePool.GetName()
-		attrUpdated = true
+	var result ctrl.Result
+	if migrating {
+		// check the progress of the migrating nodes later
+		result.RequeueAfter = migrationRequeueInterval
+	}
+	if staleAfter > 0 && (result.RequeueAfter == 0 || staleAfter < result.RequeueAfter) {
+		result.RequeueAfter = staleAfter
+	}
+	if needUpdate {
+		return result, r.Status().Update(ctx, &nodePool)
 	}
-	return attrUpdated, nil
+	return result, nil
 }
 
 // getRemovedNodes calculates removed nodes from current nodes and desired nodes
@@ -317,118 +353,230 @@ func getRemovedNodes(currentNodeList *corev1.NodeList, desiredNodeList *corev1.N
 	return removedNodes
 }
 
-// removePoolRelatedAttrs removes attributes(label/annotation/taint) that
-// relate to nodepool
-func removePoolRelatedAttrs(node *corev1.Node) error {
-	var npra NodePoolRelatedAttributes
+// conciliateNodePoolStatus will update the nodepool status if necessary
+func conciliateNodePoolStatus(
+	readyNode,
+	notReadyNode int32,
+	nodes []string,
+	summaries []appsv1alpha1.NodeSummary,
+	nodePool *appsv1alpha1.NodePool) (needUpdate bool) {
 
-	if _, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]; !exist {
-		return nil
+	if readyNode != nodePool.Status.ReadyNodeNum {
+		nodePool.Status.ReadyNodeNum = readyNode
+		needUpdate = true
 	}
 
-	if err := json.Unmarshal(
-		[]byte(node.Annotations[appsv1alpha1.AnnotationPrevAttrs]),
-		&npra); err != nil {
-		return err
+	if notReadyNode != nodePool.Status.UnreadyNodeNum {
+		nodePool.Status.UnreadyNodeNum = notReadyNode
+		needUpdate = true
 	}
 
-	for lk, lv := range npra.Labels {
-		if node.Labels[lk] == lv {
-			delete(node.Labels, lk)
+	var autonomousNode int32
+	for _, ns := range summaries {
+		if isNodeAutonomous(ns) {
+			autonomousNode++
 		}
 	}
+	if autonomousNode != nodePool.Status.AutonomousNodeNum {
+		nodePool.Status.AutonomousNodeNum = autonomousNode
+		needUpdate = true
+	}
 
-	for ak, av := range npra.Annotations {
-		if node.Annotations[ak] == av {
-			delete(node.Annotations, ak)
-		}
+	// update the node list on demand
+	sort.Strings(nodes)
+	sort.Strings(nodePool.Status.Nodes)
+	if !reflect.DeepEqual(nodes, nodePool.Status.Nodes) {
+		nodePool.Status.Nodes = nodes
+		needUpdate = true
 	}
 
-	for _, t := range npra.Taints {
-		if i, exist := containTaint(t, node.Spec.Taints); exist {
-			node.Spec.Taints = append(
-				node.Spec.Taints[:i],
-				node.Spec.Taints[i+1:]...)
+	// update the node summaries on demand, the heartbeat of a node is kept
+	// until its summary changes, so that the periodic heartbeats of the nodes
+	// do not update the nodepool status
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	oldSummaries := make(map[string]appsv1alpha1.NodeSummary, len(nodePool.Status.NodeSummaries))
+	for _, ns := range nodePool.Status.NodeSummaries {
+		oldSummaries[ns.Name] = ns
+	}
+	for i := range summaries {
+		if old, ok := oldSummaries[summaries[i].Name]; ok && !isNodeSummaryChanged(summaries[i], old) {
+			summaries[i].LastHeartbeatTime = old.LastHeartbeatTime
 		}
 	}
-	delete(node.Annotations, appsv1alpha1.AnnotationPrevAttrs)
-	delete(node.Labels, appsv1alpha1.LabelCurrentNodePool)
-
-	return nil
-}
+	if !apiequality.Semantic.DeepEqual(summaries, nodePool.Status.NodeSummaries) {
+		nodePool.Status.NodeSummaries = summaries
+		needUpdate = true
+	}
 
-// conciliateLabels will update the node's label that related to the nodepool
-func conciliateLabels(node *corev1.Node, oldLabels, newLabels map[string]string) {
-	// 1. remove labels from the node if they have been removed from the
-	// node pool
-	for oldK := range oldLabels {
-		if _, exist := newLabels[oldK]; !exist {
-			// label has been removed from the nodepool
-			delete(node.Labels, oldK)
+	for _, cond := range getNodePoolConditions(summaries) {
+		if setNodePoolCondition(nodePool, cond) {
+			needUpdate = true
 		}
 	}
 
-	// 2. update the node labels based on the latest node pool labels
-	node.Labels = mergeMap(node.Labels, newLabels)
+	return needUpdate
 }
 
-// conciliateLabels will update the node's annotation that related to the nodepool
-func conciliateAnnotations(node *corev1.Node, oldAnnos, newAnnos map[string]string) {
-	// 1. remove annotations from the node if they have been removed from the
-	// node pool
-	for oldK := range oldAnnos {
-		if _, exist := newAnnos[oldK]; !exist {
-			delete(node.Annotations, oldK)
+// getNodeSummary summarizes the health of the `node`
+func getNodeSummary(node corev1.Node) appsv1alpha1.NodeSummary {
+	ns := appsv1alpha1.NodeSummary{
+		Name:          node.GetName(),
+		Ready:         corev1.ConditionUnknown,
+		Unschedulable: node.Spec.Unschedulable,
+		Autonomy:      node.Annotations[appsv1alpha1.AnnotationNodeAutonomy] == "true",
+	}
+	_, nc := nodeutil.GetNodeCondition(&node.Status, corev1.NodeReady)
+	if nc != nil {
+		ns.Ready = nc.Status
+		ns.LastHeartbeatTime = nc.LastHeartbeatTime
+	}
+	for _, ct := range []corev1.NodeConditionType{
+		corev1.NodeMemoryPressure,
+		corev1.NodeDiskPressure,
+		corev1.NodePIDPressure,
+	} {
+		_, pc := nodeutil.GetNodeCondition(&node.Status, ct)
+		if pc != nil && pc.Status == corev1.ConditionTrue {
+			ns.PressureConditions = append(ns.PressureConditions, ct)
 		}
 	}
+	return ns
+}
 
-	// 2. update the node annotations based on the latest node pool labels
-	node.Annotations = mergeMap(node.Annotations, newAnnos)
+// isNodeSummaryChanged checks if the summary of a node has changed, the
+// heartbeat time is ignored as it changes on every heartbeat of the node
+func isNodeSummaryChanged(ns, old appsv1alpha1.NodeSummary) bool {
+	ns.LastHeartbeatTime = old.LastHeartbeatTime
+	return !apiequality.Semantic.DeepEqual(ns, old)
 }
 
-// conciliateLabels will update the node's taint that related to the nodepool
-func conciliateTaints(node *corev1.Node, oldTaints, newTaints []corev1.Taint) {
+// isNodeAutonomous checks if the node is not ready only because it has lost
+// connection with the cloud while its pods keep running autonomously
+func isNodeAutonomous(ns appsv1alpha1.NodeSummary) bool {
+	return ns.Ready != corev1.ConditionTrue && ns.Autonomy && ns.LeaseStale
+}
 
-	// 1. remove taints from the node if they have been removed from the
-	// node pool
-	for _, oldTaint := range oldTaints {
-		if _, exist := containTaint(oldTaint, node.Spec.Taints); exist {
-			node.Spec.Taints = removeTaint(oldTaint, node.Spec.Taints)
+// getNodePoolConditions calculates the Ready, Degraded, AllNodesReachable
+// and Partitioned conditions of the nodepool based on the node summaries.
+// The autonomous nodes that have lost connection with the cloud are not
+// regarded as failed nodes.
+func getNodePoolConditions(summaries []appsv1alpha1.NodeSummary) []metav1.Condition {
+	var notReady, failed, autonomous, unreachable, cordoned, pressured []string
+	for _, ns := range summaries {
+		if ns.Ready != corev1.ConditionTrue {
+			notReady = append(notReady, ns.Name)
+			if isNodeAutonomous(ns) {
+				autonomous = append(autonomous, ns.Name)
+			} else {
+				failed = append(failed, ns.Name)
+			}
+		}
+		if ns.Ready == corev1.ConditionUnknown || ns.LeaseStale {
+			unreachable = append(unreachable, ns.Name)
+		}
+		if ns.Unschedulable {
+			cordoned = append(cordoned, ns.Name)
+		}
+		if len(ns.PressureConditions) != 0 {
+			pressured = append(pressured, ns.Name)
 		}
 	}
 
-	// 2. update the node taints based on the latest node pool taints
-	for _, nt := range newTaints {
-		node.Spec.Taints = append(node.Spec.Taints, nt)
+	ready := metav1.Condition{
+		Type:    appsv1alpha1.NodePoolReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AllNodesReady",
+		Message: "all nodes in the pool are ready",
+	}
+	if len(summaries) == 0 {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "NoNodes"
+		ready.Message = "there is no node in the pool"
+	} else if len(notReady) != 0 {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "NodesNotReady"
+		ready.Message = fmt.Sprintf("nodes not ready: %s", strings.Join(notReady, ","))
+	}
+
+	degraded := metav1.Condition{
+		Type:    appsv1alpha1.NodePoolDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AsExpected",
+		Message: "all nodes in the pool are healthy",
+	}
+	var msgs []string
+	if len(failed) != 0 {
+		degraded.Reason = "NodesNotReady"
+		msgs = append(msgs, fmt.Sprintf("nodes not ready: %s", strings.Join(failed, ",")))
+	}
+	if len(autonomous) != 0 {
+		if len(msgs) == 0 {
+			degraded.Reason = "NodesAutonomous"
+		}
+		msgs = append(msgs, fmt.Sprintf("nodes running autonomously: %s", strings.Join(autonomous, ",")))
 	}
-}
-
-// conciliateNodePoolStatus will update the nodepool status if necessary
-func conciliateNodePoolStatus(
-	readyNode,
-	notReadyNode int32,
-	nodes []string,
-	nodePool *appsv1alpha1.NodePool) (needUpdate bool) {
-
-	if readyNode != nodePool.Status.ReadyNodeNum {
-		nodePool.Status.ReadyNodeNum = readyNode
-		needUpdate = true
+	if len(cordoned) != 0 {
+		if len(msgs) == 0 {
+			degraded.Reason = "NodesCordoned"
+		}
+		msgs = append(msgs, fmt.Sprintf("nodes cordoned: %s", strings.Join(cordoned, ",")))
+	}
+	if len(pressured) != 0 {
+		if len(msgs) == 0 {
+			degraded.Reason = "NodesUnderPressure"
+		}
+		msgs = append(msgs, fmt.Sprintf("nodes under pressure: %s", strings.Join(pressured, ",")))
+	}
+	if len(msgs) != 0 {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Message = strings.Join(msgs, "; ")
 	}
 
-	if notReadyNode != nodePool.Status.UnreadyNodeNum {
-		nodePool.Status.UnreadyNodeNum = notReadyNode
-		needUpdate = true
+	reachable := metav1.Condition{
+		Type:    appsv1alpha1.NodePoolAllNodesReachable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AllNodesReachable",
+		Message: "all nodes in the pool are reporting their status",
+	}
+	if len(unreachable) != 0 {
+		reachable.Status = metav1.ConditionFalse
+		reachable.Reason = "NodesUnreachable"
+		reachable.Message = fmt.Sprintf("nodes unreachable: %s", strings.Join(unreachable, ","))
 	}
 
-	// update the node list on demand
-	sort.Strings(nodes)
-	sort.Strings(nodePool.Status.Nodes)
-	if !reflect.DeepEqual(nodes, nodePool.Status.Nodes) {
-		nodePool.Status.Nodes = nodes
-		needUpdate = true
+	partitioned := metav1.Condition{
+		Type:    appsv1alpha1.NodePoolPartitioned,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AsExpected",
+		Message: "the pool is connected with the cloud",
+	}
+	if len(summaries) != 0 && len(unreachable) == len(summaries) {
+		partitioned.Status = metav1.ConditionTrue
+		partitioned.Reason = "AllNodesUnreachable"
+		partitioned.Message = fmt.Sprintf("all nodes lost connection with the cloud, %d of %d nodes are running autonomously",
+			len(autonomous), len(summaries))
 	}
 
-	return needUpdate
+	return []metav1.Condition{ready, degraded, reachable, partitioned}
+}
+
+// setNodePoolCondition sets the `cond` in the nodepool status, it will return
+// true if the condition is changed. The LastTransitionTime is only updated
+// when the status of the condition is changed.
+func setNodePoolCondition(nodePool *appsv1alpha1.NodePool, cond metav1.Condition) bool {
+	cond.ObservedGeneration = nodePool.Generation
+	existing := meta.FindStatusCondition(nodePool.Status.Conditions, cond.Type)
+	if existing != nil &&
+		existing.Status == cond.Status &&
+		existing.Reason == cond.Reason &&
+		existing.Message == cond.Message &&
+		existing.ObservedGeneration == cond.ObservedGeneration {
+		return false
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, cond)
+	return true
 }
 
 // containTaint checks if `taint` is in `taints`, if yes it will return
@@ -450,6 +598,33 @@ func isNodeReady(node corev1.Node) bool {
 	return nc != nil && nc.Status == corev1.ConditionTrue
 }
 
+// isNodeLeaseStale checks if the lease of the node has not been renewed
+// within the nodeLeaseGracePeriod. If the lease is not stale yet, the
+// duration after which it goes stale unless renewed is returned as well,
+// since the leases are not watched by the controller.
+func (r *NodePoolReconciler) isNodeLeaseStale(ctx context.Context, nodeName string) (bool, time.Duration, error) {
+	var lease coordinationv1.Lease
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: corev1.NamespaceNodeLease,
+		Name:      nodeName,
+	}, &lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the node does not report heartbeat through lease, only the
+			// ready condition is considered
+			return false, 0, nil
+		}
+		return false, 0, err
+	}
+	if lease.Spec.RenewTime == nil {
+		return true, 0, nil
+	}
+	staleAfter := nodeLeaseGracePeriod - time.Since(lease.Spec.RenewTime.Time)
+	if staleAfter <= 0 {
+		return true, 0, nil
+	}
+	return false, staleAfter, nil
+}
+
 func mergeMap(m1, m2 map[string]string) map[string]string {
 	if m1 == nil {
 		m1 = make(map[string]string)
@@ -460,32 +635,6 @@ func mergeMap(m1, m2 map[string]string) map[string]string {
 	return m1
 }
 
-// removeTaint removes `taint` from `taints` if exist
-func removeTaint(taint corev1.Taint, taints []corev1.Taint) []corev1.Taint {
-	for i := 0; i < len(taints); i++ {
-		if taint.Key == taints[i].Key && taint.Effect == taints[i].Effect {
-			taints = append(taints[:i], taints[i+1:]...)
-			break
-		}
-	}
-	return taints
-}
-
-// cachePrevPoolAttrs caches the nodepool-related attributes to the
-// node's annotation
-func cachePrevPoolAttrs(node *corev1.Node,
-	npra NodePoolRelatedAttributes) error {
-	npraJson, err := json.Marshal(npra)
-	if err != nil {
-		return err
-	}
-	if node.Annotations == nil {
-		node.Annotations = make(map[string]string)
-	}
-	node.Annotations[appsv1alpha1.AnnotationPrevAttrs] = string(npraJson)
-	return nil
-}
-
 // addNodePoolToWorkQueue adds the nodepool the reconciler's workqueue
 func addNodePoolToWorkQueue(npName string,
 	q workqueue.RateLimitingInterface) {
@@ -17,15 +17,18 @@ limitations under the License.
 package nodepool
 
 import (
+	"context"
 	"encoding/json"
 	"reflect"
-	"sort"
 	"testing"
 	"time"
 
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -37,595 +40,16 @@ const (
 	succeed = "\u2713"
 )
 
-func TestConciliateLables(t *testing.T) {
-	tests := []struct {
-		name      string
-		node      *corev1.Node
-		oldLabels map[string]string
-		newLabels map[string]string
-		expect    map[string]string
-	}{
-		{
-			"remove lable",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-node",
-					Labels: map[string]string{
-						"foo": "bar",
-						"buz": "qux",
-					},
-				},
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{"foo": "bar"},
-			map[string]string{"foo": "bar"},
-		},
-		{
-			"add labels",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:   "test-node",
-					Labels: map[string]string{"foo": "bar"},
-				},
-			},
-			map[string]string{"foo": "bar"},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-		},
-		{
-			"remove and add labels",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-node",
-					Labels: map[string]string{
-						"foo": "bar",
-						"buz": "qux",
-					},
-				},
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{
-				"foo":  "bar",
-				"quux": "quuz",
-			},
-			map[string]string{
-				"foo":  "bar",
-				"quux": "quuz",
-			},
-		},
-		{
-			"with existing node labels",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-node",
-					Labels: map[string]string{
-						"foo":    "bar",
-						"buz":    "qux",
-						"grault": "corge",
-					},
-				},
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{
-				"foo":  "bar",
-				"quux": "quuz",
-			},
-			map[string]string{
-				"foo":    "bar",
-				"quux":   "quuz",
-				"grault": "corge",
-			},
-		},
-	}
-	for _, tt := range tests {
-		st := tt
-		tf := func(t *testing.T) {
-			t.Parallel()
-			t.Logf("\tTestCase: %s", st.name)
-			{
-				conciliateLabels(st.node, st.oldLabels, st.newLabels)
-				get := st.node.Labels
-				if !reflect.DeepEqual(get, st.expect) {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
-				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
-
-			}
-		}
-		t.Run(st.name, tf)
-	}
-}
-
-func TestConciliateAnnotations(t *testing.T) {
-	tests := []struct {
-		name     string
-		node     *corev1.Node
-		oldAnnos map[string]string
-		newAnnos map[string]string
-		expect   map[string]string
-	}{
-		{
-			"remove an annotation",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-node",
-					Annotations: map[string]string{
-						"foo": "bar",
-						"buz": "qux",
-					},
-				},
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{"foo": "bar"},
-			map[string]string{"foo": "bar"},
-		},
-		{
-			"add an annotation",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:        "test-node",
-					Annotations: map[string]string{"foo": "bar"},
-				},
-			},
-			map[string]string{"foo": "bar"},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-		},
-		{
-			"remove and add an annotation",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-node",
-					Annotations: map[string]string{
-						"foo": "bar",
-						"buz": "qux",
-					},
-				},
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{
-				"foo":  "bar",
-				"quux": "quuz",
-			},
-			map[string]string{
-				"foo":  "bar",
-				"quux": "quuz",
-			},
-		},
-		{
-			"with existing node annotations",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-node",
-					Annotations: map[string]string{
-						"foo":    "bar",
-						"buz":    "qux",
-						"grault": "corge",
-					},
-				},
-			},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{
-				"foo":  "bar",
-				"quux": "quuz",
-			},
-			map[string]string{
-				"foo":    "bar",
-				"quux":   "quuz",
-				"grault": "corge",
-			},
-		},
-	}
-	for _, tt := range tests {
-		st := tt
-		tf := func(t *testing.T) {
-			t.Parallel()
-			t.Logf("\tTestCase: %s", st.name)
-			{
-				conciliateAnnotations(st.node, st.oldAnnos, st.newAnnos)
-				get := st.node.Annotations
-				if !reflect.DeepEqual(get, st.expect) {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
-				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
-
-			}
-		}
-		t.Run(st.name, tf)
-	}
-}
-
-func TestConciliateTaints(t *testing.T) {
-	tests := []struct {
-		name      string
-		node      corev1.Node
-		preTaints []corev1.Taint
-		newTaints []corev1.Taint
-		expect    []corev1.Taint
-	}{
-		{
-			"remove the taint",
-			corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
-				Spec: corev1.NodeSpec{
-					Taints: []corev1.Taint{
-						{
-							Key:    "foo",
-							Effect: corev1.TaintEffectNoSchedule,
-						},
-					},
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Effect: corev1.TaintEffectNoSchedule,
-				},
-			},
-			[]corev1.Taint{},
-			[]corev1.Taint{},
-		},
-		{
-			"add a new taint",
-			corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
-				Spec: corev1.NodeSpec{
-					Taints: []corev1.Taint{
-						{
-							Key:    "foo",
-							Effect: corev1.TaintEffectNoSchedule,
-						},
-					},
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Effect: corev1.TaintEffectNoSchedule,
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Effect: corev1.TaintEffectNoSchedule,
-				},
-				{
-					Key:    "bar",
-					Effect: corev1.TaintEffectNoExecute,
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Effect: corev1.TaintEffectNoSchedule,
-				},
-				{
-					Key:    "bar",
-					Effect: corev1.TaintEffectNoExecute,
-				},
-			},
-		},
-		{
-			"update a taint",
-			corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
-				Spec: corev1.NodeSpec{
-					Taints: []corev1.Taint{
-						{
-							Key:    "foo",
-							Effect: corev1.TaintEffectNoSchedule,
-						},
-					},
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Effect: corev1.TaintEffectNoSchedule,
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Value:  "bar",
-					Effect: corev1.TaintEffectNoExecute,
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Value:  "bar",
-					Effect: corev1.TaintEffectNoExecute,
-				},
-			},
-		},
-		{
-			"with existing node taints",
-			corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
-				Spec: corev1.NodeSpec{
-					Taints: []corev1.Taint{
-						{
-							Key:    "foo",
-							Effect: corev1.TaintEffectNoSchedule,
-						},
-						{
-							Key:    "qux",
-							Effect: corev1.TaintEffectNoSchedule,
-						},
-					},
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Effect: corev1.TaintEffectNoSchedule,
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Value:  "bar",
-					Effect: corev1.TaintEffectNoExecute,
-				},
-			},
-			[]corev1.Taint{
-				{
-					Key:    "foo",
-					Value:  "bar",
-					Effect: corev1.TaintEffectNoExecute,
-				},
-				{
-					Key:    "qux",
-					Effect: corev1.TaintEffectNoSchedule,
-				},
-			},
-		},
-	}
-	for _, tt := range tests {
-		st := tt
-		tf := func(t *testing.T) {
-			t.Parallel()
-			t.Logf("\tTestCase: %s", st.name)
-			{
-				conciliateTaints(&st.node, st.preTaints, st.newTaints)
-				get := st.node.Spec.Taints
-				if !taintSliceEqual(get, st.expect) {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
-				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
-
-			}
-		}
-		t.Run(st.name, tf)
-	}
-}
-
-func taintSliceEqual(s1, s2 []corev1.Taint) bool {
-	sort.Slice(s1, func(i, j int) bool {
-		if s1[i].Key < s1[j].Key {
-			return true
-		}
-		if s1[i].Key > s1[j].Key {
-			return false
-		}
-		// if s1[i].Key == s1[j].Key, compare the Effect
-		return s1[i].Effect < s1[j].Effect
-	})
-	sort.Slice(s2, func(i, j int) bool {
-		if s2[i].Key < s2[j].Key {
-			return true
-		}
-		if s2[i].Key > s2[j].Key {
-			return false
-		}
-		return s2[i].Effect < s2[j].Effect
-	})
-	return reflect.DeepEqual(s1, s2)
-}
-
-func TestContainTaint(t *testing.T) {
-	tmpTime := metav1.Now()
-	tests := []struct {
-		name   string
-		taint  corev1.Taint
-		taints []corev1.Taint
-		expect bool
-	}{
-		{
-			"containt the taint",
-			corev1.Taint{
-				Key:    "foo",
-				Effect: corev1.TaintEffectNoSchedule,
-			},
-			[]corev1.Taint{
-				{
-					Key:       "foo",
-					Value:     "bar",
-					Effect:    corev1.TaintEffectNoSchedule,
-					TimeAdded: &tmpTime,
-				},
-			},
-			true,
-		},
-		{
-			"not containt the taint",
-			corev1.Taint{
-				Key:    "foo",
-				Effect: corev1.TaintEffectNoSchedule,
-			},
-			[]corev1.Taint{
-				{
-					Key:       "foo",
-					Value:     "bar",
-					Effect:    corev1.TaintEffectNoExecute,
-					TimeAdded: &tmpTime,
-				},
-			},
-			false,
-		},
-	}
-	for _, tt := range tests {
-		st := tt
-		tf := func(t *testing.T) {
-			t.Parallel()
-			t.Logf("\tTestCase: %s", st.name)
-			{
-				_, get := containTaint(st.taint, st.taints)
-				if get != st.expect {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
-				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
-
-			}
-		}
-		t.Run(st.name, tf)
-	}
-}
-
-func TestIsNodeReady(t *testing.T) {
-	tests := []struct {
-		name   string
-		node   corev1.Node
-		expect bool
-	}{
-		{
-			"node is ready",
-			corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
-				Status: corev1.NodeStatus{
-					Conditions: []corev1.NodeCondition{
-						{
-							Type:   corev1.NodeReady,
-							Status: corev1.ConditionTrue,
-						},
-					},
-				},
-			},
-			true,
-		},
-		{
-			"node is not ready",
-			corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
-				Status: corev1.NodeStatus{
-					Conditions: []corev1.NodeCondition{},
-				},
-			},
-			false,
-		},
-	}
-	for _, tt := range tests {
-		st := tt
-		tf := func(t *testing.T) {
-			t.Parallel()
-			t.Logf("\tTestCase: %s", st.name)
-			{
-				get := isNodeReady(st.node)
-				if get != st.expect {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
-				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
-
-			}
-		}
-		t.Run(st.name, tf)
-	}
-}
-
-func TestMergeMap(t *testing.T) {
-	tests := []struct {
-		name   string
-		map1   map[string]string
-		map2   map[string]string
-		expect map[string]string
-	}{
-		{
-			"add new key/val",
-			map[string]string{"foo": "bar"},
-			map[string]string{"buz": "qux"},
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-		},
-		{
-			"nil m1 map",
-			nil,
-			map[string]string{"buz": "qux"},
-			map[string]string{"buz": "qux"},
-		},
-		{
-			"replace exist key/val",
-			map[string]string{
-				"foo": "bar",
-				"buz": "qux",
-			},
-			map[string]string{"buz": "quux"},
-			map[string]string{
-				"foo": "bar",
-				"buz": "quux",
-			},
-		},
-	}
-	for _, tt := range tests {
-		st := tt
-		tf := func(t *testing.T) {
-			t.Parallel()
-			t.Logf("\tTestCase: %s", st.name)
-			{
-				get := mergeMap(st.map1, st.map2)
-				if !reflect.DeepEqual(get, st.expect) {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
-				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
-
-			}
-		}
-		t.Run(st.name, tf)
-	}
-}
-
-func TestRemoveTaint(t *testing.T) {
+func TestContainTaint(t *testing.T) {
 	tmpTime := metav1.Now()
 	tests := []struct {
 		name   string
 		taint  corev1.Taint
 		taints []corev1.Taint
-		expect []corev1.Taint
+		expect bool
 	}{
 		{
-			"remove the taint",
+			"containt the taint",
 			corev1.Taint{
 				Key:    "foo",
 				Effect: corev1.TaintEffectNoSchedule,
@@ -638,10 +62,10 @@ func TestRemoveTaint(t *testing.T) {
 					TimeAdded: &tmpTime,
 				},
 			},
-			[]corev1.Taint{},
+			true,
 		},
 		{
-			"not contained the taint",
+			"not containt the taint",
 			corev1.Taint{
 				Key:    "foo",
 				Effect: corev1.TaintEffectNoSchedule,
@@ -654,14 +78,7 @@ func TestRemoveTaint(t *testing.T) {
 					TimeAdded: &tmpTime,
 				},
 			},
-			[]corev1.Taint{
-				{
-					Key:       "foo",
-					Value:     "bar",
-					Effect:    corev1.TaintEffectNoExecute,
-					TimeAdded: &tmpTime,
-				},
-			},
+			false,
 		},
 	}
 	for _, tt := range tests {
@@ -670,8 +87,8 @@ func TestRemoveTaint(t *testing.T) {
 			t.Parallel()
 			t.Logf("\tTestCase: %s", st.name)
 			{
-				get := removeTaint(st.taint, st.taints)
-				if !reflect.DeepEqual(get, st.expect) {
+				_, get := containTaint(st.taint, st.taints)
+				if get != st.expect {
 					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
 				}
 				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
@@ -682,301 +99,134 @@ func TestRemoveTaint(t *testing.T) {
 	}
 }
 
-// prepare variables that can be reused
-
-var testLabel1 map[string]string = map[string]string{
-	"foo":                             "bar",
-	"buz":                             "qux",
-	appsv1alpha1.LabelCurrentNodePool: "test",
-}
-
-var testAnnotations map[string]string = map[string]string{
-	"foo": "qux",
-	"buz": "qux",
-}
-
-var testTaints []corev1.Taint = []corev1.Taint{
-	{
-		Key:    "foo",
-		Value:  "bar",
-		Effect: corev1.TaintEffectNoExecute,
-	},
-}
-
-var currentTaints = []corev1.Taint{
-	{
-		Key:    "foo",
-		Value:  "beijing",
-		Effect: corev1.TaintEffectNoExecute,
-	},
-}
-
-var testNPRA1 NodePoolRelatedAttributes = NodePoolRelatedAttributes{
-	Labels:      testLabel1,
-	Annotations: testAnnotations,
-	Taints:      testTaints,
-}
-
-func TestCachePrevPoolAttrs(t *testing.T) {
-
-	npraBytes, _ := json.Marshal(testNPRA1)
-
-	var annotationWithNP map[string]string = map[string]string{
-		"foo":                            "qux",
-		"buz":                            "qux",
-		appsv1alpha1.AnnotationPrevAttrs: string(npraBytes),
-	}
-
+func TestIsNodeReady(t *testing.T) {
 	tests := []struct {
 		name   string
-		node   *corev1.Node
-		npra   NodePoolRelatedAttributes
-		expect error
+		node   corev1.Node
+		expect bool
 	}{
 		{
-			"node already cached",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels:      testLabel1,
-					Annotations: annotationWithNP,
-				},
-				Spec: corev1.NodeSpec{
-					Taints: testTaints,
+			"node is ready",
+			corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:   corev1.NodeReady,
+							Status: corev1.ConditionTrue,
+						},
+					},
 				},
 			},
-			testNPRA1,
-			nil,
+			true,
 		},
 		{
-			"node without cached annotation",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels:      testLabel1,
-					Annotations: nil,
-				},
-				Spec: corev1.NodeSpec{
-					Taints: testTaints,
+			"node is not ready",
+			corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{},
 				},
 			},
-			testNPRA1,
-			nil,
+			false,
 		},
 	}
-
 	for _, tt := range tests {
 		st := tt
 		tf := func(t *testing.T) {
 			t.Parallel()
 			t.Logf("\tTestCase: %s", st.name)
 			{
-				get := cachePrevPoolAttrs(st.node, st.npra)
+				get := isNodeReady(st.node)
 				if get != st.expect {
 					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
 				}
 				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+
 			}
 		}
 		t.Run(st.name, tf)
 	}
 }
 
-func TestConciliateNode(t *testing.T) {
-
-	npraBytes, _ := json.Marshal(testNPRA1)
-
-	// test cases
+func TestMergeMap(t *testing.T) {
 	tests := []struct {
 		name   string
-		node   *corev1.Node
-		np     appsv1alpha1.NodePool
-		expect bool
+		map1   map[string]string
+		map2   map[string]string
+		expect map[string]string
 	}{
 		{
-			"nothing to update",
-			// use DeepCopy because of the side effects
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: map[string]string{
-						"foo":                            "qux",
-						"buz":                            "qux",
-						appsv1alpha1.AnnotationPrevAttrs: string(npraBytes),
-					},
-				},
-				Spec: corev1.NodeSpec{
-					Taints: testTaints,
-				},
-			},
-			appsv1alpha1.NodePool{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
-				},
-				Spec: appsv1alpha1.NodePoolSpec{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: testAnnotations,
-					Taints:      testTaints,
-				},
-			},
-			false,
-		},
-		{
-			"node change nodepool",
-			// use DeepCopy because of the side effects
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: map[string]string{
-						"foo":                            "qux",
-						"buz":                            "qux",
-						appsv1alpha1.AnnotationPrevAttrs: string(npraBytes),
-					},
-				},
-				Spec: corev1.NodeSpec{
-					Taints: testTaints,
-				},
-			},
-			appsv1alpha1.NodePool{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
-				},
-				Spec: appsv1alpha1.NodePoolSpec{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: testAnnotations,
-					Taints:      currentTaints,
-				},
-			},
-			true,
-		},
-		{
-			"outdated npra attrs",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: map[string]string{
-						"foo":                            "qux",
-						"buz":                            "qux",
-						appsv1alpha1.AnnotationPrevAttrs: string(npraBytes),
-					},
-				},
-				Spec: corev1.NodeSpec{
-					Taints: testTaints,
-				},
-			},
-			appsv1alpha1.NodePool{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
-				},
-				Spec: appsv1alpha1.NodePoolSpec{
-					Labels: map[string]string{
-						"foo":                             "qux",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: testAnnotations,
-					Taints:      testTaints,
-				},
-			},
-			true,
-		},
-		{
-			"no npra attrs",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: nil,
-				},
-				Spec: corev1.NodeSpec{
-					Taints: testTaints,
-				},
-			},
-			appsv1alpha1.NodePool{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
-				},
-				Spec: appsv1alpha1.NodePoolSpec{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: testAnnotations,
-					Taints:      testTaints,
-				},
+			"add new key/val",
+			map[string]string{"foo": "bar"},
+			map[string]string{"buz": "qux"},
+			map[string]string{
+				"foo": "bar",
+				"buz": "qux",
 			},
-			true,
 		},
 		{
-			"nodepool name not match owner label",
-			&corev1.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":                             "qux",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: nil,
-				},
-				Spec: corev1.NodeSpec{
-					Taints: testTaints,
-				},
+			"nil m1 map",
+			nil,
+			map[string]string{"buz": "qux"},
+			map[string]string{"buz": "qux"},
+		},
+		{
+			"replace exist key/val",
+			map[string]string{
+				"foo": "bar",
+				"buz": "qux",
 			},
-			appsv1alpha1.NodePool{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test1",
-				},
-				Spec: appsv1alpha1.NodePoolSpec{
-					Labels: map[string]string{
-						"foo":                             "bar",
-						"buz":                             "qux",
-						appsv1alpha1.LabelCurrentNodePool: "test",
-					},
-					Annotations: testAnnotations,
-					Taints:      testTaints,
-				},
+			map[string]string{"buz": "quux"},
+			map[string]string{
+				"foo": "bar",
+				"buz": "quux",
 			},
-			true,
 		},
 	}
-
 	for _, tt := range tests {
 		st := tt
 		tf := func(t *testing.T) {
+			t.Parallel()
 			t.Logf("\tTestCase: %s", st.name)
 			{
-				get, _ := concilateNode(st.node, st.np)
-				if get != st.expect {
+				get := mergeMap(st.map1, st.map2)
+				if !reflect.DeepEqual(get, st.expect) {
 					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
 				}
 				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+
 			}
 		}
 		t.Run(st.name, tf)
 	}
+}
+
+// prepare variables that can be reused
+
+var testLabel1 map[string]string = map[string]string{
+	"foo":                             "bar",
+	"buz":                             "qux",
+	appsv1alpha1.LabelCurrentNodePool: "test",
+}
 
+var testAnnotations map[string]string = map[string]string{
+	"foo": "qux",
+	"buz": "qux",
+}
+
+var testTaints []corev1.Taint = []corev1.Taint{
+	{
+		Key:    "foo",
+		Value:  "bar",
+		Effect: corev1.TaintEffectNoExecute,
+	},
+}
+
+var testNPRA1 NodePoolRelatedAttributes = NodePoolRelatedAttributes{
+	Labels:      testLabel1,
+	Annotations: testAnnotations,
+	Taints:      testTaints,
 }
 
 func TestRemovePoolRelatedAttrs(t *testing.T) {
@@ -1038,11 +288,20 @@ func TestRemovePoolRelatedAttrs(t *testing.T) {
 }
 
 func TestConciliateNodePoolStatus(t *testing.T) {
+	readySummaries := []appsv1alpha1.NodeSummary{
+		{Name: "node1", Ready: corev1.ConditionTrue},
+	}
+	var readyConditions []metav1.Condition
+	for _, cond := range getNodePoolConditions(readySummaries) {
+		meta.SetStatusCondition(&readyConditions, cond)
+	}
+
 	tests := []struct {
 		name          string
 		readyNodes    int32
 		notReadyNodes int32
 		nodes         []string
+		summaries     []appsv1alpha1.NodeSummary
 		nodePool      *appsv1alpha1.NodePool
 		expect        bool
 	}{
@@ -1051,6 +310,7 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 			1,
 			1,
 			[]string{"node1"},
+			readySummaries,
 			&appsv1alpha1.NodePool{
 				Status: appsv1alpha1.NodePoolStatus{
 					ReadyNodeNum:   1,
@@ -1058,6 +318,8 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 					Nodes: []string{
 						"node1",
 					},
+					NodeSummaries: readySummaries,
+					Conditions:    readyConditions,
 				},
 			},
 			false,
@@ -1066,6 +328,7 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 			2,
 			1,
 			[]string{"node1"},
+			readySummaries,
 			&appsv1alpha1.NodePool{
 				Status: appsv1alpha1.NodePoolStatus{
 					ReadyNodeNum:   1,
@@ -1073,6 +336,8 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 					Nodes: []string{
 						"node1",
 					},
+					NodeSummaries: readySummaries,
+					Conditions:    readyConditions,
 				},
 			},
 			true,
@@ -1082,6 +347,7 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 			1,
 			2,
 			[]string{"node1"},
+			readySummaries,
 			&appsv1alpha1.NodePool{
 				Status: appsv1alpha1.NodePoolStatus{
 					ReadyNodeNum:   1,
@@ -1089,6 +355,8 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 					Nodes: []string{
 						"node1",
 					},
+					NodeSummaries: readySummaries,
+					Conditions:    readyConditions,
 				},
 			},
 			true,
@@ -1098,6 +366,7 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 			1,
 			1,
 			[]string{"node1", "node2"},
+			readySummaries,
 			&appsv1alpha1.NodePool{
 				Status: appsv1alpha1.NodePoolStatus{
 					ReadyNodeNum:   1,
@@ -1105,6 +374,8 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 					Nodes: []string{
 						"node1",
 					},
+					NodeSummaries: readySummaries,
+					Conditions:    readyConditions,
 				},
 			},
 			true,
@@ -1114,6 +385,68 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 			2,
 			1,
 			[]string{"node1", "node2"},
+			readySummaries,
+			&appsv1alpha1.NodePool{
+				Status: appsv1alpha1.NodePoolStatus{
+					ReadyNodeNum:   1,
+					UnreadyNodeNum: 1,
+					Nodes: []string{
+						"node1",
+					},
+					NodeSummaries: readySummaries,
+					Conditions:    readyConditions,
+				},
+			},
+			true,
+		},
+		{
+			"updated node summaries",
+			1,
+			1,
+			[]string{"node1"},
+			[]appsv1alpha1.NodeSummary{
+				{Name: "node1", Ready: corev1.ConditionTrue, Unschedulable: true},
+			},
+			&appsv1alpha1.NodePool{
+				Status: appsv1alpha1.NodePoolStatus{
+					ReadyNodeNum:   1,
+					UnreadyNodeNum: 1,
+					Nodes: []string{
+						"node1",
+					},
+					NodeSummaries: readySummaries,
+					Conditions:    readyConditions,
+				},
+			},
+			true,
+		},
+		{
+			"only heartbeat changed",
+			1,
+			1,
+			[]string{"node1"},
+			[]appsv1alpha1.NodeSummary{
+				{Name: "node1", Ready: corev1.ConditionTrue, LastHeartbeatTime: metav1.Unix(1000, 0)},
+			},
+			&appsv1alpha1.NodePool{
+				Status: appsv1alpha1.NodePoolStatus{
+					ReadyNodeNum:   1,
+					UnreadyNodeNum: 1,
+					Nodes: []string{
+						"node1",
+					},
+					NodeSummaries: readySummaries,
+					Conditions:    readyConditions,
+				},
+			},
+			false,
+		},
+		{
+			"updated conditions",
+			1,
+			1,
+			[]string{"node1"},
+			readySummaries,
 			&appsv1alpha1.NodePool{
 				Status: appsv1alpha1.NodePoolStatus{
 					ReadyNodeNum:   1,
@@ -1121,6 +454,7 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 					Nodes: []string{
 						"node1",
 					},
+					NodeSummaries: readySummaries,
 				},
 			},
 			true,
@@ -1133,7 +467,7 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 			t.Parallel()
 			t.Logf("\tTestCase: %s", st.name)
 			{
-				get := conciliateNodePoolStatus(st.readyNodes, st.notReadyNodes, st.nodes, st.nodePool)
+				get := conciliateNodePoolStatus(st.readyNodes, st.notReadyNodes, st.nodes, st.summaries, st.nodePool)
 				if get != st.expect {
 					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
 				}
@@ -1144,6 +478,221 @@ func TestConciliateNodePoolStatus(t *testing.T) {
 	}
 }
 
+func TestGetNodeSummary(t *testing.T) {
+	heartbeat := metav1.NewTime(time.Unix(1000, 0))
+	tests := []struct {
+		name   string
+		node   corev1.Node
+		expect appsv1alpha1.NodeSummary
+	}{
+		{
+			"ready node",
+			corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:              corev1.NodeReady,
+							Status:            corev1.ConditionTrue,
+							LastHeartbeatTime: heartbeat,
+						},
+					},
+				},
+			},
+			appsv1alpha1.NodeSummary{
+				Name:              "node1",
+				Ready:             corev1.ConditionTrue,
+				LastHeartbeatTime: heartbeat,
+			},
+		},
+		{
+			"cordoned node under pressure",
+			corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Spec:       corev1.NodeSpec{Unschedulable: true},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:              corev1.NodeReady,
+							Status:            corev1.ConditionFalse,
+							LastHeartbeatTime: heartbeat,
+						},
+						{
+							Type:   corev1.NodeMemoryPressure,
+							Status: corev1.ConditionFalse,
+						},
+						{
+							Type:   corev1.NodeDiskPressure,
+							Status: corev1.ConditionTrue,
+						},
+					},
+				},
+			},
+			appsv1alpha1.NodeSummary{
+				Name:               "node1",
+				Ready:              corev1.ConditionFalse,
+				LastHeartbeatTime:  heartbeat,
+				Unschedulable:      true,
+				PressureConditions: []corev1.NodeConditionType{corev1.NodeDiskPressure},
+			},
+		},
+		{
+			"autonomous node",
+			corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node1",
+					Annotations: map[string]string{
+						appsv1alpha1.AnnotationNodeAutonomy: "true",
+					},
+				},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:              corev1.NodeReady,
+							Status:            corev1.ConditionUnknown,
+							LastHeartbeatTime: heartbeat,
+						},
+					},
+				},
+			},
+			appsv1alpha1.NodeSummary{
+				Name:              "node1",
+				Ready:             corev1.ConditionUnknown,
+				LastHeartbeatTime: heartbeat,
+				Autonomy:          true,
+			},
+		},
+		{
+			"node without ready condition",
+			corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			},
+			appsv1alpha1.NodeSummary{
+				Name:  "node1",
+				Ready: corev1.ConditionUnknown,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := getNodeSummary(st.node)
+				if !reflect.DeepEqual(get, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestGetNodePoolConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		summaries []appsv1alpha1.NodeSummary
+		expect    map[string]metav1.ConditionStatus
+	}{
+		{
+			"empty pool",
+			nil,
+			map[string]metav1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:             metav1.ConditionFalse,
+				appsv1alpha1.NodePoolDegraded:          metav1.ConditionFalse,
+				appsv1alpha1.NodePoolAllNodesReachable: metav1.ConditionTrue,
+				appsv1alpha1.NodePoolPartitioned:       metav1.ConditionFalse,
+			},
+		},
+		{
+			"all nodes healthy",
+			[]appsv1alpha1.NodeSummary{
+				{Name: "node1", Ready: corev1.ConditionTrue},
+				{Name: "node2", Ready: corev1.ConditionTrue},
+			},
+			map[string]metav1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:             metav1.ConditionTrue,
+				appsv1alpha1.NodePoolDegraded:          metav1.ConditionFalse,
+				appsv1alpha1.NodePoolAllNodesReachable: metav1.ConditionTrue,
+				appsv1alpha1.NodePoolPartitioned:       metav1.ConditionFalse,
+			},
+		},
+		{
+			"cordoned node",
+			[]appsv1alpha1.NodeSummary{
+				{Name: "node1", Ready: corev1.ConditionTrue, Unschedulable: true},
+			},
+			map[string]metav1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:             metav1.ConditionTrue,
+				appsv1alpha1.NodePoolDegraded:          metav1.ConditionTrue,
+				appsv1alpha1.NodePoolAllNodesReachable: metav1.ConditionTrue,
+				appsv1alpha1.NodePoolPartitioned:       metav1.ConditionFalse,
+			},
+		},
+		{
+			"unreachable node",
+			[]appsv1alpha1.NodeSummary{
+				{Name: "node1", Ready: corev1.ConditionTrue},
+				{Name: "node2", Ready: corev1.ConditionUnknown},
+			},
+			map[string]metav1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:             metav1.ConditionFalse,
+				appsv1alpha1.NodePoolDegraded:          metav1.ConditionTrue,
+				appsv1alpha1.NodePoolAllNodesReachable: metav1.ConditionFalse,
+				appsv1alpha1.NodePoolPartitioned:       metav1.ConditionFalse,
+			},
+		},
+		{
+			"autonomous node lost connection",
+			[]appsv1alpha1.NodeSummary{
+				{Name: "node1", Ready: corev1.ConditionTrue},
+				{Name: "node2", Ready: corev1.ConditionUnknown, Autonomy: true, LeaseStale: true},
+			},
+			map[string]metav1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:             metav1.ConditionFalse,
+				appsv1alpha1.NodePoolDegraded:          metav1.ConditionTrue,
+				appsv1alpha1.NodePoolAllNodesReachable: metav1.ConditionFalse,
+				appsv1alpha1.NodePoolPartitioned:       metav1.ConditionFalse,
+			},
+		},
+		{
+			"all nodes lost connection",
+			[]appsv1alpha1.NodeSummary{
+				{Name: "node1", Ready: corev1.ConditionUnknown, Autonomy: true, LeaseStale: true},
+				{Name: "node2", Ready: corev1.ConditionUnknown, LeaseStale: true},
+			},
+			map[string]metav1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:             metav1.ConditionFalse,
+				appsv1alpha1.NodePoolDegraded:          metav1.ConditionTrue,
+				appsv1alpha1.NodePoolAllNodesReachable: metav1.ConditionFalse,
+				appsv1alpha1.NodePoolPartitioned:       metav1.ConditionTrue,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := make(map[string]metav1.ConditionStatus)
+				for _, cond := range getNodePoolConditions(st.summaries) {
+					get[cond.Type] = cond.Status
+				}
+				if !reflect.DeepEqual(get, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
 func TestCreateNP(t *testing.T) {
 
 	scheme := runtime.NewScheme()
@@ -1311,3 +860,79 @@ func TestGetRemovedNodes(t *testing.T) {
 		t.Run(st.name, tf)
 	}
 }
+
+func TestIsNodeLeaseStale(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+
+	renewed := metav1.NewMicroTime(time.Now())
+	expired := metav1.NewMicroTime(time.Now().Add(-2 * nodeLeaseGracePeriod))
+	newLease := func(name string, renewTime *metav1.MicroTime) *coordinationv1.Lease {
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: corev1.NamespaceNodeLease},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: renewTime},
+		}
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			newLease("renewed", &renewed),
+			newLease("expired", &expired),
+			newLease("never-renewed", nil)).
+		Build()
+	r := &NodePoolReconciler{Client: cl, Scheme: scheme}
+
+	tests := []struct {
+		name             string
+		nodeName         string
+		expect           bool
+		expectStaleAfter bool
+	}{
+		{
+			"lease is renewed",
+			"renewed",
+			false,
+			true,
+		},
+		{
+			"lease is expired",
+			"expired",
+			true,
+			false,
+		},
+		{
+			"lease is never renewed",
+			"never-renewed",
+			true,
+			false,
+		},
+		{
+			"lease does not exist",
+			"no-lease",
+			false,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get, staleAfter, err := r.isNodeLeaseStale(context.TODO(), st.nodeName)
+				if err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				if (staleAfter > 0) != st.expectStaleAfter || staleAfter > nodeLeaseGracePeriod {
+					t.Fatalf("\t%s\texpect the lease to go stale later %v, but get %v", failed, st.expectStaleAfter, staleAfter)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -22,8 +22,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
@@ -188,6 +191,45 @@ func TestUpdate(t *testing.T) {
 			createQueue(),
 			1,
 		},
+		{
+			"only node heartbeat change",
+			&corev1.Node{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha1.LabelDesiredNodePool: "test",
+						v1alpha1.LabelCurrentNodePool: "test",
+					},
+				},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:              corev1.NodeReady,
+							Status:            corev1.ConditionTrue,
+							LastHeartbeatTime: v1.Unix(2000, 0),
+						},
+					},
+				},
+			},
+			&corev1.Node{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: map[string]string{
+						v1alpha1.LabelDesiredNodePool: "test",
+						v1alpha1.LabelCurrentNodePool: "test",
+					},
+				},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:              corev1.NodeReady,
+							Status:            corev1.ConditionTrue,
+							LastHeartbeatTime: v1.Unix(1000, 0),
+						},
+					},
+				},
+			},
+			createQueue(),
+			0,
+		},
 		{
 			"nothing change ",
 			&corev1.Node{
@@ -315,3 +357,151 @@ func TestDelete(t *testing.T) {
 	}
 
 }
+
+func TestUpdateSelectedNode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			newAdoptionPool("hangzhou", map[string]string{"region": "hangzhou"}),
+			newAdoptionPool("beijing", map[string]string{"region": "beijing"}),
+			&v1alpha1.NodePool{
+				ObjectMeta: v1.ObjectMeta{Name: "shanghai"},
+				Spec: v1alpha1.NodePoolSpec{
+					Selector: &v1.LabelSelector{MatchLabels: map[string]string{"region": "shanghai"}},
+				},
+			}).
+		Build()
+	e := EnqueueNodePoolForSelectedNode{client: cl}
+
+	tests := []struct {
+		name      string
+		oldLabels map[string]string
+		newLabels map[string]string
+		q         workqueue.RateLimitingInterface
+		added     int // the items in queue
+	}{
+		{
+			"labels not changed",
+			map[string]string{"region": "hangzhou"},
+			map[string]string{"region": "hangzhou"},
+			createQueue(),
+			0,
+		},
+		{
+			"node is selected by a new pool",
+			map[string]string{},
+			map[string]string{"region": "hangzhou"},
+			createQueue(),
+			1,
+		},
+		{
+			"node moves between selecting pools",
+			map[string]string{"region": "hangzhou"},
+			map[string]string{"region": "beijing"},
+			createQueue(),
+			2,
+		},
+		{
+			"pool does not adopt nodes by selector",
+			map[string]string{},
+			map[string]string{"region": "shanghai"},
+			createQueue(),
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				e.Update(event.UpdateEvent{
+					ObjectOld: &corev1.Node{ObjectMeta: v1.ObjectMeta{Labels: st.oldLabels}},
+					ObjectNew: &corev1.Node{ObjectMeta: v1.ObjectMeta{Labels: st.newLabels}},
+				}, st.q)
+				get := st.q.Len()
+				if get != st.added {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.added, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.added, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestUpdatePod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&corev1.Node{ObjectMeta: v1.ObjectMeta{
+				Name:   "node1",
+				Labels: map[string]string{v1alpha1.LabelCurrentNodePool: "hangzhou"},
+			}},
+			&corev1.Node{ObjectMeta: v1.ObjectMeta{
+				Name:   "node2",
+				Labels: map[string]string{v1alpha1.LabelCurrentNodePool: "beijing"},
+			}},
+			&corev1.Node{ObjectMeta: v1.ObjectMeta{Name: "node3"}}).
+		Build()
+	e := EnqueueNodePoolForPod{client: cl}
+
+	tests := []struct {
+		name   string
+		oldPod *corev1.Pod
+		newPod *corev1.Pod
+		q      workqueue.RateLimitingInterface
+		added  int // the items in queue
+	}{
+		{
+			"pod is not changed",
+			newResourcePod("pod1", "node1", "1", "1Gi", corev1.PodRunning),
+			newResourcePod("pod1", "node1", "1", "1Gi", corev1.PodRunning),
+			createQueue(),
+			0,
+		},
+		{
+			"pod is scheduled",
+			newResourcePod("pod1", "", "1", "1Gi", corev1.PodPending),
+			newResourcePod("pod1", "node1", "1", "1Gi", corev1.PodPending),
+			createQueue(),
+			1,
+		},
+		{
+			"pod is terminated",
+			newResourcePod("pod1", "node2", "1", "1Gi", corev1.PodRunning),
+			newResourcePod("pod1", "node2", "1", "1Gi", corev1.PodFailed),
+			createQueue(),
+			1,
+		},
+		{
+			"node is not in any pool",
+			newResourcePod("pod1", "", "1", "1Gi", corev1.PodPending),
+			newResourcePod("pod1", "node3", "1", "1Gi", corev1.PodPending),
+			createQueue(),
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				e.Update(event.UpdateEvent{ObjectOld: st.oldPod, ObjectNew: st.newPod}, st.q)
+				get := st.q.Len()
+				if get != st.added {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.added, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.added, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
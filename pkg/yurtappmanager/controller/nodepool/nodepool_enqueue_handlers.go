@@ -17,12 +17,18 @@ limitations under the License.
 package nodepool
 
 import (
+	"context"
 	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
 )
@@ -90,9 +96,9 @@ func (e *EnqueueNodePoolForNode) Update(evt event.UpdateEvent,
 		return
 	}
 
-	if isNodeReady(*newNode) != isNodeReady(*oldNode) {
-		// if the newNode and oldNode status are different
-		klog.V(5).Infof("node phase has been changed,"+
+	if isNodeSummaryChanged(getNodeSummary(*newNode), getNodeSummary(*oldNode)) {
+		// if the newNode and oldNode health summary are different
+		klog.V(5).Infof("node health has been changed,"+
 			" will enqueue pool(%s) for node(%s)", newNp, newNode.GetName())
 		addNodePoolToWorkQueue(newNp, q)
 		return
@@ -135,3 +141,149 @@ func (e *EnqueueNodePoolForNode) Delete(evt event.DeleteEvent,
 func (e *EnqueueNodePoolForNode) Generic(evt event.GenericEvent,
 	q workqueue.RateLimitingInterface) {
 }
+
+// EnqueueNodePoolForSelectedNode enqueues the nodepools that adopt nodes by
+// selector and whose selector matches the node
+type EnqueueNodePoolForSelectedNode struct {
+	client client.Client
+}
+
+// Create implements EventHandler
+func (e *EnqueueNodePoolForSelectedNode) Create(evt event.CreateEvent,
+	q workqueue.RateLimitingInterface) {
+	e.addSelectingNodePoolsToWorkQueue(q, evt.Object.GetLabels())
+}
+
+// Update implements EventHandler
+func (e *EnqueueNodePoolForSelectedNode) Update(evt event.UpdateEvent,
+	q workqueue.RateLimitingInterface) {
+	if reflect.DeepEqual(evt.ObjectNew.GetLabels(), evt.ObjectOld.GetLabels()) {
+		return
+	}
+	// both the pools that selected the node before and the pools that
+	// select the node now need to be reconciled
+	e.addSelectingNodePoolsToWorkQueue(q,
+		evt.ObjectOld.GetLabels(), evt.ObjectNew.GetLabels())
+}
+
+// Delete implements EventHandler
+func (e *EnqueueNodePoolForSelectedNode) Delete(evt event.DeleteEvent,
+	q workqueue.RateLimitingInterface) {
+	e.addSelectingNodePoolsToWorkQueue(q, evt.Object.GetLabels())
+}
+
+// Generic implements EventHandler
+func (e *EnqueueNodePoolForSelectedNode) Generic(evt event.GenericEvent,
+	q workqueue.RateLimitingInterface) {
+}
+
+// addSelectingNodePoolsToWorkQueue adds the nodepools whose selector matches
+// any of the node label sets to the workqueue
+func (e *EnqueueNodePoolForSelectedNode) addSelectingNodePoolsToWorkQueue(
+	q workqueue.RateLimitingInterface, labelSets ...map[string]string) {
+	var poolList appsv1alpha1.NodePoolList
+	if err := e.client.List(context.TODO(), &poolList); err != nil {
+		klog.Errorf("fail to list nodepools, %v", err)
+		return
+	}
+	selectors, err := getAdoptionSelectors(poolList.Items, "")
+	if err != nil {
+		klog.Errorf("fail to get nodepool selectors, %v", err)
+		return
+	}
+	for name, s := range selectors {
+		for _, ls := range labelSets {
+			if s.Matches(labels.Set(ls)) {
+				klog.V(5).Infof("will enqueue pool(%s) as its selector matches the node", name)
+				addNodePoolToWorkQueue(name, q)
+				break
+			}
+		}
+	}
+}
+
+var _ handler.EventHandler = &EnqueueNodePoolForSelectedNode{}
+
+// EnqueueNodePoolForPod enqueues the nodepool that the node of the pod
+// belongs to, so that the resources requested in the pool can be updated
+type EnqueueNodePoolForPod struct {
+	client client.Client
+}
+
+// Create implements EventHandler
+func (e *EnqueueNodePoolForPod) Create(evt event.CreateEvent,
+	q workqueue.RateLimitingInterface) {
+	pod, ok := evt.Object.(*corev1.Pod)
+	if !ok {
+		klog.Error("fail to assert runtime Object to v1.Pod")
+		return
+	}
+	e.addNodePoolOfNodeToWorkQueue(q, pod.Spec.NodeName)
+}
+
+// Update implements EventHandler
+func (e *EnqueueNodePoolForPod) Update(evt event.UpdateEvent,
+	q workqueue.RateLimitingInterface) {
+	newPod, ok := evt.ObjectNew.(*corev1.Pod)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to v1.Pod",
+			evt.ObjectNew.GetName())
+		return
+	}
+	oldPod, ok := evt.ObjectOld.(*corev1.Pod)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to v1.Pod",
+			evt.ObjectOld.GetName())
+		return
+	}
+	// only the pods that are scheduled or terminated affect the resources
+	// requested in the pool
+	if newPod.Spec.NodeName != oldPod.Spec.NodeName {
+		e.addNodePoolOfNodeToWorkQueue(q, oldPod.Spec.NodeName)
+		e.addNodePoolOfNodeToWorkQueue(q, newPod.Spec.NodeName)
+		return
+	}
+	if isPodTerminated(newPod) != isPodTerminated(oldPod) {
+		e.addNodePoolOfNodeToWorkQueue(q, newPod.Spec.NodeName)
+	}
+}
+
+// Delete implements EventHandler
+func (e *EnqueueNodePoolForPod) Delete(evt event.DeleteEvent,
+	q workqueue.RateLimitingInterface) {
+	pod, ok := evt.Object.(*corev1.Pod)
+	if !ok {
+		klog.Error("fail to assert runtime Object to v1.Pod")
+		return
+	}
+	e.addNodePoolOfNodeToWorkQueue(q, pod.Spec.NodeName)
+}
+
+// Generic implements EventHandler
+func (e *EnqueueNodePoolForPod) Generic(evt event.GenericEvent,
+	q workqueue.RateLimitingInterface) {
+}
+
+// addNodePoolOfNodeToWorkQueue adds the nodepool that the node belongs to
+// to the workqueue
+func (e *EnqueueNodePoolForPod) addNodePoolOfNodeToWorkQueue(
+	q workqueue.RateLimitingInterface, nodeName string) {
+	if nodeName == "" {
+		return
+	}
+	var node corev1.Node
+	if err := e.client.Get(context.TODO(), types.NamespacedName{Name: nodeName}, &node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("fail to get node(%s), %v", nodeName, err)
+		}
+		return
+	}
+	np := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+	if np == "" {
+		return
+	}
+	klog.V(5).Infof("will enqueue pool(%s) as pods on node(%s) have been changed", np, nodeName)
+	addNodePoolToWorkQueue(np, q)
+}
+
+var _ handler.EventHandler = &EnqueueNodePoolForPod{}
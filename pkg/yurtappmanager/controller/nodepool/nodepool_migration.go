@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+)
+
+// migrationRequeueInterval is the interval to check the progress of
+// the migrating nodes
+var migrationRequeueInterval = 5 * time.Second
+
+const (
+	eventTypeNodeMigrationStarted   = "NodeMigrationStarted"
+	eventTypeNodeMigrationBlocked   = "NodeMigrationBlocked"
+	eventTypeNodeMigrationCompleted = "NodeMigrationCompleted"
+)
+
+// migrateNode migrates the node out of the nodePool. The node will be
+// cordoned first, then the pods that belong to the pool-scoped workloads
+// (YurtAppSet, YurtAppDaemon) will be evicted, the nodepool related
+// attributes will be removed only after all of these pods are gone.
+// It returns true if the migration is completed.
+func (r *NodePoolReconciler) migrateNode(ctx context.Context, node *corev1.Node,
+	nodePool *appsv1alpha1.NodePool) (completed bool, err error) {
+	to := node.Labels[appsv1alpha1.LabelDesiredNodePool]
+	if node.Annotations[appsv1alpha1.AnnotationMigrationPhase] == "" {
+		startMigration(node, nodePool.GetName(), to)
+		if err := r.Update(ctx, node); err != nil {
+			return false, err
+		}
+		klog.V(4).Infof("start migrating node(%s) from nodepool(%s) to nodepool(%s)",
+			node.GetName(), nodePool.GetName(), to)
+		r.recordMigrationEvent(ctx, nodePool, to, corev1.EventTypeNormal,
+			eventTypeNodeMigrationStarted,
+			"start migrating node %s from pool %q to pool %q", node.GetName(), nodePool.GetName(), to)
+	}
+
+	pods, err := r.getPoolScopedPods(ctx, node.GetName(), nodePool.GetName())
+	if err != nil {
+		return false, err
+	}
+
+	if len(pods) == 0 {
+		if err := removePoolRelatedAttrs(node); err != nil {
+			return false, err
+		}
+		completeMigration(node)
+		if err := r.Update(ctx, node); err != nil {
+			return false, err
+		}
+		klog.V(4).Infof("node(%s) has been migrated out of nodepool(%s)",
+			node.GetName(), nodePool.GetName())
+		r.recordMigrationEvent(ctx, nodePool, to, corev1.EventTypeNormal,
+			eventTypeNodeMigrationCompleted,
+			"node %s has been migrated from pool %q to pool %q", node.GetName(), nodePool.GetName(), to)
+		return true, nil
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		err := r.evictPod(ctx, pod)
+		if err == nil || apierrors.IsNotFound(err) {
+			continue
+		}
+		if apierrors.IsTooManyRequests(err) {
+			// the eviction is refused by the PodDisruptionBudget,
+			// will retry later
+			klog.V(4).Infof("eviction of pod(%s/%s) is blocked, %v",
+				pod.GetNamespace(), pod.GetName(), err)
+			r.recordMigrationEvent(ctx, nodePool, "", corev1.EventTypeWarning,
+				eventTypeNodeMigrationBlocked,
+				"eviction of pod %s/%s on node %s is blocked: %v",
+				pod.GetNamespace(), pod.GetName(), node.GetName(), err)
+			continue
+		}
+		return false, err
+	}
+
+	pending := strconv.Itoa(len(pods))
+	if node.Annotations[appsv1alpha1.AnnotationMigrationPendingPods] != pending ||
+		node.Annotations[appsv1alpha1.AnnotationMigrationTo] != to {
+		node.Annotations[appsv1alpha1.AnnotationMigrationPendingPods] = pending
+		node.Annotations[appsv1alpha1.AnnotationMigrationTo] = to
+		if err := r.Update(ctx, node); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// startMigration cordons the node and records the migration on the node
+// annotations
+func startMigration(node *corev1.Node, from, to string) {
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	if !node.Spec.Unschedulable {
+		node.Spec.Unschedulable = true
+		node.Annotations[appsv1alpha1.AnnotationMigrationCordoned] = "true"
+	}
+	node.Annotations[appsv1alpha1.AnnotationMigrationPhase] = appsv1alpha1.MigrationPhaseDraining
+	node.Annotations[appsv1alpha1.AnnotationMigrationFrom] = from
+	node.Annotations[appsv1alpha1.AnnotationMigrationTo] = to
+}
+
+// completeMigration uncordons the node if it is cordoned by the migration
+// and removes the migration annotations
+func completeMigration(node *corev1.Node) {
+	if node.Annotations[appsv1alpha1.AnnotationMigrationCordoned] == "true" {
+		node.Spec.Unschedulable = false
+	}
+	delete(node.Annotations, appsv1alpha1.AnnotationMigrationCordoned)
+	delete(node.Annotations, appsv1alpha1.AnnotationMigrationPhase)
+	delete(node.Annotations, appsv1alpha1.AnnotationMigrationFrom)
+	delete(node.Annotations, appsv1alpha1.AnnotationMigrationTo)
+	delete(node.Annotations, appsv1alpha1.AnnotationMigrationPendingPods)
+	delete(node.Labels, appsv1alpha1.LabelCurrentNodePool)
+}
+
+// getPoolScopedPods returns the pods on the node that are created by the
+// pool-scoped workloads of the nodepool. The pods of DaemonSet are skipped
+// like `kubectl drain` does, as they are recreated on the node right after
+// being evicted, and are removed by the DaemonSet once the node leaves the pool.
+func (r *NodePoolReconciler) getPoolScopedPods(ctx context.Context,
+	nodeName, npName string) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	if err := r.List(ctx, &podList,
+		client.MatchingFields{fieldindex.IndexNameForPodNodeName: nodeName},
+		client.MatchingLabels{appsv1alpha1.PoolNameLabelKey: npName}); err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName != nodeName ||
+			pod.Status.Phase == corev1.PodSucceeded ||
+			pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// evictPod evicts the pod through the eviction api, so that the
+// PodDisruptionBudget will be respected
+func (r *NodePoolReconciler) evictPod(ctx context.Context, pod *corev1.Pod) error {
+	return r.kubeClient.CoreV1().Pods(pod.GetNamespace()).Evict(ctx, &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.GetName(),
+			Namespace: pod.GetNamespace(),
+		},
+	})
+}
+
+// isMigratingIn checks if the node is still migrating out of another
+// existing nodepool, the nodepool related attributes will not be added
+// until the migration is completed
+func (r *NodePoolReconciler) isMigratingIn(ctx context.Context, node *corev1.Node,
+	nodePool *appsv1alpha1.NodePool) (bool, error) {
+	from := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+	if from == "" || from == nodePool.GetName() {
+		return false, nil
+	}
+	var fromPool appsv1alpha1.NodePool
+	if err := r.Get(ctx, types.NamespacedName{Name: from}, &fromPool); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// recordMigrationEvent records the migration event on the nodepool the node
+// migrates out of and the nodepool the node migrates into
+func (r *NodePoolReconciler) recordMigrationEvent(ctx context.Context,
+	from *appsv1alpha1.NodePool, to, eventType, reason, messageFmt string, args ...interface{}) {
+	r.recorder.Eventf(from, eventType, reason, messageFmt, args...)
+	if to == "" {
+		return
+	}
+	var toPool appsv1alpha1.NodePool
+	if err := r.Get(ctx, types.NamespacedName{Name: to}, &toPool); err != nil {
+		klog.V(4).Infof("fail to get nodepool(%s) to record event, %v", to, err)
+		return
+	}
+	r.recorder.Eventf(&toPool, eventType, reason, messageFmt, args...)
+}
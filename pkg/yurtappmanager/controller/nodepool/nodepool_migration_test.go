@@ -0,0 +1,282 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newMigratingNode() *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Labels: map[string]string{
+				appsv1alpha1.LabelCurrentNodePool: "hangzhou",
+				appsv1alpha1.LabelDesiredNodePool: "beijing",
+			},
+		},
+	}
+}
+
+func newPoolScopedPod(name, nodeName, poolName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				appsv1alpha1.PoolNameLabelKey: poolName,
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func newDaemonSetPod(name, nodeName, poolName string) *corev1.Pod {
+	pod := newPoolScopedPod(name, nodeName, poolName)
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       "DaemonSet",
+		Name:       "ds-" + poolName,
+		UID:        types.UID("ds-" + poolName),
+		Controller: &controller,
+	}}
+	return pod
+}
+
+func TestMigrateNode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	appsv1alpha1.AddToScheme(scheme)
+
+	tests := []struct {
+		name              string
+		pods              []client.Object
+		evictErr          error
+		expectCompleted   bool
+		expectEvictions   int
+		expectPhase       string
+		expectCurrentPool string
+		expectCordoned    bool
+	}{
+		{
+			"no pool-scoped pods on the node",
+			[]client.Object{
+				newPoolScopedPod("pod1", "node2", "hangzhou"),
+				newPoolScopedPod("pod2", "node1", "beijing"),
+			},
+			nil,
+			true,
+			0,
+			"",
+			"",
+			false,
+		},
+		{
+			"evict pool-scoped pods",
+			[]client.Object{
+				newPoolScopedPod("pod1", "node1", "hangzhou"),
+			},
+			nil,
+			false,
+			1,
+			appsv1alpha1.MigrationPhaseDraining,
+			"hangzhou",
+			true,
+		},
+		{
+			"skip pods of DaemonSet",
+			[]client.Object{
+				newDaemonSetPod("pod1", "node1", "hangzhou"),
+			},
+			nil,
+			true,
+			0,
+			"",
+			"",
+			false,
+		},
+		{
+			"evict pool-scoped pods except pods of DaemonSet",
+			[]client.Object{
+				newPoolScopedPod("pod1", "node1", "hangzhou"),
+				newDaemonSetPod("pod2", "node1", "hangzhou"),
+			},
+			nil,
+			false,
+			1,
+			appsv1alpha1.MigrationPhaseDraining,
+			"hangzhou",
+			true,
+		},
+		{
+			"eviction is blocked by pdb",
+			[]client.Object{
+				newPoolScopedPod("pod1", "node1", "hangzhou"),
+			},
+			apierrors.NewTooManyRequests("disruption budget", 10),
+			false,
+			1,
+			appsv1alpha1.MigrationPhaseDraining,
+			"hangzhou",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				hangzhou := &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+				beijing := &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "beijing"}}
+				cl := fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(append(st.pods, hangzhou, beijing, newMigratingNode())...).
+					Build()
+				kubeClient := fakeclientset.NewSimpleClientset()
+				evictions := 0
+				kubeClient.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					if action.GetSubresource() != "eviction" {
+						return false, nil, nil
+					}
+					evictions++
+					return true, nil, st.evictErr
+				})
+				r := &NodePoolReconciler{
+					Client:     cl,
+					Scheme:     scheme,
+					recorder:   record.NewFakeRecorder(10),
+					kubeClient: kubeClient,
+				}
+
+				var node corev1.Node
+				if err := cl.Get(context.TODO(), types.NamespacedName{Name: "node1"}, &node); err != nil {
+					t.Fatalf("\t%s\tfail to get node, %v", failed, err)
+				}
+				completed, err := r.migrateNode(context.TODO(), &node, hangzhou)
+				if err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				if completed != st.expectCompleted {
+					t.Fatalf("\t%s\texpect completed %v, but get %v", failed, st.expectCompleted, completed)
+				}
+				if evictions != st.expectEvictions {
+					t.Fatalf("\t%s\texpect %d evictions, but get %d", failed, st.expectEvictions, evictions)
+				}
+
+				if err := cl.Get(context.TODO(), types.NamespacedName{Name: "node1"}, &node); err != nil {
+					t.Fatalf("\t%s\tfail to get node, %v", failed, err)
+				}
+				if phase := node.Annotations[appsv1alpha1.AnnotationMigrationPhase]; phase != st.expectPhase {
+					t.Fatalf("\t%s\texpect phase %q, but get %q", failed, st.expectPhase, phase)
+				}
+				if pool := node.Labels[appsv1alpha1.LabelCurrentNodePool]; pool != st.expectCurrentPool {
+					t.Fatalf("\t%s\texpect current pool %q, but get %q", failed, st.expectCurrentPool, pool)
+				}
+				if node.Spec.Unschedulable != st.expectCordoned {
+					t.Fatalf("\t%s\texpect unschedulable %v, but get %v", failed, st.expectCordoned, node.Spec.Unschedulable)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expectCompleted, completed)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestIsMigratingIn(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}},
+			&appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "beijing"}}).
+		Build()
+	r := &NodePoolReconciler{Client: cl, Scheme: scheme}
+
+	tests := []struct {
+		name        string
+		currentPool string
+		expect      bool
+	}{
+		{
+			"node is not in any pool",
+			"",
+			false,
+		},
+		{
+			"node is already in the pool",
+			"beijing",
+			false,
+		},
+		{
+			"node is still in another pool",
+			"hangzhou",
+			true,
+		},
+		{
+			"node is in a deleted pool",
+			"shanghai",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				node := &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node1",
+						Labels: map[string]string{
+							appsv1alpha1.LabelCurrentNodePool: st.currentPool,
+						},
+					},
+				}
+				get, err := r.isMigratingIn(context.TODO(), node,
+					&appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "beijing"}})
+				if err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon/workloadcontroller"
+)
+
+// deletionRequeueInterval is the interval to check whether the references
+// of the deleting nodepool have been removed
+var deletionRequeueInterval = 10 * time.Second
+
+const eventTypeDeletionBlocked = "DeletionBlocked"
+
+// handleNodePoolDeletion removes the finalizer of the deleting nodepool once
+// no workload references it, otherwise the blocking references will be
+// reported by the DeletionBlocked condition
+func (r *NodePoolReconciler) handleNodePoolDeletion(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(nodePool, appsv1alpha1.NodePoolFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	refs, err := r.getNodePoolReferences(ctx, nodePool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(refs) != 0 {
+		klog.V(4).Infof("nodepool(%s) is still referenced by %s",
+			nodePool.GetName(), strings.Join(refs, ", "))
+		cond := metav1.Condition{
+			Type:    appsv1alpha1.NodePoolDeletionBlocked,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ReferencedByWorkloads",
+			Message: fmt.Sprintf("the pool is still referenced by: %s", strings.Join(refs, ", ")),
+		}
+		if setNodePoolCondition(nodePool, cond) {
+			r.recorder.Event(nodePool, corev1.EventTypeWarning, eventTypeDeletionBlocked, cond.Message)
+			if err := r.Status().Update(ctx, nodePool); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: deletionRequeueInterval}, nil
+	}
+
+	controllerutil.RemoveFinalizer(nodePool, appsv1alpha1.NodePoolFinalizer)
+	if err := r.Update(ctx, nodePool); err != nil {
+		return ctrl.Result{}, err
+	}
+	klog.V(4).Infof("finalizer of nodepool(%s) has been removed", nodePool.GetName())
+	return ctrl.Result{}, nil
+}
+
+// getNodePoolReferences returns the YurtAppSets, YurtAppDaemons and
+// YurtIngresses that still reference the nodepool
+func (r *NodePoolReconciler) getNodePoolReferences(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool) ([]string, error) {
+	var refs []string
+
+	var yasList appsv1alpha1.YurtAppSetList
+	if err := r.List(ctx, &yasList); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, yas := range yasList.Items {
+		referenced := false
+		for _, pool := range yas.Spec.Topology.Pools {
+			if pool.Name == nodePool.GetName() {
+				referenced = true
+				break
+			}
+		}
+		if !referenced && yas.Spec.Topology.NodePoolSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(yas.Spec.Topology.NodePoolSelector)
+			if err != nil {
+				klog.Errorf("fail to convert the nodepool selector of YurtAppSet(%s/%s), %v",
+					yas.GetNamespace(), yas.GetName(), err)
+				continue
+			}
+			referenced = selector.Matches(labels.Set(nodePool.GetLabels()))
+		}
+		if referenced {
+			refs = append(refs, fmt.Sprintf("YurtAppSet %s/%s", yas.GetNamespace(), yas.GetName()))
+		}
+	}
+
+	var yadList appsv1alpha1.YurtAppDaemonList
+	if err := r.List(ctx, &yadList); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, yad := range yadList.Items {
+		selector, err := metav1.LabelSelectorAsSelector(yad.Spec.NodePoolSelector)
+		if err != nil {
+			klog.Errorf("fail to convert the nodepool selector of YurtAppDaemon(%s/%s), %v",
+				yad.GetNamespace(), yad.GetName(), err)
+			continue
+		}
+		if !selector.Matches(labels.Set(nodePool.GetLabels())) {
+			continue
+		}
+		// the nodepool dropped by the NodePoolPolicy has no workload of the YurtAppDaemon
+		nameToNodePools := map[string]appsv1alpha1.NodePool{nodePool.GetName(): *nodePool}
+		currentNodePoolToWorkload := make(map[string]*workloadcontroller.Workload)
+		for _, status := range yad.Status.WorkloadStatuses {
+			currentNodePoolToWorkload[status.NodePool] = &workloadcontroller.Workload{Name: status.WorkloadName}
+		}
+		filtered, _, _ := yurtappdaemon.FilterNodePools(&yad, nameToNodePools, currentNodePoolToWorkload, time.Now())
+		if _, ok := filtered[nodePool.GetName()]; ok {
+			refs = append(refs, fmt.Sprintf("YurtAppDaemon %s/%s", yad.GetNamespace(), yad.GetName()))
+		}
+	}
+
+	var yingList appsv1alpha1.YurtIngressList
+	if err := r.List(ctx, &yingList); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, ying := range yingList.Items {
+		for _, pool := range ying.Spec.Pools {
+			if pool.Name == nodePool.GetName() {
+				refs = append(refs, fmt.Sprintf("YurtIngress %s", ying.GetName()))
+				break
+			}
+		}
+	}
+
+	return refs, nil
+}
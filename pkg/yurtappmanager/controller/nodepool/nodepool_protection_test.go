@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newDeletingPool() *appsv1alpha1.NodePool {
+	now := metav1.Now()
+	return &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "hangzhou",
+			Labels:            map[string]string{"region": "hangzhou"},
+			DeletionTimestamp: &now,
+			Finalizers:        []string{appsv1alpha1.NodePoolFinalizer},
+		},
+		Spec: appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Edge},
+	}
+}
+
+func TestHandleNodePoolDeletion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+
+	tests := []struct {
+		name            string
+		objs            []client.Object
+		expectRefs      int
+		expectFinalizer bool
+	}{
+		{
+			"pool is not referenced",
+			[]client.Object{
+				&appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "yas", Namespace: "default"},
+					Spec: appsv1alpha1.YurtAppSetSpec{
+						Topology: appsv1alpha1.Topology{
+							Pools: []appsv1alpha1.Pool{{Name: "beijing"}},
+						},
+					},
+				},
+			},
+			0,
+			false,
+		},
+		{
+			"pool is selected by YurtAppSet",
+			[]client.Object{
+				&appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "yas", Namespace: "default"},
+					Spec: appsv1alpha1.YurtAppSetSpec{
+						Topology: appsv1alpha1.Topology{
+							NodePoolSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"region": "hangzhou"},
+							},
+						},
+					},
+				},
+			},
+			1,
+			true,
+		},
+		{
+			"pool is referenced by workloads",
+			[]client.Object{
+				&appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "yas", Namespace: "default"},
+					Spec: appsv1alpha1.YurtAppSetSpec{
+						Topology: appsv1alpha1.Topology{
+							Pools: []appsv1alpha1.Pool{{Name: "hangzhou"}},
+						},
+					},
+				},
+				&appsv1alpha1.YurtAppDaemon{
+					ObjectMeta: metav1.ObjectMeta{Name: "yad", Namespace: "default"},
+					Spec: appsv1alpha1.YurtAppDaemonSpec{
+						NodePoolSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"region": "hangzhou"},
+						},
+					},
+				},
+				&appsv1alpha1.YurtIngress{
+					ObjectMeta: metav1.ObjectMeta{Name: "ying"},
+					Spec: appsv1alpha1.YurtIngressSpec{
+						Pools: []appsv1alpha1.IngressPool{{Name: "hangzhou"}},
+					},
+				},
+			},
+			3,
+			true,
+		},
+		{
+			"pool is excluded by YurtAppDaemon",
+			[]client.Object{
+				&appsv1alpha1.YurtAppDaemon{
+					ObjectMeta: metav1.ObjectMeta{Name: "yad", Namespace: "default"},
+					Spec: appsv1alpha1.YurtAppDaemonSpec{
+						NodePoolSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"region": "hangzhou"},
+						},
+						NodePoolPolicy: &appsv1alpha1.NodePoolPolicy{
+							ExcludedNodePools: []string{"hangzhou"},
+						},
+					},
+				},
+			},
+			0,
+			false,
+		},
+		{
+			"pool type is not selected by YurtAppDaemon",
+			[]client.Object{
+				&appsv1alpha1.YurtAppDaemon{
+					ObjectMeta: metav1.ObjectMeta{Name: "yad", Namespace: "default"},
+					Spec: appsv1alpha1.YurtAppDaemonSpec{
+						NodePoolSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"region": "hangzhou"},
+						},
+						NodePoolPolicy: &appsv1alpha1.NodePoolPolicy{
+							Types: []appsv1alpha1.NodePoolType{appsv1alpha1.Cloud},
+						},
+					},
+				},
+			},
+			0,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				np := newDeletingPool()
+				cl := fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(append(st.objs, np)...).
+					Build()
+				r := &NodePoolReconciler{
+					Client:   cl,
+					Scheme:   scheme,
+					recorder: record.NewFakeRecorder(10),
+				}
+
+				refs, err := r.getNodePoolReferences(context.TODO(), np)
+				if err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				if len(refs) != st.expectRefs {
+					t.Fatalf("\t%s\texpect %d references, but get %v", failed, st.expectRefs, refs)
+				}
+
+				if _, err := r.handleNodePoolDeletion(context.TODO(), np); err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				// the nodepool will be removed once the finalizer is removed
+				var get appsv1alpha1.NodePool
+				if err := cl.Get(context.TODO(), types.NamespacedName{Name: np.Name}, &get); err != nil &&
+					!apierrors.IsNotFound(err) {
+					t.Fatalf("\t%s\tfail to get nodepool, %v", failed, err)
+				}
+				hasFinalizer := controllerutil.ContainsFinalizer(&get, appsv1alpha1.NodePoolFinalizer)
+				if hasFinalizer != st.expectFinalizer {
+					t.Fatalf("\t%s\texpect finalizer %v, but get %v", failed, st.expectFinalizer, hasFinalizer)
+				}
+				blocked := meta.IsStatusConditionTrue(get.Status.Conditions, appsv1alpha1.NodePoolDeletionBlocked)
+				if blocked != st.expectFinalizer {
+					t.Fatalf("\t%s\texpect deletion blocked %v, but get %v", failed, st.expectFinalizer, blocked)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expectRefs, refs)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
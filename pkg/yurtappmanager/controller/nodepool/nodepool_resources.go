@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+)
+
+// poolResources is the resource summary of the nodes in the nodepool
+type poolResources struct {
+	capacity    corev1.ResourceList
+	allocatable corev1.ResourceList
+	requested   corev1.ResourceList
+}
+
+// addNode adds the capacity and allocatable of the node and the resources
+// requested by the pods on the node to the summary
+func (pr *poolResources) addNode(node *corev1.Node, pods []corev1.Pod) {
+	pr.capacity = addResourceList(pr.capacity, node.Status.Capacity)
+	pr.allocatable = addResourceList(pr.allocatable, node.Status.Allocatable)
+	if pr.requested == nil {
+		pr.requested = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("0"),
+			corev1.ResourceMemory: resource.MustParse("0"),
+			corev1.ResourcePods:   resource.MustParse("0"),
+		}
+	}
+	for i := range pods {
+		reqs, _ := resourcehelper.PodRequestsAndLimits(&pods[i])
+		for _, rn := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if q, exist := reqs[rn]; exist {
+				sum := pr.requested[rn]
+				sum.Add(q)
+				pr.requested[rn] = sum
+			}
+		}
+		podNum := pr.requested[corev1.ResourcePods]
+		podNum.Add(resource.MustParse("1"))
+		pr.requested[corev1.ResourcePods] = podNum
+	}
+}
+
+// getNodePods returns the pods on the node that still occupy the resources
+// of the node, i.e. the pods that have not terminated
+func (r *NodePoolReconciler) getNodePods(ctx context.Context, nodeName string) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	if err := r.List(ctx, &podList,
+		client.MatchingFields{fieldindex.IndexNameForPodNodeName: nodeName}); err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		// double check the node name in case that the field index is not
+		// supported by the client
+		if pod.Spec.NodeName != nodeName || isPodTerminated(&pod) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// conciliateNodePoolResources will update the resource summary in the
+// nodepool status if necessary
+func conciliateNodePoolResources(pr poolResources, nodePool *appsv1alpha1.NodePool) (needUpdate bool) {
+	if !apiequality.Semantic.DeepEqual(pr.capacity, nodePool.Status.Capacity) {
+		nodePool.Status.Capacity = pr.capacity
+		needUpdate = true
+	}
+	if !apiequality.Semantic.DeepEqual(pr.allocatable, nodePool.Status.Allocatable) {
+		nodePool.Status.Allocatable = pr.allocatable
+		needUpdate = true
+	}
+	if !apiequality.Semantic.DeepEqual(pr.requested, nodePool.Status.Requested) {
+		nodePool.Status.Requested = pr.requested
+		needUpdate = true
+	}
+	return needUpdate
+}
+
+// addResourceList adds the resources in `newList` to `list`
+func addResourceList(list, newList corev1.ResourceList) corev1.ResourceList {
+	if len(newList) == 0 {
+		return list
+	}
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	for name, quantity := range newList {
+		if value, exist := list[name]; exist {
+			value.Add(quantity)
+			list[name] = value
+		} else {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+	return list
+}
+
+// isPodTerminated checks if the pod has terminated and released the
+// resources of the node
+func isPodTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
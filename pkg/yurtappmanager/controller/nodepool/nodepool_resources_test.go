@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newResourceNode(name, cpu, memory string) *corev1.Node {
+	list := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Capacity:    list,
+			Allocatable: list,
+		},
+	}
+}
+
+func newResourcePod(name, nodeName, cpu, memory string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{
+				{
+					Name: "main",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestConciliateNodePoolResources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			newResourcePod("pod1", "node1", "500m", "1Gi", corev1.PodRunning),
+			newResourcePod("pod2", "node2", "1", "512Mi", corev1.PodRunning),
+			newResourcePod("pod3", "node2", "1", "512Mi", corev1.PodSucceeded),
+			newResourcePod("pod4", "node3", "1", "512Mi", corev1.PodRunning)).
+		Build()
+	r := &NodePoolReconciler{Client: cl, Scheme: scheme}
+
+	tests := []struct {
+		name          string
+		nodes         []*corev1.Node
+		expectAlloc   corev1.ResourceList
+		expectRequest corev1.ResourceList
+		expectUpdate  bool
+	}{
+		{
+			"empty pool",
+			nil,
+			nil,
+			nil,
+			false,
+		},
+		{
+			"sum the resources of nodes",
+			[]*corev1.Node{
+				newResourceNode("node1", "2", "4Gi"),
+				newResourceNode("node2", "4", "8Gi"),
+			},
+			corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("6"),
+				corev1.ResourceMemory: resource.MustParse("12Gi"),
+			},
+			corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1500m"),
+				corev1.ResourceMemory: resource.MustParse("1536Mi"),
+				corev1.ResourcePods:   resource.MustParse("2"),
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				var pr poolResources
+				for _, node := range st.nodes {
+					pods, err := r.getNodePods(context.TODO(), node.GetName())
+					if err != nil {
+						t.Fatalf("\t%s\tunexpected error %v", failed, err)
+					}
+					pr.addNode(node, pods)
+				}
+				np := &appsv1alpha1.NodePool{}
+				if get := conciliateNodePoolResources(pr, np); get != st.expectUpdate {
+					t.Fatalf("\t%s\texpect update %v, but get %v", failed, st.expectUpdate, get)
+				}
+				if !apiequality.Semantic.DeepEqual(np.Status.Allocatable, st.expectAlloc) {
+					t.Fatalf("\t%s\texpect allocatable %v, but get %v", failed, st.expectAlloc, np.Status.Allocatable)
+				}
+				if !apiequality.Semantic.DeepEqual(np.Status.Requested, st.expectRequest) {
+					t.Fatalf("\t%s\texpect requested %v, but get %v", failed, st.expectRequest, np.Status.Requested)
+				}
+				if conciliateNodePoolResources(pr, np) {
+					t.Fatalf("\t%s\texpect no update for the same resources", failed)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expectRequest, np.Status.Requested)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
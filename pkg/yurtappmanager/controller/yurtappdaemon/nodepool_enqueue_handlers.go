@@ -18,9 +18,11 @@ package yurtappdaemon
 
 import (
 	"context"
+	"reflect"
 
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -38,6 +40,27 @@ func (e *EnqueueYurtAppDaemonForNodePool) Create(event event.CreateEvent, limiti
 }
 
 func (e *EnqueueYurtAppDaemonForNodePool) Update(event event.UpdateEvent, limitingInterface workqueue.RateLimitingInterface) {
+	oldNp, ok := event.ObjectOld.(*v1alpha1.NodePool)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to NodePool", event.ObjectOld.GetName())
+		return
+	}
+	newNp, ok := event.ObjectNew.(*v1alpha1.NodePool)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to NodePool", event.ObjectNew.GetName())
+		return
+	}
+	// the node counts are used by the replica policy and the nodepool policy, while the other
+	// changes of the nodepool status can be ignored
+	if reflect.DeepEqual(oldNp.GetLabels(), newNp.GetLabels()) &&
+		reflect.DeepEqual(oldNp.Spec.Taints, newNp.Spec.Taints) &&
+		oldNp.Spec.Type == newNp.Spec.Type &&
+		(oldNp.DeletionTimestamp == nil) == (newNp.DeletionTimestamp == nil) &&
+		oldNp.Status.ReadyNodeNum == newNp.Status.ReadyNodeNum &&
+		oldNp.Status.UnreadyNodeNum == newNp.Status.UnreadyNodeNum &&
+		oldNp.Status.AutonomousNodeNum == newNp.Status.AutonomousNodeNum {
+		return
+	}
 	e.addAllYurtAppDaemonToWorkQueue(limitingInterface)
 }
 
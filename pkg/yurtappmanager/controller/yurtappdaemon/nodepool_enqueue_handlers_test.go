@@ -21,6 +21,7 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/util/workqueue"
@@ -289,3 +290,84 @@ func TestGeneric(t *testing.T) {
 		t.Run(st.name, tf)
 	}
 }
+
+func TestUpdateNodePool(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+
+	ep := EnqueueYurtAppDaemonForNodePool{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(&appsv1alpha1.YurtAppDaemon{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "yad"},
+			}).
+			Build(),
+	}
+	newPool := func(ready, unready int32, cpu string) *appsv1alpha1.NodePool {
+		return &appsv1alpha1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+			Status: appsv1alpha1.NodePoolStatus{
+				ReadyNodeNum:   ready,
+				UnreadyNodeNum: unready,
+				Requested:      v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		event  event.UpdateEvent
+		expect int
+	}{
+		{
+			"ready node count changes",
+			event.UpdateEvent{ObjectOld: newPool(2, 0, "1"), ObjectNew: newPool(3, 0, "1")},
+			1,
+		},
+		{
+			"unready node count changes",
+			event.UpdateEvent{ObjectOld: newPool(2, 0, "1"), ObjectNew: newPool(2, 1, "1")},
+			1,
+		},
+		{
+			"autonomous node count changes",
+			event.UpdateEvent{ObjectOld: newPool(2, 1, "1"), ObjectNew: func() *appsv1alpha1.NodePool {
+				np := newPool(2, 1, "1")
+				np.Status.AutonomousNodeNum = 1
+				return np
+			}()},
+			1,
+		},
+		{
+			"nodepool type changes",
+			event.UpdateEvent{ObjectOld: newPool(2, 0, "1"), ObjectNew: func() *appsv1alpha1.NodePool {
+				np := newPool(2, 0, "1")
+				np.Spec.Type = appsv1alpha1.Cloud
+				return np
+			}()},
+			1,
+		},
+		{
+			"only requested resources change",
+			event.UpdateEvent{ObjectOld: newPool(2, 0, "1"), ObjectNew: newPool(2, 0, "2")},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				q := createQueue()
+				ep.Update(st.event, q)
+				if get := q.Len(); get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, q.Len())
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappdaemon
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon/workloadcontroller"
+)
+
+// FilterNodePools applies the NodePoolPolicy of YurtAppDaemon to the nodepools selected by NodePoolSelector.
+// Excluded nodepools and nodepools of other types are dropped. A nodepool with less ready nodes than
+// MinReadyNodes is dropped too, unless it already has a workload, which is kept until the teardown grace
+// period has passed since the nodepool was found not ready. The autonomous nodes of a nodepool partitioned
+// from the cloud keep running their pods, so they are counted as ready.
+// It returns the nodepools to run the workload, the time since when each kept nodepool has been not ready,
+// and the duration after which the next teardown is due.
+func FilterNodePools(instance *unitv1alpha1.YurtAppDaemon, nameToNodePools map[string]unitv1alpha1.NodePool,
+	currentNodepoolToWorkload map[string]*workloadcontroller.Workload, now time.Time) (map[string]unitv1alpha1.NodePool,
+	map[string]metav1.Time, time.Duration) {
+
+	policy := instance.Spec.NodePoolPolicy
+	if policy == nil {
+		return nameToNodePools, nil, 0
+	}
+
+	excluded := make(map[string]bool, len(policy.ExcludedNodePools))
+	for _, np := range policy.ExcludedNodePools {
+		excluded[np] = true
+	}
+	types := make(map[unitv1alpha1.NodePoolType]bool, len(policy.Types))
+	for _, t := range policy.Types {
+		types[t] = true
+	}
+	lastNotReadySince := make(map[string]metav1.Time)
+	for _, status := range instance.Status.WorkloadStatuses {
+		if status.NotReadySince != nil {
+			lastNotReadySince[status.NodePool] = *status.NotReadySince
+		}
+	}
+
+	filtered := make(map[string]unitv1alpha1.NodePool, len(nameToNodePools))
+	notReadySince := make(map[string]metav1.Time)
+	var requeueAfter time.Duration
+	for name, np := range nameToNodePools {
+		if excluded[name] || (len(types) != 0 && !types[np.Spec.Type]) {
+			continue
+		}
+
+		readyNodes := np.Status.ReadyNodeNum + np.Status.AutonomousNodeNum
+		if readyNodes >= policy.MinReadyNodes {
+			filtered[name] = np
+			continue
+		}
+
+		if _, ok := currentNodepoolToWorkload[name]; !ok {
+			klog.V(4).Infof("YurtAppDaemon[%s/%s] skip nodepool %s with %d ready nodes", instance.GetNamespace(),
+				instance.GetName(), name, readyNodes)
+			continue
+		}
+
+		since, ok := lastNotReadySince[name]
+		if !ok {
+			since = metav1.NewTime(now)
+		}
+		if policy.TeardownGracePeriodSeconds != nil {
+			remaining := since.Add(time.Duration(*policy.TeardownGracePeriodSeconds) * time.Second).Sub(now)
+			if remaining <= 0 {
+				klog.Infof("YurtAppDaemon[%s/%s] tear down the workload of nodepool %s with %d ready nodes",
+					instance.GetNamespace(), instance.GetName(), name, readyNodes)
+				continue
+			}
+			if requeueAfter == 0 || remaining < requeueAfter {
+				requeueAfter = remaining
+			}
+		}
+		filtered[name] = np
+		notReadySince[name] = since
+	}
+
+	return filtered, notReadySince, requeueAfter
+}
+
+// setNotReadySince records the time since when the nodepools have been not ready in the workload statuses.
+func setNotReadySince(newStatus *unitv1alpha1.YurtAppDaemonStatus, notReadySince map[string]metav1.Time) {
+	for i := range newStatus.WorkloadStatuses {
+		status := &newStatus.WorkloadStatuses[i]
+		status.NotReadySince = nil
+		if since, ok := notReadySince[status.NodePool]; ok {
+			status.NotReadySince = since.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappdaemon
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilpointer "k8s.io/utils/pointer"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon/workloadcontroller"
+)
+
+func newPolicyNodePool(name string, poolType unitv1alpha1.NodePoolType, ready int32) unitv1alpha1.NodePool {
+	return unitv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       unitv1alpha1.NodePoolSpec{Type: poolType},
+		Status:     unitv1alpha1.NodePoolStatus{ReadyNodeNum: ready},
+	}
+}
+
+func TestFilterNodePools(t *testing.T) {
+	now := time.Now()
+	nodepools := map[string]unitv1alpha1.NodePool{
+		"hangzhou": newPolicyNodePool("hangzhou", unitv1alpha1.Edge, 3),
+		"beijing":  newPolicyNodePool("beijing", unitv1alpha1.Edge, 0),
+		"shanghai": newPolicyNodePool("shanghai", unitv1alpha1.Edge, 1),
+		"cloud":    newPolicyNodePool("cloud", unitv1alpha1.Cloud, 5),
+	}
+	// the nodepool is partitioned from the cloud, its nodes run autonomously
+	partitioned := newPolicyNodePool("shenzhen", unitv1alpha1.Edge, 0)
+	partitioned.Status.AutonomousNodeNum = 2
+	nodepools["shenzhen"] = partitioned
+	workloads := map[string]*workloadcontroller.Workload{
+		"beijing":  {Name: "yad-beijing"},
+		"shenzhen": {Name: "yad-shenzhen"},
+	}
+	notReadyStatus := func(since time.Time) unitv1alpha1.YurtAppDaemonStatus {
+		return unitv1alpha1.YurtAppDaemonStatus{
+			WorkloadStatuses: []unitv1alpha1.NodePoolWorkloadStatus{
+				{NodePool: "beijing", NotReadySince: &metav1.Time{Time: since}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		policy       *unitv1alpha1.NodePoolPolicy
+		status       unitv1alpha1.YurtAppDaemonStatus
+		expect       []string
+		notReady     []string
+		requeueAfter time.Duration
+	}{
+		{
+			"no policy",
+			nil,
+			unitv1alpha1.YurtAppDaemonStatus{},
+			[]string{"beijing", "cloud", "hangzhou", "shanghai", "shenzhen"},
+			nil,
+			0,
+		},
+		{
+			"exclude nodepools and filter types",
+			&unitv1alpha1.NodePoolPolicy{
+				ExcludedNodePools: []string{"shanghai"},
+				Types:             []unitv1alpha1.NodePoolType{unitv1alpha1.Edge},
+			},
+			unitv1alpha1.YurtAppDaemonStatus{},
+			[]string{"beijing", "hangzhou", "shenzhen"},
+			nil,
+			0,
+		},
+		{
+			"keep existing workload of not ready nodepool",
+			&unitv1alpha1.NodePoolPolicy{MinReadyNodes: 2},
+			unitv1alpha1.YurtAppDaemonStatus{},
+			[]string{"beijing", "cloud", "hangzhou", "shenzhen"},
+			[]string{"beijing"},
+			0,
+		},
+		{
+			"teardown grace period not passed",
+			&unitv1alpha1.NodePoolPolicy{MinReadyNodes: 2, TeardownGracePeriodSeconds: utilpointer.Int32Ptr(60)},
+			notReadyStatus(now.Add(-20 * time.Second)),
+			[]string{"beijing", "cloud", "hangzhou", "shenzhen"},
+			[]string{"beijing"},
+			40 * time.Second,
+		},
+		{
+			"teardown grace period passed",
+			&unitv1alpha1.NodePoolPolicy{MinReadyNodes: 2, TeardownGracePeriodSeconds: utilpointer.Int32Ptr(60)},
+			notReadyStatus(now.Add(-time.Minute)),
+			[]string{"cloud", "hangzhou", "shenzhen"},
+			nil,
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yad := &unitv1alpha1.YurtAppDaemon{
+					Spec:   unitv1alpha1.YurtAppDaemonSpec{NodePoolPolicy: st.policy},
+					Status: st.status,
+				}
+				filtered, notReadySince, requeueAfter := FilterNodePools(yad, nodepools, workloads, now)
+				var get, getNotReady []string
+				for np := range filtered {
+					get = append(get, np)
+				}
+				for np := range notReadySince {
+					getNotReady = append(getNotReady, np)
+				}
+				sort.Strings(get)
+				sort.Strings(getNotReady)
+				if !reflect.DeepEqual(get, st.expect) || !reflect.DeepEqual(getNotReady, st.notReady) {
+					t.Fatalf("\t%s\texpect %v %v, but get %v %v", failed, st.expect, st.notReady, get, getNotReady)
+				}
+				if requeueAfter != st.requeueAfter {
+					t.Fatalf("\t%s\texpect requeue after %v, but get %v", failed, st.requeueAfter, requeueAfter)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
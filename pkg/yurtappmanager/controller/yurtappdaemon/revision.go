@@ -228,6 +228,8 @@ func (r *ReconcileYurtAppDaemon) newRevision(ud *appsalphav1.YurtAppDaemon, revi
 		selectedLabels = ud.Spec.WorkloadTemplate.StatefulSetTemplate.Labels
 	case ud.Spec.WorkloadTemplate.DeploymentTemplate != nil:
 		selectedLabels = ud.Spec.WorkloadTemplate.DeploymentTemplate.Labels
+	case ud.Spec.WorkloadTemplate.DaemonSetTemplate != nil:
+		selectedLabels = ud.Spec.WorkloadTemplate.DaemonSetTemplate.Labels
 	default:
 		klog.Errorf("YurtAppDaemon(%s/%s) need specific WorkloadTemplate", ud.GetNamespace(), ud.GetName())
 		return nil, fmt.Errorf("YurtAppDaemon(%s/%s) need specific WorkloadTemplate", ud.GetNamespace(), ud.GetName())
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappdaemon
+
+import (
+	"sort"
+	"sync"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon/workloadcontroller"
+)
+
+// workloadErrors records the last error met when managing the workload of each nodepool.
+// It is safe to be used by the concurrent batches of workload operations.
+type workloadErrors struct {
+	sync.Mutex
+	errs map[string]string
+}
+
+func newWorkloadErrors() *workloadErrors {
+	return &workloadErrors{errs: map[string]string{}}
+}
+
+func (e *workloadErrors) record(nodepool string, err error) {
+	e.Lock()
+	defer e.Unlock()
+	e.errs[nodepool] = err.Error()
+}
+
+func (e *workloadErrors) get(nodepool string) string {
+	e.Lock()
+	defer e.Unlock()
+	return e.errs[nodepool]
+}
+
+// calculateWorkloadStatuses fills the per nodepool workload status and the aggregated replicas of YurtAppDaemon.
+// Replicas of a workload which is not running the expected revision are not counted as updated.
+func calculateWorkloadStatuses(instance *unitv1alpha1.YurtAppDaemon, newStatus *unitv1alpha1.YurtAppDaemonStatus,
+	currentNodepoolToWorkload map[string]*workloadcontroller.Workload, allNameToNodePools map[string]unitv1alpha1.NodePool,
+	expectedRevision string, poolErrors *workloadErrors) {
+
+	statuses := make([]unitv1alpha1.NodePoolWorkloadStatus, 0, len(allNameToNodePools))
+	var replicas, readyReplicas, updatedReplicas int32
+	for npName, np := range allNameToNodePools {
+		status := unitv1alpha1.NodePoolWorkloadStatus{
+			NodePool:  npName,
+			LastError: poolErrors.get(npName),
+		}
+		if load, ok := currentNodepoolToWorkload[npName]; ok {
+			status.WorkloadName = load.Name
+			status.Revision = load.GetRevision()
+			status.Replicas = load.GetDesiredReplicas()
+			status.ReadyReplicas = load.Status.ReadyReplicas
+			if status.Revision == workloadcontroller.GetWorkloadRevision(instance, np, expectedRevision) {
+				status.UpdatedReplicas = load.Status.UpdatedReplicas
+			}
+		}
+
+		replicas += status.Replicas
+		readyReplicas += status.ReadyReplicas
+		updatedReplicas += status.UpdatedReplicas
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].NodePool < statuses[j].NodePool
+	})
+
+	newStatus.Replicas = replicas
+	newStatus.ReadyReplicas = readyReplicas
+	newStatus.UpdatedReplicas = updatedReplicas
+	newStatus.WorkloadStatuses = statuses
+}
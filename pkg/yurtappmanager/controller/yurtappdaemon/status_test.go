@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappdaemon
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilpointer "k8s.io/utils/pointer"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon/workloadcontroller"
+)
+
+func newStatusWorkload(name, revision string, replicas *int32, status workloadcontroller.WorkloadStatus) *workloadcontroller.Workload {
+	return &workloadcontroller.Workload{
+		Name:      name,
+		Namespace: "default",
+		Spec: workloadcontroller.WorkloadSpec{
+			Ref: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels: map[string]string{
+						unitv1alpha1.ControllerRevisionHashLabelKey: revision,
+					},
+				},
+			},
+			Replicas: replicas,
+		},
+		Status: status,
+	}
+}
+
+func TestCalculateWorkloadStatuses(t *testing.T) {
+	yad := &unitv1alpha1.YurtAppDaemon{
+		ObjectMeta: metav1.ObjectMeta{Name: "yad", Namespace: "default"},
+	}
+	nodepools := map[string]unitv1alpha1.NodePool{
+		"hangzhou": {ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}},
+		"beijing":  {ObjectMeta: metav1.ObjectMeta{Name: "beijing"}},
+		"shanghai": {ObjectMeta: metav1.ObjectMeta{Name: "shanghai"}},
+	}
+	workloads := map[string]*workloadcontroller.Workload{
+		"hangzhou": newStatusWorkload("yad-hangzhou-a", "v2", utilpointer.Int32Ptr(3),
+			workloadcontroller.WorkloadStatus{Replicas: 3, ReadyReplicas: 2, UpdatedReplicas: 3}),
+		"beijing": newStatusWorkload("yad-beijing-b", "v1", nil,
+			workloadcontroller.WorkloadStatus{Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1}),
+	}
+	poolErrors := newWorkloadErrors()
+	poolErrors.record("shanghai", fmt.Errorf("quota exceeded"))
+
+	newStatus := &unitv1alpha1.YurtAppDaemonStatus{}
+	calculateWorkloadStatuses(yad, newStatus, workloads, nodepools, "v2", poolErrors)
+
+	expect := &unitv1alpha1.YurtAppDaemonStatus{
+		Replicas:        4,
+		ReadyReplicas:   3,
+		UpdatedReplicas: 3,
+		WorkloadStatuses: []unitv1alpha1.NodePoolWorkloadStatus{
+			{NodePool: "beijing", WorkloadName: "yad-beijing-b", Revision: "v1", Replicas: 1, ReadyReplicas: 1},
+			{NodePool: "hangzhou", WorkloadName: "yad-hangzhou-a", Revision: "v2", Replicas: 3, ReadyReplicas: 2, UpdatedReplicas: 3},
+			{NodePool: "shanghai", LastError: "quota exceeded"},
+		},
+	}
+	if !reflect.DeepEqual(newStatus, expect) {
+		t.Fatalf("\t%s\texpect %v, but get %v", failed, expect, newStatus)
+	}
+	t.Logf("\t%s\texpect %v, get %v", succeed, expect, newStatus)
+}
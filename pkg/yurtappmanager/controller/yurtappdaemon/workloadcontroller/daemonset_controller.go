@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/refmanager"
+)
+
+// DaemonSetControllor manages a DaemonSet for each nodepool, which runs one pod
+// on every node of the nodepool.
+type DaemonSetControllor struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (d *DaemonSetControllor) GetTemplateType() v1alpha1.TemplateType {
+	return v1alpha1.DaemonSetTemplateType
+}
+
+func (d *DaemonSetControllor) DeleteWorkload(yda *v1alpha1.YurtAppDaemon, load *Workload) error {
+	klog.Infof("YurtAppDaemon[%s/%s] prepare delete DaemonSet[%s/%s]", yda.GetNamespace(),
+		yda.GetName(), load.Namespace, load.Name)
+
+	set := load.Spec.Ref.(runtime.Object)
+	cliSet, ok := set.(client.Object)
+	if !ok {
+		return errors.New("fail to convert runtime.Object to client.Object")
+	}
+	return d.Delete(context.TODO(), cliSet, client.PropagationPolicy(metav1.DeletePropagationBackground))
+}
+
+// applyTemplate updates the object to the latest revision, depending on the YurtAppDaemon.
+func (d *DaemonSetControllor) applyTemplate(scheme *runtime.Scheme, yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string, set *appsv1.DaemonSet) error {
+	// the overrides of the nodepool are part of the workload revision
+	revision = GetWorkloadRevision(yad, nodepool, revision)
+
+	if set.Labels == nil {
+		set.Labels = map[string]string{}
+	}
+	for k, v := range yad.Spec.WorkloadTemplate.DaemonSetTemplate.Labels {
+		set.Labels[k] = v
+	}
+	for k, v := range yad.Spec.Selector.MatchLabels {
+		set.Labels[k] = v
+	}
+	set.Labels[v1alpha1.ControllerRevisionHashLabelKey] = revision
+	set.Labels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
+
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+	for k, v := range yad.Spec.WorkloadTemplate.DaemonSetTemplate.Annotations {
+		set.Annotations[k] = v
+	}
+	set.Annotations[v1alpha1.AnnotationRefNodePool] = nodepool.GetName()
+
+	set.Namespace = yad.GetNamespace()
+	set.GenerateName = getWorkloadPrefix(yad.GetName(), nodepool.GetName())
+
+	set.Spec = *yad.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.DeepCopy()
+	if set.Spec.Selector == nil {
+		set.Spec.Selector = yad.Spec.Selector.DeepCopy()
+	}
+	if set.Spec.Selector.MatchLabels == nil {
+		set.Spec.Selector.MatchLabels = map[string]string{}
+	}
+	set.Spec.Selector.MatchLabels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
+
+	// apply the overrides of the nodepool in order
+	for _, override := range GetNodePoolOverrides(yad, nodepool) {
+		patched := &appsv1.DaemonSet{}
+		if err := adapter.StrategicMergeByPatches(set, &override.Patch, patched); err != nil {
+			return fmt.Errorf("fail to apply override %s to nodepool %s: %v", string(override.Patch.Raw), nodepool.GetName(), err)
+		}
+		patched.DeepCopyInto(set)
+	}
+
+	// pin the required node affinity to the nodepool
+	AttachNodePoolAffinity(&set.Spec.Template.Spec, nodepool.GetName())
+
+	if set.Spec.Template.Labels == nil {
+		set.Spec.Template.Labels = map[string]string{}
+	}
+	set.Spec.Template.Labels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
+	set.Spec.Template.Labels[v1alpha1.ControllerRevisionHashLabelKey] = revision
+
+	// use nodeSelector
+	set.Spec.Template.Spec.NodeSelector = CreateNodeSelectorForNodePool(set.Spec.Template.Spec.NodeSelector, nodepool.GetName())
+
+	// toleration
+	nodePoolTaints := TaintsToTolerations(nodepool.Spec.Taints)
+	set.Spec.Template.Spec.Tolerations = append(set.Spec.Template.Spec.Tolerations, nodePoolTaints...)
+
+	if err := controllerutil.SetControllerReference(yad, set, scheme); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *DaemonSetControllor) ObjectKey(load *Workload) client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: load.Namespace,
+		Name:      load.Name,
+	}
+}
+
+func (d *DaemonSetControllor) UpdateWorkload(load *Workload, yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string) error {
+	klog.Infof("YurtAppDaemon[%s/%s] prepare update DaemonSet[%s/%s]", yad.GetNamespace(),
+		yad.GetName(), load.Namespace, load.Name)
+
+	set := &appsv1.DaemonSet{}
+	var updateError error
+	for i := 0; i < updateRetries; i++ {
+		getError := d.Client.Get(context.TODO(), d.ObjectKey(load), set)
+		if getError != nil {
+			return getError
+		}
+
+		// the selector of DaemonSet is immutable
+		selector := set.Spec.Selector
+		if err := d.applyTemplate(d.Scheme, yad, nodepool, revision, set); err != nil {
+			return err
+		}
+		set.Spec.Selector = selector
+		updateError = d.Client.Update(context.TODO(), set)
+		if updateError == nil {
+			break
+		}
+	}
+
+	return updateError
+}
+
+func (d *DaemonSetControllor) CreateWorkload(yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string) error {
+	klog.Infof("YurtAppDaemon[%s/%s] prepare create new daemonset by nodepool %s ", yad.GetNamespace(), yad.GetName(), nodepool.GetName())
+
+	set := appsv1.DaemonSet{}
+	if err := d.applyTemplate(d.Scheme, yad, nodepool, revision, &set); err != nil {
+		klog.Errorf("YurtAppDaemon[%s/%s] faild to apply template, when create daemonset: %v", yad.GetNamespace(),
+			yad.GetName(), err)
+		return err
+	}
+	return d.Client.Create(context.TODO(), &set)
+}
+
+func (d *DaemonSetControllor) GetAllWorkloads(yad *v1alpha1.YurtAppDaemon) ([]*Workload, error) {
+	allDaemonSets := appsv1.DaemonSetList{}
+	selector, err := metav1.LabelSelectorAsSelector(yad.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Client.List(context.TODO(), &allDaemonSets, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, err
+	}
+
+	manager, err := refmanager.New(d.Client, yad.Spec.Selector, yad, d.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]metav1.Object, 0, len(allDaemonSets.Items))
+	for i := 0; i < len(allDaemonSets.Items); i++ {
+		t := allDaemonSets.Items[i]
+		selected = append(selected, &t)
+	}
+
+	objs, err := manager.ClaimOwnedObjects(selected)
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]*Workload, 0, len(objs))
+	for i, o := range objs {
+		set := o.(*appsv1.DaemonSet)
+		spec := set.Spec
+		// the replicas of a DaemonSet is the number of nodes it should be scheduled to
+		desired := set.Status.DesiredNumberScheduled
+		w := &Workload{
+			Name:      o.GetName(),
+			Namespace: o.GetNamespace(),
+			Kind:      set.Kind,
+			Spec: WorkloadSpec{
+				Ref:          objs[i],
+				Replicas:     &desired,
+				NodeSelector: spec.Template.Spec.NodeSelector,
+				Toleration:   spec.Template.Spec.Tolerations,
+			},
+			Status: WorkloadStatus{
+				Replicas:        set.Status.DesiredNumberScheduled,
+				ReadyReplicas:   set.Status.NumberReady,
+				UpdatedReplicas: set.Status.UpdatedNumberScheduled,
+			},
+		}
+		workloads = append(workloads, w)
+	}
+	return workloads, nil
+}
+
+var _ WorkloadControllor = &DaemonSetControllor{}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadcontroller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakeclint "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newDaemonSetDaemon() *v1alpha1.YurtAppDaemon {
+	return &v1alpha1.YurtAppDaemon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "foo-ns",
+			UID:       "yad-uid",
+		},
+		Spec: v1alpha1.YurtAppDaemonSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "daemon-foo"}},
+			WorkloadTemplate: v1alpha1.WorkloadTemplate{
+				DaemonSetTemplate: &v1alpha1.DaemonSetTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "daemon-foo"}},
+					Spec: appsv1.DaemonSetSpec{
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "daemon-foo"}},
+						Template: v1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "daemon-foo"}},
+							Spec: v1.PodSpec{
+								NodeSelector: map[string]string{"kubernetes.io/arch": "arm64"},
+								Containers:   []v1.Container{{Name: "agent", Image: "agent:1.0"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDaemonSetControllor_CreateWorkload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+	fc := fakeclint.NewClientBuilder().WithScheme(scheme).Build()
+	dc := DaemonSetControllor{Client: fc, Scheme: scheme}
+
+	yad := newDaemonSetDaemon()
+	nodepool := v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "np"},
+		Spec: v1alpha1.NodePoolSpec{
+			Taints: []v1.Taint{{Key: "edge", Effect: v1.TaintEffectNoSchedule}},
+		},
+	}
+	if err := dc.CreateWorkload(yad, nodepool, "1"); err != nil {
+		t.Fatalf("\t%s\tfail to create workload, %v", failed, err)
+	}
+
+	ws, err := dc.GetAllWorkloads(yad)
+	if err != nil {
+		t.Fatalf("\t%s\tfail to get workloads, %v", failed, err)
+	}
+	if len(ws) != 1 {
+		t.Fatalf("\t%s\texpect 1 workload, but get %d", failed, len(ws))
+	}
+	if ws[0].GetNodePoolName() != "np" || ws[0].GetRevision() != "1" {
+		t.Fatalf("\t%s\texpect workload of nodepool np in revision 1, but get %s in %s",
+			failed, ws[0].GetNodePoolName(), ws[0].GetRevision())
+	}
+	expectNodeSelector := map[string]string{"kubernetes.io/arch": "arm64", v1alpha1.LabelCurrentNodePool: "np"}
+	if len(ws[0].GetToleration()) != 1 || !reflect.DeepEqual(ws[0].GetNodeSelector(), expectNodeSelector) {
+		t.Fatalf("\t%s\texpect workload to be scheduled to nodepool np, but get %v, %v",
+			failed, ws[0].GetNodeSelector(), ws[0].GetToleration())
+	}
+	set := ws[0].Spec.Ref.(*appsv1.DaemonSet)
+	if set.Spec.Selector.MatchLabels[v1alpha1.PoolNameLabelKey] != "np" {
+		t.Fatalf("\t%s\texpect selector of nodepool np, but get %v", failed, set.Spec.Selector)
+	}
+	t.Logf("\t%s\tcreate daemonset %s", succeed, set.Name)
+}
+
+func TestDaemonSetControllor_UpdateWorkload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+
+	yad := newDaemonSetDaemon()
+	yad.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.Template.Spec.Containers[0].Image = "agent:2.0"
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "daemon-foo", "legacy": "true"}}
+	set := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-np",
+			Namespace: "foo-ns",
+			Labels: map[string]string{
+				"app":                                   "daemon-foo",
+				v1alpha1.PoolNameLabelKey:               "np",
+				v1alpha1.ControllerRevisionHashLabelKey: "1",
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(yad,
+				v1alpha1.SchemeGroupVersion.WithKind("YurtAppDaemon"))},
+		},
+		Spec: appsv1.DaemonSetSpec{Selector: selector},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+			NumberReady:            2,
+			UpdatedNumberScheduled: 1,
+		},
+	}
+	fc := fakeclint.NewClientBuilder().WithScheme(scheme).WithObjects(set).Build()
+	dc := DaemonSetControllor{Client: fc, Scheme: scheme}
+
+	ws, err := dc.GetAllWorkloads(yad)
+	if err != nil || len(ws) != 1 {
+		t.Fatalf("\t%s\tfail to get workloads, %v, %v", failed, ws, err)
+	}
+	expectStatus := WorkloadStatus{Replicas: 3, ReadyReplicas: 2, UpdatedReplicas: 1}
+	if ws[0].Status != expectStatus || ws[0].GetDesiredReplicas() != 3 {
+		t.Fatalf("\t%s\texpect status %v, but get %v", failed, expectStatus, ws[0].Status)
+	}
+
+	nodepool := v1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "np"}}
+	if err := dc.UpdateWorkload(ws[0], yad, nodepool, "2"); err != nil {
+		t.Fatalf("\t%s\tfail to update workload, %v", failed, err)
+	}
+
+	updated := &appsv1.DaemonSet{}
+	if err := fc.Get(context.TODO(), dc.ObjectKey(ws[0]), updated); err != nil {
+		t.Fatalf("\t%s\tfail to get daemonset, %v", failed, err)
+	}
+	if updated.Labels[v1alpha1.ControllerRevisionHashLabelKey] != "2" ||
+		updated.Spec.Template.Spec.Containers[0].Image != "agent:2.0" {
+		t.Fatalf("\t%s\texpect daemonset updated to revision 2, but get %v", failed, updated.Labels)
+	}
+	if !reflect.DeepEqual(updated.Spec.Selector, selector) {
+		t.Fatalf("\t%s\texpect selector %v kept, but get %v", failed, selector, updated.Spec.Selector)
+	}
+	t.Logf("\t%s\tupdate daemonset %s", succeed, updated.Name)
+}
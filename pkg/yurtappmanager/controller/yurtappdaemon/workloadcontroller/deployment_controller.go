@@ -19,6 +19,7 @@ package workloadcontroller
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/refmanager"
 )
 
@@ -57,6 +59,8 @@ func (d *DeploymentControllor) DeleteWorkload(yda *v1alpha1.YurtAppDaemon, load
 
 // ApplyTemplate updates the object to the latest revision, depending on the YurtAppDaemon.
 func (a *DeploymentControllor) applyTemplate(scheme *runtime.Scheme, yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string, set *appsv1.Deployment) error {
+	// the overrides of the nodepool are part of the workload revision
+	revision = GetWorkloadRevision(yad, nodepool, revision)
 
 	if set.Labels == nil {
 		set.Labels = map[string]string{}
@@ -84,11 +88,23 @@ func (a *DeploymentControllor) applyTemplate(scheme *runtime.Scheme, yad *v1alph
 	set.Spec = *yad.Spec.WorkloadTemplate.DeploymentTemplate.Spec.DeepCopy()
 	set.Spec.Selector.MatchLabels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
 
-	// set RequiredDuringSchedulingIgnoredDuringExecution nil
-	if set.Spec.Template.Spec.Affinity != nil && set.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
-		set.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+	// apply the overrides of the nodepool in order
+	for _, override := range GetNodePoolOverrides(yad, nodepool) {
+		patched := &appsv1.Deployment{}
+		if err := adapter.StrategicMergeByPatches(set, &override.Patch, patched); err != nil {
+			return fmt.Errorf("fail to apply override %s to nodepool %s: %v", string(override.Patch.Raw), nodepool.GetName(), err)
+		}
+		patched.DeepCopyInto(set)
+	}
+
+	// the replica policy takes precedence over the overrides
+	if replicas := GetWorkloadReplicas(yad, nodepool); replicas != nil {
+		set.Spec.Replicas = replicas
 	}
 
+	// pin the required node affinity to the nodepool
+	AttachNodePoolAffinity(&set.Spec.Template.Spec, nodepool.GetName())
+
 	if set.Spec.Template.Labels == nil {
 		set.Spec.Template.Labels = map[string]string{}
 	}
@@ -96,7 +112,7 @@ func (a *DeploymentControllor) applyTemplate(scheme *runtime.Scheme, yad *v1alph
 	set.Spec.Template.Labels[v1alpha1.ControllerRevisionHashLabelKey] = revision
 
 	// use nodeSelector
-	set.Spec.Template.Spec.NodeSelector = CreateNodeSelectorByNodepoolName(nodepool.GetName())
+	set.Spec.Template.Spec.NodeSelector = CreateNodeSelectorForNodePool(set.Spec.Template.Spec.NodeSelector, nodepool.GetName())
 
 	// toleration
 	nodePoolTaints := TaintsToTolerations(nodepool.Spec.Taints)
@@ -190,9 +206,15 @@ func (d *DeploymentControllor) GetAllWorkloads(set *v1alpha1.YurtAppDaemon) ([]*
 			Kind:      deploy.Kind,
 			Spec: WorkloadSpec{
 				Ref:          objs[i],
+				Replicas:     spec.Replicas,
 				NodeSelector: spec.Template.Spec.NodeSelector,
 				Toleration:   spec.Template.Spec.Tolerations,
 			},
+			Status: WorkloadStatus{
+				Replicas:        deploy.Status.Replicas,
+				ReadyReplicas:   deploy.Status.ReadyReplicas,
+				UpdatedReplicas: deploy.Status.UpdatedReplicas,
+			},
 		}
 		workloads = append(workloads, w)
 	}
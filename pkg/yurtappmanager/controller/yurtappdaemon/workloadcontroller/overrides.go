@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadcontroller
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog"
+
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// GetNodePoolOverrides returns the overrides of the YurtAppDaemon that match
+// the nodepool by name or by labels, in the order they are declared.
+func GetNodePoolOverrides(yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool) []v1alpha1.WorkloadOverride {
+	var overrides []v1alpha1.WorkloadOverride
+	for _, override := range yad.Spec.Overrides {
+		if overrideMatchNodePool(override, nodepool) {
+			overrides = append(overrides, override)
+		}
+	}
+	return overrides
+}
+
+func overrideMatchNodePool(override v1alpha1.WorkloadOverride, nodepool v1alpha1.NodePool) bool {
+	for _, name := range override.NodePools {
+		if name == nodepool.GetName() {
+			return true
+		}
+	}
+	if override.NodePoolSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(override.NodePoolSelector)
+	if err != nil {
+		klog.Errorf("fail to convert the nodepool selector of override, %v", err)
+		return false
+	}
+	return selector.Matches(labels.Set(nodepool.GetLabels()))
+}
+
+// GetWorkloadRevision returns the revision of the workload in the nodepool.
+// The patches of the overrides matching the nodepool are hashed into the
+// revision, so that the workload will be updated once its overrides change.
+// The revision is truncated if the result exceeds the max length of label value.
+func GetWorkloadRevision(yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string) string {
+	overrides := GetNodePoolOverrides(yad, nodepool)
+	if len(overrides) == 0 {
+		return revision
+	}
+
+	hasher := fnv.New32a()
+	for _, override := range overrides {
+		hasher.Write(override.Patch.Raw)
+	}
+	workloadRevision := fmt.Sprintf("%s-%s", revision, rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())))
+	if len(workloadRevision) <= validation.LabelValueMaxLength {
+		return workloadRevision
+	}
+
+	// hash the revision as well, so that the truncated revisions can still be told apart
+	hasher.Write([]byte(revision))
+	hash := rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+	return fmt.Sprintf("%s-%s", revision[:validation.LabelValueMaxLength-len(hash)-1], hash)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadcontroller
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	utilpointer "k8s.io/utils/pointer"
+	fakeclint "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newOverrideDaemon(overrides ...v1alpha1.WorkloadOverride) *v1alpha1.YurtAppDaemon {
+	return &v1alpha1.YurtAppDaemon{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "foo-ns"},
+		Spec: v1alpha1.YurtAppDaemonSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "daemon-foo"}},
+			WorkloadTemplate: v1alpha1.WorkloadTemplate{
+				DeploymentTemplate: &v1alpha1.DeploymentTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "daemon-foo"}},
+					Spec: appsv1.DeploymentSpec{
+						Replicas: utilpointer.Int32Ptr(1),
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "daemon-foo"}},
+						Template: v1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "daemon-foo"}},
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{{Name: "nginx", Image: "nginx:1.19"}},
+							},
+						},
+					},
+				},
+			},
+			Overrides: overrides,
+		},
+	}
+}
+
+func newOverride(patch string, nodepools ...string) v1alpha1.WorkloadOverride {
+	return v1alpha1.WorkloadOverride{
+		NodePools: nodepools,
+		Patch:     runtime.RawExtension{Raw: []byte(patch)},
+	}
+}
+
+func TestGetWorkloadRevision(t *testing.T) {
+	hangzhou := v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou", Labels: map[string]string{"region": "east"}},
+	}
+	base := GetWorkloadRevision(newOverrideDaemon(), hangzhou, "rev")
+	replicas := GetWorkloadRevision(newOverrideDaemon(newOverride(`{"spec":{"replicas":3}}`, "hangzhou")), hangzhou, "rev")
+
+	tests := []struct {
+		name      string
+		overrides []v1alpha1.WorkloadOverride
+		expect    func(revision string) bool
+	}{
+		{
+			"no overrides",
+			nil,
+			func(revision string) bool { return revision == "rev" },
+		},
+		{
+			"overrides of other nodepools",
+			[]v1alpha1.WorkloadOverride{newOverride(`{"spec":{"replicas":3}}`, "beijing")},
+			func(revision string) bool { return revision == base },
+		},
+		{
+			"override by nodepool name",
+			[]v1alpha1.WorkloadOverride{newOverride(`{"spec":{"replicas":3}}`, "hangzhou")},
+			func(revision string) bool { return revision != base && revision == replicas },
+		},
+		{
+			"override by nodepool selector",
+			[]v1alpha1.WorkloadOverride{{
+				NodePoolSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}},
+				Patch:            runtime.RawExtension{Raw: []byte(`{"spec":{"replicas":3}}`)},
+			}},
+			func(revision string) bool { return revision == replicas },
+		},
+		{
+			"patch changes",
+			[]v1alpha1.WorkloadOverride{newOverride(`{"spec":{"replicas":4}}`, "hangzhou")},
+			func(revision string) bool { return revision != base && revision != replicas },
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := GetWorkloadRevision(newOverrideDaemon(st.overrides...), hangzhou, "rev")
+				if !st.expect(get) {
+					t.Fatalf("\t%s\tunexpected revision %s", failed, get)
+				}
+				t.Logf("\t%s\tget revision %v", succeed, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestGetWorkloadRevisionOfLongRevision(t *testing.T) {
+	hangzhou := v1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+	yad := newOverrideDaemon(newOverride(`{"spec":{"replicas":3}}`, "hangzhou"))
+	prefix := strings.Repeat("a", 50)
+
+	first := GetWorkloadRevision(yad, hangzhou, prefix+"-5d8f9c7b6a")
+	second := GetWorkloadRevision(yad, hangzhou, prefix+"-6b7c8d9f5a")
+	for _, revision := range []string{first, second} {
+		if errs := validation.IsValidLabelValue(revision); len(errs) > 0 {
+			t.Fatalf("\t%s\tinvalid revision %s: %v", failed, revision, errs)
+		}
+	}
+	if first == second {
+		t.Fatalf("\t%s\texpect different revisions, but get %s", failed, first)
+	}
+	t.Logf("\t%s\tget revisions %s and %s", succeed, first, second)
+}
+
+func TestApplyTemplateWithOverrides(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+	dc := DeploymentControllor{Client: fakeclint.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme}
+
+	yad := newOverrideDaemon(
+		newOverride(`{"spec":{"replicas":3}}`, "hangzhou"),
+		newOverride(`{"spec":{"replicas":5,"template":{"spec":{"containers":[{"name":"nginx","image":"mirror/nginx:1.19"}]}}}}`, "hangzhou"),
+		newOverride(`{"spec":{"replicas":7}}`, "beijing"))
+	nodepool := v1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+
+	set := &appsv1.Deployment{}
+	if err := dc.applyTemplate(scheme, yad, nodepool, "rev", set); err != nil {
+		t.Fatalf("\t%s\tfail to apply template, %v", failed, err)
+	}
+	if *set.Spec.Replicas != 5 {
+		t.Fatalf("\t%s\texpect the overrides to be applied in order, but get %d replicas", failed, *set.Spec.Replicas)
+	}
+	if image := set.Spec.Template.Spec.Containers[0].Image; image != "mirror/nginx:1.19" {
+		t.Fatalf("\t%s\texpect image mirror/nginx:1.19, but get %s", failed, image)
+	}
+	if set.Spec.Template.Spec.NodeSelector[v1alpha1.LabelCurrentNodePool] != "hangzhou" {
+		t.Fatalf("\t%s\texpect workload to be scheduled to nodepool hangzhou, but get %v",
+			failed, set.Spec.Template.Spec.NodeSelector)
+	}
+	if revision := set.Labels[v1alpha1.ControllerRevisionHashLabelKey]; revision != GetWorkloadRevision(yad, nodepool, "rev") {
+		t.Fatalf("\t%s\texpect overrides hashed into revision, but get %s", failed, revision)
+	}
+	t.Logf("\t%s\tapply overrides to deployment", succeed)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadcontroller
+
+import (
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// GetWorkloadReplicas returns the replicas of the workload in the nodepool
+// according to the replica policy of the YurtAppDaemon, nil is returned if
+// no replica policy is set or the workload is a DaemonSet, which runs on
+// every node of the nodepool.
+func GetWorkloadReplicas(yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool) *int32 {
+	policy := yad.Spec.ReplicaPolicy
+	if policy == nil || yad.Spec.WorkloadTemplate.DaemonSetTemplate != nil {
+		return nil
+	}
+
+	var replicas int32
+	switch policy.Type {
+	case v1alpha1.ReplicaPolicyNodeRatio:
+		nodesPerReplica := int32(1)
+		if policy.NodesPerReplica != nil && *policy.NodesPerReplica > 0 {
+			nodesPerReplica = *policy.NodesPerReplica
+		}
+		nodes := nodepool.Status.ReadyNodeNum + nodepool.Status.UnreadyNodeNum
+		replicas = (nodes + nodesPerReplica - 1) / nodesPerReplica
+	case v1alpha1.ReplicaPolicyReadyNodes:
+		// the autonomous nodes keep running their pods while partitioned
+		// from the cloud, their replicas should not be scaled in
+		replicas = nodepool.Status.ReadyNodeNum + nodepool.Status.AutonomousNodeNum
+	default:
+		replicas = getTemplateReplicas(yad)
+	}
+
+	if policy.MinReplicas != nil && replicas < *policy.MinReplicas {
+		replicas = *policy.MinReplicas
+	}
+	if policy.MaxReplicas != nil && replicas > *policy.MaxReplicas {
+		replicas = *policy.MaxReplicas
+	}
+	return &replicas
+}
+
+// getTemplateReplicas returns the replicas of the workload template, which
+// defaults to 1 just like Deployment and StatefulSet
+func getTemplateReplicas(yad *v1alpha1.YurtAppDaemon) int32 {
+	template := yad.Spec.WorkloadTemplate
+	switch {
+	case template.StatefulSetTemplate != nil && template.StatefulSetTemplate.Spec.Replicas != nil:
+		return *template.StatefulSetTemplate.Spec.Replicas
+	case template.DeploymentTemplate != nil && template.DeploymentTemplate.Spec.Replicas != nil:
+		return *template.DeploymentTemplate.Spec.Replicas
+	}
+	return 1
+}
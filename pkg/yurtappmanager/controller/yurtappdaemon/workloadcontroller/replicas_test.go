@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadcontroller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	utilpointer "k8s.io/utils/pointer"
+	fakeclint "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestGetWorkloadReplicas(t *testing.T) {
+	nodepool := v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+		Status:     v1alpha1.NodePoolStatus{ReadyNodeNum: 7, UnreadyNodeNum: 3, AutonomousNodeNum: 1},
+	}
+
+	tests := []struct {
+		name   string
+		policy *v1alpha1.ReplicaPolicy
+		expect *int32
+	}{
+		{
+			"no replica policy",
+			nil,
+			nil,
+		},
+		{
+			"fixed replicas of template",
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyFixed},
+			utilpointer.Int32Ptr(1),
+		},
+		{
+			"one replica per 3 nodes",
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyNodeRatio, NodesPerReplica: utilpointer.Int32Ptr(3)},
+			utilpointer.Int32Ptr(4),
+		},
+		{
+			"ready and autonomous nodes",
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyReadyNodes},
+			utilpointer.Int32Ptr(8),
+		},
+		{
+			"clamped by max replicas",
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyReadyNodes, MaxReplicas: utilpointer.Int32Ptr(5)},
+			utilpointer.Int32Ptr(5),
+		},
+		{
+			"clamped by min replicas",
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyFixed, MinReplicas: utilpointer.Int32Ptr(2)},
+			utilpointer.Int32Ptr(2),
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yad := newOverrideDaemon()
+				yad.Spec.ReplicaPolicy = st.policy
+				get := GetWorkloadReplicas(yad, nodepool)
+				if (get == nil) != (st.expect == nil) || (get != nil && *get != *st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestApplyTemplateWithReplicaPolicy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+	dc := DeploymentControllor{Client: fakeclint.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme}
+
+	yad := newOverrideDaemon(newOverride(`{"spec":{"replicas":3}}`, "hangzhou"))
+	yad.Spec.ReplicaPolicy = &v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyReadyNodes}
+	nodepool := v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+		Status:     v1alpha1.NodePoolStatus{ReadyNodeNum: 6},
+	}
+
+	set := &appsv1.Deployment{}
+	if err := dc.applyTemplate(scheme, yad, nodepool, "rev", set); err != nil {
+		t.Fatalf("\t%s\tfail to apply template, %v", failed, err)
+	}
+	if *set.Spec.Replicas != 6 {
+		t.Fatalf("\t%s\texpect replica policy to take precedence, but get %d replicas", failed, *set.Spec.Replicas)
+	}
+	t.Logf("\t%s\tapply replica policy to deployment", succeed)
+}
@@ -17,14 +17,327 @@ limitations under the License.
 package workloadcontroller
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	yurtctlutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/util"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/refmanager"
 )
 
+// statefulSetNameMaxLength is the max length of the StatefulSet name, as the
+// name with a hash suffix is used as the controller-revision-hash label value
+// of its pods, which can not be longer than 63 characters.
+const statefulSetNameMaxLength = 52
+
 type StatefulSetControllor struct {
 	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (s *StatefulSetControllor) GetTemplateType() v1alpha1.TemplateType {
+	return v1alpha1.StatefulSetTemplateType
+}
+
+func (s *StatefulSetControllor) DeleteWorkload(yad *v1alpha1.YurtAppDaemon, load *Workload) error {
+	klog.Infof("YurtAppDaemon[%s/%s] prepare delete StatefulSet[%s/%s]", yad.GetNamespace(),
+		yad.GetName(), load.Namespace, load.Name)
+
+	set := load.Spec.Ref.(runtime.Object)
+	cliSet, ok := set.(client.Object)
+	if !ok {
+		return errors.New("fail to convert runtime.Object to client.Object")
+	}
+	// the PersistentVolumeClaims of the StatefulSet are retained, just like
+	// deleting a StatefulSet directly
+	return s.Delete(context.TODO(), cliSet, client.PropagationPolicy(metav1.DeletePropagationBackground))
+}
+
+// applyTemplate updates the object to the latest revision, depending on the YurtAppDaemon.
+func (s *StatefulSetControllor) applyTemplate(scheme *runtime.Scheme, yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string, set *appsv1.StatefulSet) error {
+	// the overrides of the nodepool are part of the workload revision
+	revision = GetWorkloadRevision(yad, nodepool, revision)
+	template := yad.Spec.WorkloadTemplate.StatefulSetTemplate
+
+	if set.Labels == nil {
+		set.Labels = map[string]string{}
+	}
+	for k, v := range template.Labels {
+		set.Labels[k] = v
+	}
+	for k, v := range yad.Spec.Selector.MatchLabels {
+		set.Labels[k] = v
+	}
+	set.Labels[v1alpha1.ControllerRevisionHashLabelKey] = revision
+	set.Labels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
+
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+	for k, v := range template.Annotations {
+		set.Annotations[k] = v
+	}
+	set.Annotations[v1alpha1.AnnotationRefNodePool] = nodepool.GetName()
+
+	set.Namespace = yad.GetNamespace()
+	set.GenerateName = getStatefulSetPrefix(yad.GetName(), nodepool.GetName())
+
+	set.Spec = *template.Spec.DeepCopy()
+	set.Spec.Selector = yad.Spec.Selector.DeepCopy()
+	if set.Spec.Selector.MatchLabels == nil {
+		set.Spec.Selector.MatchLabels = map[string]string{}
+	}
+	set.Spec.Selector.MatchLabels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
+
+	// apply the overrides of the nodepool in order
+	for _, override := range GetNodePoolOverrides(yad, nodepool) {
+		patched := &appsv1.StatefulSet{}
+		if err := adapter.StrategicMergeByPatches(set, &override.Patch, patched); err != nil {
+			return fmt.Errorf("fail to apply override %s to nodepool %s: %v", string(override.Patch.Raw), nodepool.GetName(), err)
+		}
+		patched.DeepCopyInto(set)
+	}
+
+	// the replica policy takes precedence over the overrides
+	if replicas := GetWorkloadReplicas(yad, nodepool); replicas != nil {
+		set.Spec.Replicas = replicas
+	}
+
+	// pin the required node affinity to the nodepool
+	AttachNodePoolAffinity(&set.Spec.Template.Spec, nodepool.GetName())
+
+	if set.Spec.Template.Labels == nil {
+		set.Spec.Template.Labels = map[string]string{}
+	}
+	set.Spec.Template.Labels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
+	set.Spec.Template.Labels[v1alpha1.ControllerRevisionHashLabelKey] = revision
+
+	// the claims of each pool are labeled with the pool name, so that the
+	// volumes provisioned for different pools can be told apart
+	for i := range set.Spec.VolumeClaimTemplates {
+		claim := &set.Spec.VolumeClaimTemplates[i]
+		if claim.Labels == nil {
+			claim.Labels = map[string]string{}
+		}
+		claim.Labels[v1alpha1.PoolNameLabelKey] = nodepool.GetName()
+	}
+
+	// use nodeSelector
+	set.Spec.Template.Spec.NodeSelector = CreateNodeSelectorForNodePool(set.Spec.Template.Spec.NodeSelector, nodepool.GetName())
+
+	// toleration
+	nodePoolTaints := TaintsToTolerations(nodepool.Spec.Taints)
+	set.Spec.Template.Spec.Tolerations = append(set.Spec.Template.Spec.Tolerations, nodePoolTaints...)
+
+	if err := controllerutil.SetControllerReference(yad, set, scheme); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *StatefulSetControllor) ObjectKey(load *Workload) client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: load.Namespace,
+		Name:      load.Name,
+	}
+}
+
+func (s *StatefulSetControllor) UpdateWorkload(load *Workload, yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string) error {
+	klog.Infof("YurtAppDaemon[%s/%s] prepare update StatefulSet[%s/%s]", yad.GetNamespace(),
+		yad.GetName(), load.Namespace, load.Name)
+
+	set := &appsv1.StatefulSet{}
+	var updateError error
+	for i := 0; i < updateRetries; i++ {
+		getError := s.Client.Get(context.TODO(), s.ObjectKey(load), set)
+		if getError != nil {
+			return getError
+		}
+
+		// only replicas, template, updateStrategy and minReadySeconds of a
+		// StatefulSet can be updated, keep the others as they are
+		origin := set.Spec.DeepCopy()
+		if err := s.applyTemplate(s.Scheme, yad, nodepool, revision, set); err != nil {
+			return err
+		}
+		set.Spec.Selector = origin.Selector
+		set.Spec.VolumeClaimTemplates = origin.VolumeClaimTemplates
+		set.Spec.ServiceName = origin.ServiceName
+		set.Spec.PodManagementPolicy = origin.PodManagementPolicy
+
+		updateError = s.Client.Update(context.TODO(), set)
+		if updateError == nil {
+			break
+		}
+	}
+	if updateError != nil {
+		return updateError
+	}
+
+	if set.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return nil
+	}
+	var partition int32
+	if set.Spec.UpdateStrategy.RollingUpdate != nil && set.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		partition = *set.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+	// If RollingUpdate, work around for issue https://github.com/kubernetes/kubernetes/issues/67250
+	return s.deleteStuckPods(set, set.Labels[v1alpha1.ControllerRevisionHashLabelKey], partition)
+}
+
+func (s *StatefulSetControllor) CreateWorkload(yad *v1alpha1.YurtAppDaemon, nodepool v1alpha1.NodePool, revision string) error {
+	klog.Infof("YurtAppDaemon[%s/%s] prepare create new statefulset by nodepool %s ", yad.GetNamespace(), yad.GetName(), nodepool.GetName())
+
+	set := appsv1.StatefulSet{}
+	if err := s.applyTemplate(s.Scheme, yad, nodepool, revision, &set); err != nil {
+		klog.Errorf("YurtAppDaemon[%s/%s] faild to apply template, when create statefulset: %v", yad.GetNamespace(),
+			yad.GetName(), err)
+		return err
+	}
+	return s.Client.Create(context.TODO(), &set)
+}
+
+func (s *StatefulSetControllor) GetAllWorkloads(yad *v1alpha1.YurtAppDaemon) ([]*Workload, error) {
+	allSets := appsv1.StatefulSetList{}
+	selector, err := metav1.LabelSelectorAsSelector(yad.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Client.List(context.TODO(), &allSets, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, err
+	}
+
+	manager, err := refmanager.New(s.Client, yad.Spec.Selector, yad, s.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]metav1.Object, 0, len(allSets.Items))
+	for i := 0; i < len(allSets.Items); i++ {
+		t := allSets.Items[i]
+		selected = append(selected, &t)
+	}
+
+	objs, err := manager.ClaimOwnedObjects(selected)
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]*Workload, 0, len(objs))
+	for i, o := range objs {
+		set := o.(*appsv1.StatefulSet)
+		spec := set.Spec
+		w := &Workload{
+			Name:      o.GetName(),
+			Namespace: o.GetNamespace(),
+			Kind:      set.Kind,
+			Spec: WorkloadSpec{
+				Ref:          objs[i],
+				Replicas:     spec.Replicas,
+				NodeSelector: spec.Template.Spec.NodeSelector,
+				Toleration:   spec.Template.Spec.Tolerations,
+			},
+			Status: WorkloadStatus{
+				Replicas:        set.Status.Replicas,
+				ReadyReplicas:   set.Status.ReadyReplicas,
+				UpdatedReplicas: set.Status.UpdatedReplicas,
+			},
+		}
+		workloads = append(workloads, w)
+	}
+	return workloads, nil
+}
+
+func (s *StatefulSetControllor) getStatefulSetPods(set *appsv1.StatefulSet) ([]*corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(set.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	podList := &corev1.PodList{}
+	if err := s.Client.List(context.TODO(), podList, &client.ListOptions{
+		Namespace:     set.Namespace,
+		LabelSelector: selector,
+	}); err != nil {
+		return nil, err
+	}
+
+	manager, err := refmanager.New(s.Client, set.Spec.Selector, set, s.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]metav1.Object, len(podList.Items))
+	for i, pod := range podList.Items {
+		selected[i] = pod.DeepCopy()
+	}
+	claimed, err := manager.ClaimOwnedObjects(selected)
+	if err != nil {
+		return nil, err
+	}
+
+	claimedPods := make([]*corev1.Pod, len(claimed))
+	for i, pod := range claimed {
+		claimedPods[i] = pod.(*corev1.Pod)
+	}
+	return claimedPods, nil
+}
+
+// deleteStuckPods tries to work around the blocking issue https://github.com/kubernetes/kubernetes/issues/67250
+func (s *StatefulSetControllor) deleteStuckPods(set *appsv1.StatefulSet, revision string, partition int32) error {
+	pods, err := s.getStatefulSetPods(set)
+	if err != nil {
+		return err
+	}
+
+	for i := range pods {
+		pod := pods[i]
+		// If the pod is considered as stuck, delete it.
+		if isPodStuckForRollingUpdate(pod, revision, partition) {
+			klog.V(2).Infof("Delete pod %s/%s at stuck state", pod.Namespace, pod.Name)
+			err = s.Delete(context.TODO(), pod, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isPodStuckForRollingUpdate checks whether the pod is stuck under strategy RollingUpdate.
+// If a pod needs to upgrade (pod_ordinal >= partition && pod_revision != sts_revision)
+// and its readiness is false, or worse status like Pending, ImagePullBackOff, it will be blocked.
+func isPodStuckForRollingUpdate(pod *corev1.Pod, revision string, partition int32) bool {
+	if yurtctlutil.GetOrdinal(pod) < partition {
+		return false
+	}
+
+	if pod.GetLabels()[v1alpha1.ControllerRevisionHashLabelKey] == revision {
+		return false
+	}
+
+	return !podutil.IsPodReadyConditionTrue(pod.Status)
+}
 
-	scheme *runtime.Scheme
+// getStatefulSetPrefix returns the generate name of the StatefulSet, which
+// makes sure that the name of the StatefulSet is not too long.
+func getStatefulSetPrefix(controllerName, nodepoolName string) string {
+	prefix := getWorkloadPrefix(controllerName, nodepoolName)
+	// 5 random characters will be appended to the generate name
+	if len(prefix)+5 > statefulSetNameMaxLength {
+		prefix = fmt.Sprintf("%s-", controllerName)
+	}
+	return prefix
 }
 
-// var _ WorkloadControllor = &StatefulSetControllor{}
+var _ WorkloadControllor = &StatefulSetControllor{}
@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadcontroller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	utilpointer "k8s.io/utils/pointer"
+	fakeclint "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newStatefulSetDaemon(claims ...string) *v1alpha1.YurtAppDaemon {
+	yad := &v1alpha1.YurtAppDaemon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "foo-ns",
+			UID:       "yad-uid",
+		},
+		Spec: v1alpha1.YurtAppDaemonSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "daemon-foo"}},
+			WorkloadTemplate: v1alpha1.WorkloadTemplate{
+				StatefulSetTemplate: &v1alpha1.StatefulSetTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "daemon-foo"}},
+					Spec: appsv1.StatefulSetSpec{
+						Replicas:    utilpointer.Int32Ptr(2),
+						ServiceName: "foo",
+						Template: v1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "daemon-foo"}},
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{{Name: "nginx", Image: "nginx:1.19"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, claim := range claims {
+		yad.Spec.WorkloadTemplate.StatefulSetTemplate.Spec.VolumeClaimTemplates = append(
+			yad.Spec.WorkloadTemplate.StatefulSetTemplate.Spec.VolumeClaimTemplates,
+			v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: claim}})
+	}
+	return yad
+}
+
+func newStatefulSetPod(name, revision string, ready bool, owner *appsv1.StatefulSet) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: owner.Namespace,
+			Labels: map[string]string{
+				"app":                                   "daemon-foo",
+				v1alpha1.PoolNameLabelKey:               "np",
+				v1alpha1.ControllerRevisionHashLabelKey: revision,
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner,
+				appsv1.SchemeGroupVersion.WithKind("StatefulSet"))},
+		},
+		Status: v1.PodStatus{
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestStatefulSetControllor_CreateWorkload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+	fc := fakeclint.NewClientBuilder().WithScheme(scheme).Build()
+	sc := StatefulSetControllor{Client: fc, Scheme: scheme}
+
+	yad := newStatefulSetDaemon("data")
+	nodepool := v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "np"},
+		Spec: v1alpha1.NodePoolSpec{
+			Taints: []v1.Taint{{Key: "edge", Effect: v1.TaintEffectNoSchedule}},
+		},
+	}
+	if err := sc.CreateWorkload(yad, nodepool, "1"); err != nil {
+		t.Fatalf("\t%s\tfail to create workload, %v", failed, err)
+	}
+
+	ws, err := sc.GetAllWorkloads(yad)
+	if err != nil {
+		t.Fatalf("\t%s\tfail to get workloads, %v", failed, err)
+	}
+	if len(ws) != 1 {
+		t.Fatalf("\t%s\texpect 1 workload, but get %d", failed, len(ws))
+	}
+	if ws[0].GetNodePoolName() != "np" || ws[0].GetRevision() != "1" {
+		t.Fatalf("\t%s\texpect workload of nodepool np in revision 1, but get %s in %s",
+			failed, ws[0].GetNodePoolName(), ws[0].GetRevision())
+	}
+	if len(ws[0].GetToleration()) != 1 || ws[0].GetNodeSelector()[v1alpha1.LabelCurrentNodePool] != "np" {
+		t.Fatalf("\t%s\texpect workload to be scheduled to nodepool np, but get %v, %v",
+			failed, ws[0].GetNodeSelector(), ws[0].GetToleration())
+	}
+	set := ws[0].Spec.Ref.(*appsv1.StatefulSet)
+	if pool := set.Spec.VolumeClaimTemplates[0].Labels[v1alpha1.PoolNameLabelKey]; pool != "np" {
+		t.Fatalf("\t%s\texpect volume claim of nodepool np, but get %q", failed, pool)
+	}
+	t.Logf("\t%s\tcreate statefulset %s", succeed, set.Name)
+}
+
+func TestStatefulSetControllor_UpdateWorkload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+
+	tests := []struct {
+		name          string
+		strategy      appsv1.StatefulSetUpdateStrategyType
+		expectDeleted []string
+	}{
+		{
+			"delete stuck pods when rolling update",
+			appsv1.RollingUpdateStatefulSetStrategyType,
+			[]string{"foo-np-0"},
+		},
+		{
+			"keep pods when on delete",
+			appsv1.OnDeleteStatefulSetStrategyType,
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yad := newStatefulSetDaemon("data")
+				yad.Spec.WorkloadTemplate.StatefulSetTemplate.Spec.UpdateStrategy.Type = st.strategy
+				set := &appsv1.StatefulSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "foo-np",
+						Namespace: "foo-ns",
+						UID:       "sts-uid",
+						Labels: map[string]string{
+							"app":                                   "daemon-foo",
+							v1alpha1.PoolNameLabelKey:               "np",
+							v1alpha1.ControllerRevisionHashLabelKey: "1",
+						},
+						OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(yad,
+							v1alpha1.SchemeGroupVersion.WithKind("YurtAppDaemon"))},
+					},
+					Spec: appsv1.StatefulSetSpec{
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{
+							"app": "daemon-foo", v1alpha1.PoolNameLabelKey: "np"}},
+						ServiceName:          "foo",
+						VolumeClaimTemplates: []v1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "old"}}},
+					},
+				}
+				fc := fakeclint.NewClientBuilder().WithScheme(scheme).WithObjects(set,
+					newStatefulSetPod("foo-np-0", "1", false, set),
+					newStatefulSetPod("foo-np-1", "1", true, set)).Build()
+				sc := StatefulSetControllor{Client: fc, Scheme: scheme}
+
+				load := &Workload{Name: set.Name, Namespace: set.Namespace, Spec: WorkloadSpec{Ref: set}}
+				nodepool := v1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "np"}}
+				if err := sc.UpdateWorkload(load, yad, nodepool, "2"); err != nil {
+					t.Fatalf("\t%s\tfail to update workload, %v", failed, err)
+				}
+
+				updated := &appsv1.StatefulSet{}
+				if err := fc.Get(context.TODO(), sc.ObjectKey(load), updated); err != nil {
+					t.Fatalf("\t%s\tfail to get statefulset, %v", failed, err)
+				}
+				if updated.Labels[v1alpha1.ControllerRevisionHashLabelKey] != "2" {
+					t.Fatalf("\t%s\texpect revision 2, but get %v", failed, updated.Labels)
+				}
+				if claims := updated.Spec.VolumeClaimTemplates; len(claims) != 1 || claims[0].Name != "old" {
+					t.Fatalf("\t%s\texpect volume claim templates to be kept, but get %v", failed, claims)
+				}
+
+				var deleted []string
+				for _, name := range []string{"foo-np-0", "foo-np-1"} {
+					err := fc.Get(context.TODO(), types.NamespacedName{Namespace: "foo-ns", Name: name}, &v1.Pod{})
+					if apierrors.IsNotFound(err) {
+						deleted = append(deleted, name)
+					}
+				}
+				if len(deleted) != len(st.expectDeleted) || (len(deleted) != 0 && deleted[0] != st.expectDeleted[0]) {
+					t.Fatalf("\t%s\texpect deleted pods %v, but get %v", failed, st.expectDeleted, deleted)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expectDeleted, deleted)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestIsPodStuckForRollingUpdate(t *testing.T) {
+	owner := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "foo-np", Namespace: "foo-ns"}}
+
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		partition int32
+		expect    bool
+	}{
+		{
+			"unready pod of old revision",
+			newStatefulSetPod("foo-np-1", "1", false, owner),
+			0,
+			true,
+		},
+		{
+			"ready pod of old revision",
+			newStatefulSetPod("foo-np-1", "1", true, owner),
+			0,
+			false,
+		},
+		{
+			"unready pod of new revision",
+			newStatefulSetPod("foo-np-1", "2", false, owner),
+			0,
+			false,
+		},
+		{
+			"unready pod below partition",
+			newStatefulSetPod("foo-np-1", "1", false, owner),
+			2,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := isPodStuckForRollingUpdate(st.pod, "2", st.partition)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
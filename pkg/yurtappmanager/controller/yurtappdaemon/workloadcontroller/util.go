@@ -39,6 +39,49 @@ func CreateNodeSelectorByNodepoolName(nodepool string) map[string]string {
 	}
 }
 
+// CreateNodeSelectorForNodePool merges the nodepool selector into the user-provided nodeSelector.
+func CreateNodeSelectorForNodePool(nodeSelector map[string]string, nodepool string) map[string]string {
+	selector := CreateNodeSelectorByNodepoolName(nodepool)
+	for k, v := range nodeSelector {
+		if _, ok := selector[k]; !ok {
+			selector[k] = v
+		}
+	}
+	return selector
+}
+
+// IsNodeSelectorPinnedToNodePool checks whether the nodeSelector pins pods to the nodepool.
+func IsNodeSelectorPinnedToNodePool(nodeSelector map[string]string, nodepool string) bool {
+	value, ok := nodeSelector[v1alpha1.LabelCurrentNodePool]
+	return ok && value == nodepool
+}
+
+// AttachNodePoolAffinity adds the nodepool constraint into every required node selector term
+// of the user-provided node affinity, since the terms are ORed and each of them should only
+// match the nodes of the nodepool.
+func AttachNodePoolAffinity(podSpec *corev1.PodSpec, nodepool string) {
+	if podSpec.Affinity == nil || podSpec.Affinity.NodeAffinity == nil ||
+		podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return
+	}
+
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      v1alpha1.LabelCurrentNodePool,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{nodepool},
+	}
+	terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for i := range terms {
+		expressions := make([]corev1.NodeSelectorRequirement, 0, len(terms[i].MatchExpressions)+1)
+		for _, expression := range terms[i].MatchExpressions {
+			if expression.Key != v1alpha1.LabelCurrentNodePool {
+				expressions = append(expressions, expression)
+			}
+		}
+		terms[i].MatchExpressions = append(expressions, requirement)
+	}
+}
+
 func TaintsToTolerations(taints []corev1.Taint) []corev1.Toleration {
 	tolerations := []corev1.Toleration{}
 	for _, taint := range taints {
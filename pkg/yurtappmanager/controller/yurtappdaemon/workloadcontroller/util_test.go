@@ -90,6 +90,99 @@ func TestCreateNodeSelectorByNodepoolName(t *testing.T) {
 	}
 }
 
+func TestCreateNodeSelectorForNodePool(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeSelector map[string]string
+		nodepool     string
+		expect       map[string]string
+	}{
+		{
+			"no user nodeSelector",
+			nil,
+			"a",
+			map[string]string{v1alpha1.LabelCurrentNodePool: "a"},
+		},
+		{
+			"user nodeSelector preserved",
+			map[string]string{"kubernetes.io/arch": "arm64", v1alpha1.LabelCurrentNodePool: "b"},
+			"a",
+			map[string]string{"kubernetes.io/arch": "arm64", v1alpha1.LabelCurrentNodePool: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := CreateNodeSelectorForNodePool(st.nodeSelector, st.nodepool)
+				if !reflect.DeepEqual(get, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestAttachNodePoolAffinity(t *testing.T) {
+	gpu := corev1.NodeSelectorRequirement{Key: "gpu", Operator: corev1.NodeSelectorOpExists}
+	arch := corev1.NodeSelectorRequirement{Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}}
+	pool := corev1.NodeSelectorRequirement{Key: v1alpha1.LabelCurrentNodePool, Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}
+	stalePool := corev1.NodeSelectorRequirement{Key: v1alpha1.LabelCurrentNodePool, Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}
+	requiredAffinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		affinity *corev1.Affinity
+		expect   *corev1.Affinity
+	}{
+		{
+			"no affinity",
+			nil,
+			nil,
+		},
+		{
+			"pool constraint merged into every term",
+			requiredAffinity(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{gpu}},
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{arch}}),
+			requiredAffinity(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{gpu, pool}},
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{arch, pool}}),
+		},
+		{
+			"stale pool constraint replaced",
+			requiredAffinity(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{stalePool, gpu}}),
+			requiredAffinity(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{gpu, pool}}),
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				podSpec := &corev1.PodSpec{Affinity: st.affinity.DeepCopy()}
+				AttachNodePoolAffinity(podSpec, "a")
+				if !reflect.DeepEqual(podSpec.Affinity, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, podSpec.Affinity)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, podSpec.Affinity)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
 func TestTaintsToTolerations(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -34,12 +34,16 @@ type Workload struct {
 // WorkloadSpec stores the spec details of the workload
 type WorkloadSpec struct {
 	Ref          metav1.Object
+	Replicas     *int32
 	Toleration   []corev1.Toleration
 	NodeSelector map[string]string
 }
 
 // WorkloadStatus stores the observed state of the Workload.
 type WorkloadStatus struct {
+	Replicas        int32
+	ReadyReplicas   int32
+	UpdatedReplicas int32
 }
 
 func (w *Workload) GetRevision() string {
@@ -58,6 +62,18 @@ func (w *Workload) GetNodeSelector() map[string]string {
 	return w.Spec.NodeSelector
 }
 
+func (w *Workload) GetReplicas() *int32 {
+	return w.Spec.Replicas
+}
+
+// GetDesiredReplicas returns the number of desired replicas of the workload, which defaults to 1.
+func (w *Workload) GetDesiredReplicas() int32 {
+	if w.Spec.Replicas == nil {
+		return 1
+	}
+	return *w.Spec.Replicas
+}
+
 func (w *Workload) GetKind() string {
 	return w.Kind
 }
@@ -21,6 +21,7 @@ import (
 	"flag"
 	"fmt"
 	"reflect"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -87,6 +88,31 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to the workloads, so that the status of YurtAppDaemon is kept up to date
+	err = c.Watch(&source.Kind{Type: &appsv1.Deployment{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &unitv1alpha1.YurtAppDaemon{},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &unitv1alpha1.YurtAppDaemon{},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &appsv1.DaemonSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &unitv1alpha1.YurtAppDaemon{},
+	})
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes to NodePool
 	err = c.Watch(&source.Kind{Type: &unitv1alpha1.NodePool{}}, &EnqueueYurtAppDaemonForNodePool{client: mgr.GetClient()})
 	if err != nil {
@@ -114,8 +140,9 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 
 		recorder: mgr.GetEventRecorderFor(controllerName),
 		controls: map[unitv1alpha1.TemplateType]workloadcontroller.WorkloadControllor{
-			//			unitv1alpha1.StatefulSetTemplateType: &StatefulSetControllor{Client: mgr.GetClient(), scheme: mgr.GetScheme()},
-			unitv1alpha1.DeploymentTemplateType: &workloadcontroller.DeploymentControllor{Client: mgr.GetClient(), Scheme: mgr.GetScheme()},
+			unitv1alpha1.StatefulSetTemplateType: &workloadcontroller.StatefulSetControllor{Client: mgr.GetClient(), Scheme: mgr.GetScheme()},
+			unitv1alpha1.DeploymentTemplateType:  &workloadcontroller.DeploymentControllor{Client: mgr.GetClient(), Scheme: mgr.GetScheme()},
+			unitv1alpha1.DaemonSetTemplateType:   &workloadcontroller.DaemonSetControllor{Client: mgr.GetClient(), Scheme: mgr.GetScheme()},
 		},
 	}
 }
@@ -181,12 +208,19 @@ func (r *ReconcileYurtAppDaemon) Reconcile(_ context.Context, request reconcile.
 		return reconcile.Result{}, err
 	}
 
+	allNameToNodePools, notReadySince, requeueAfter := FilterNodePools(instance, allNameToNodePools, currentNPToWorkload, time.Now())
+
 	newStatus, err := r.manageWorkloads(instance, currentNPToWorkload, allNameToNodePools, expectedRevision.Name, templateType)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	setNotReadySince(newStatus, notReadySince)
 
-	return r.updateStatus(instance, newStatus, oldStatus, currentRevision, collisionCount, templateType)
+	result, err := r.updateStatus(instance, newStatus, oldStatus, currentRevision, collisionCount, templateType)
+	if err == nil && requeueAfter > 0 {
+		result.RequeueAfter = requeueAfter
+	}
+	return result, err
 }
 
 func (r *ReconcileYurtAppDaemon) updateStatus(instance *unitv1alpha1.YurtAppDaemon, newStatus, oldStatus *unitv1alpha1.YurtAppDaemonStatus,
@@ -204,6 +238,10 @@ func (r *ReconcileYurtAppDaemon) updateYurtAppDaemon(yad *unitv1alpha1.YurtAppDa
 		oldStatus.TemplateType == newStatus.TemplateType &&
 		yad.Generation == newStatus.ObservedGeneration &&
 		reflect.DeepEqual(oldStatus.NodePools, newStatus.NodePools) &&
+		oldStatus.Replicas == newStatus.Replicas &&
+		oldStatus.ReadyReplicas == newStatus.ReadyReplicas &&
+		oldStatus.UpdatedReplicas == newStatus.UpdatedReplicas &&
+		reflect.DeepEqual(oldStatus.WorkloadStatuses, newStatus.WorkloadStatuses) &&
 		reflect.DeepEqual(oldStatus.Conditions, newStatus.Conditions) {
 		klog.Infof("YurtAppDaemon[%s/%s] oldStatus==newStatus, no need to update status", yad.GetNamespace(), yad.GetName())
 		return yad, nil
@@ -263,8 +301,13 @@ func (r *ReconcileYurtAppDaemon) manageWorkloads(instance *unitv1alpha1.YurtAppD
 	}
 	newStatus.NodePools = nps
 
+	poolErrors := newWorkloadErrors()
+	defer func() {
+		calculateWorkloadStatuses(instance, newStatus, currentNodepoolToWorkload, allNameToNodePools, expectedRevision, poolErrors)
+	}()
+
 	needDeleted, needUpdate, needCreate := r.classifyWorkloads(instance, currentNodepoolToWorkload, allNameToNodePools, expectedRevision)
-	provision, err := r.manageWorkloadsProvision(instance, allNameToNodePools, expectedRevision, templateType, needDeleted, needCreate)
+	provision, err := r.manageWorkloadsProvision(instance, allNameToNodePools, expectedRevision, templateType, needDeleted, needCreate, poolErrors)
 	if err != nil {
 		SetYurtAppDaemonCondition(newStatus, NewYurtAppDaemonCondition(unitv1alpha1.WorkLoadProvisioned, corev1.ConditionFalse, "Error", err.Error()))
 		return newStatus, fmt.Errorf("fail to manage workload provision: %v", err)
@@ -279,6 +322,7 @@ func (r *ReconcileYurtAppDaemon) manageWorkloads(instance *unitv1alpha1.YurtAppD
 			u := needUpdate[index]
 			updateWorkloadErr := r.controls[templateType].UpdateWorkload(u, instance, allNameToNodePools[u.GetNodePoolName()], expectedRevision)
 			if updateWorkloadErr != nil {
+				poolErrors.record(u.GetNodePoolName(), updateWorkloadErr)
 				r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed %s", eventTypeWorkloadsUpdated),
 					fmt.Sprintf("Error updating workload type(%s) %s when updating: %s", templateType, u.Name, updateWorkloadErr))
 				klog.Errorf("YurtAppDaemon[%s/%s] update workload[%s/%s/%s] error %v", instance.GetNamespace(), instance.GetName(),
@@ -299,7 +343,7 @@ func (r *ReconcileYurtAppDaemon) manageWorkloads(instance *unitv1alpha1.YurtAppD
 
 func (r *ReconcileYurtAppDaemon) manageWorkloadsProvision(instance *unitv1alpha1.YurtAppDaemon,
 	allNameToNodePools map[string]unitv1alpha1.NodePool, expectedRevision string, templateType unitv1alpha1.TemplateType,
-	needDeleted []*workloadcontroller.Workload, needCreate []string) (bool, error) {
+	needDeleted []*workloadcontroller.Workload, needCreate []string, poolErrors *workloadErrors) (bool, error) {
 	// 针对于Create 的 需要创建
 
 	var errs []error
@@ -312,6 +356,7 @@ func (r *ReconcileYurtAppDaemon) manageWorkloadsProvision(instance *unitv1alpha1
 			err := r.controls[templateType].CreateWorkload(instance, allNameToNodePools[nodepoolName], expectedRevision)
 			//err := r.poolControls[workloadType].CreatePool(ud, poolName, revision, replicas)
 			if err != nil {
+				poolErrors.record(nodepoolName, err)
 				klog.Errorf("YurtAppDaemon[%s/%s] templatetype %s create workload by nodepool %s error: %s",
 					instance.GetNamespace(), instance.GetName(), templateType, nodepoolName, err.Error())
 				if !errors.IsTimeout(err) {
@@ -358,7 +403,7 @@ func (r *ReconcileYurtAppDaemon) classifyWorkloads(instance *unitv1alpha1.YurtAp
 		if np, ok := allNameToNodePools[npName]; ok {
 			match := true
 			// judge workload NodeSelector
-			if !reflect.DeepEqual(load.GetNodeSelector(), workloadcontroller.CreateNodeSelectorByNodepoolName(npName)) {
+			if !workloadcontroller.IsNodeSelectorPinnedToNodePool(load.GetNodeSelector(), npName) {
 				match = false
 			}
 			// judge workload whether toleration all taints
@@ -366,8 +411,14 @@ func (r *ReconcileYurtAppDaemon) classifyWorkloads(instance *unitv1alpha1.YurtAp
 				match = false
 			}
 
-			// judge revision
-			if load.GetRevision() != expectedRevision {
+			// judge revision, including the overrides of the nodepool
+			if load.GetRevision() != workloadcontroller.GetWorkloadRevision(instance, np, expectedRevision) {
+				match = false
+			}
+
+			// judge replicas derived from the size of the nodepool
+			if replicas := workloadcontroller.GetWorkloadReplicas(instance, np); replicas != nil &&
+				(load.GetReplicas() == nil || *load.GetReplicas() != *replicas) {
 				match = false
 			}
 
@@ -428,10 +479,12 @@ func (r *ReconcileYurtAppDaemon) getTemplateControls(instance *unitv1alpha1.Yurt
 		return r.controls[unitv1alpha1.StatefulSetTemplateType], unitv1alpha1.StatefulSetTemplateType, nil
 	case instance.Spec.WorkloadTemplate.DeploymentTemplate != nil:
 		return r.controls[unitv1alpha1.DeploymentTemplateType], unitv1alpha1.DeploymentTemplateType, nil
+	case instance.Spec.WorkloadTemplate.DaemonSetTemplate != nil:
+		return r.controls[unitv1alpha1.DaemonSetTemplateType], unitv1alpha1.DaemonSetTemplateType, nil
 	default:
 		klog.Errorf("The appropriate WorkloadTemplate was not found")
-		return nil, "", fmt.Errorf("The appropriate WorkloadTemplate was not found, Now Support(%s/%s)",
-			unitv1alpha1.StatefulSetTemplateType, unitv1alpha1.DeploymentTemplateType)
+		return nil, "", fmt.Errorf("The appropriate WorkloadTemplate was not found, Now Support(%s/%s/%s)",
+			unitv1alpha1.StatefulSetTemplateType, unitv1alpha1.DeploymentTemplateType, unitv1alpha1.DaemonSetTemplateType)
 	}
 }
 
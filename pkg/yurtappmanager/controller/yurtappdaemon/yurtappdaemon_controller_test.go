@@ -398,7 +398,7 @@ func TestManageWorkloadsProvision(t *testing.T) {
 			{
 				rc := &ReconcileYurtAppDaemon{}
 				get, _ := rc.manageWorkloadsProvision(
-					st.instance, st.allNameToNodePools, st.expectedRevision, st.templateType, st.needDeleted, st.needCreate)
+					st.instance, st.allNameToNodePools, st.expectedRevision, st.templateType, st.needDeleted, st.needCreate, newWorkloadErrors())
 				if !reflect.DeepEqual(get, false) {
 					t.Fatalf("\t%s\texpect %v, but get %v", failed, false, get)
 				}
@@ -43,11 +43,14 @@ type Adapter interface {
 	// IsExpected checks the pool is the expected revision or not.
 	// If not, YurtAppSet will call ApplyPoolTemplate to update it.
 	IsExpected(pool metav1.Object, revision string) bool
+	// ApplyPoolReplicas sets the replicas of the pool and keeps its revision.
+	ApplyPoolReplicas(replicas int32, pool runtime.Object) error
 	// PostUpdate does some works after pool updated
 	PostUpdate(yas *alpha1.YurtAppSet, pool runtime.Object, revision string) error
 }
 
 type ReplicasInfo struct {
-	Replicas      int32
-	ReadyReplicas int32
+	Replicas        int32
+	ReadyReplicas   int32
+	UpdatedReplicas int32
 }
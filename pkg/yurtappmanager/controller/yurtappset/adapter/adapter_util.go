@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -146,18 +147,73 @@ func PoolHasPatch(poolConfig *appsv1alpha1.Pool, set metav1.Object) bool {
 	return true
 }
 
-func CreateNewPatchedObject(patchInfo *runtime.RawExtension, set metav1.Object, newPatched metav1.Object) error {
+// PatchInfo returns the patch of the pool recorded in the patch annotation
+// of the pool workload. The patch type is recorded along with the patch unless
+// it is the default strategic merge patch, so that the pool is updated once
+// its patch type changes.
+func PatchInfo(poolConfig *appsv1alpha1.Pool) string {
+	if poolConfig.Patch == nil {
+		return ""
+	}
+	if isStrategicMergePatch(poolConfig.PatchType) {
+		return string(poolConfig.Patch.Raw)
+	}
+	return fmt.Sprintf("%s:%s", poolConfig.PatchType, string(poolConfig.Patch.Raw))
+}
+
+func isStrategicMergePatch(patchType appsv1alpha1.PatchType) bool {
+	return patchType == "" || patchType == appsv1alpha1.StrategicMergePatchType
+}
+
+// ApplyJSONPatch applies the RFC 6902 JSON patch or the RFC 7386 merge patch
+// to the json document. The strategic merge patch is applied as a merge patch,
+// as it can not be applied without the schema of the document.
+func ApplyJSONPatch(patchType appsv1alpha1.PatchType, original, patch []byte) ([]byte, error) {
+	switch {
+	case patchType == appsv1alpha1.JSONPatchType:
+		decoded, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, err
+		}
+		return decoded.Apply(original)
+	case patchType == appsv1alpha1.MergePatchType || isStrategicMergePatch(patchType):
+		return jsonpatch.MergePatch(original, patch)
+	default:
+		return nil, fmt.Errorf("unsupported patch type %s", patchType)
+	}
+}
+
+// PatchByPatches applies the patch of the patch type to oldobj, and stores the result in newPatched.
+func PatchByPatches(oldobj interface{}, patchType appsv1alpha1.PatchType, patch *runtime.RawExtension, newPatched interface{}) error {
+	if isStrategicMergePatch(patchType) {
+		return StrategicMergeByPatches(oldobj, patch, newPatched)
+	}
+
+	original, err := json.Marshal(oldobj)
+	if err != nil {
+		klog.Errorf("Marshal error %v", err)
+		return err
+	}
+	patched, err := ApplyJSONPatch(patchType, original, patch.Raw)
+	if err != nil {
+		klog.Errorf("Apply %s patch error %v, patch Raw %v", patchType, err, string(patch.Raw))
+		return err
+	}
+	return json.Unmarshal(patched, newPatched)
+}
+
+func CreateNewPatchedObject(poolConfig *appsv1alpha1.Pool, set metav1.Object, newPatched metav1.Object) error {
 
-	if err := StrategicMergeByPatches(set, patchInfo, newPatched); err != nil {
+	if err := PatchByPatches(set, poolConfig.PatchType, poolConfig.Patch, newPatched); err != nil {
 		return err
 	}
 
 	if anno := newPatched.GetAnnotations(); anno == nil {
 		newPatched.SetAnnotations(map[string]string{
-			appsv1alpha1.AnnotationPatchKey: string(patchInfo.Raw),
+			appsv1alpha1.AnnotationPatchKey: PatchInfo(poolConfig),
 		})
 	} else {
-		anno[appsv1alpha1.AnnotationPatchKey] = string(patchInfo.Raw)
+		anno[appsv1alpha1.AnnotationPatchKey] = PatchInfo(poolConfig)
 	}
 	return nil
 }
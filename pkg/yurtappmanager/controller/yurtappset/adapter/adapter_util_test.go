@@ -159,7 +159,7 @@ func TestCreateNewPatchedObject(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.Name, func(t *testing.T) {
 			newObj := &appsv1.Deployment{}
-			if err := CreateNewPatchedObject(c.PatchInfo, c.OldObj, newObj); err != nil {
+			if err := CreateNewPatchedObject(&unitv1alpha1.Pool{Patch: c.PatchInfo}, c.OldObj, newObj); err != nil {
 				t.Fatalf("%s CreateNewPatchedObject error %v", c.Name, err)
 			}
 			if !c.EqualFunction(newObj) {
@@ -168,3 +168,87 @@ func TestCreateNewPatchedObject(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateNewPatchedObjectByPatchType(t *testing.T) {
+	oldObj := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "nginx", Image: "nginx:1.19.0", Args: []string{"--a", "--b"}},
+						{Name: "sidecar", Image: "sidecar:1.0"},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		Name            string
+		PatchType       unitv1alpha1.PatchType
+		Patch           string
+		ExpectErr       bool
+		ExpectPatchInfo string
+		EqualFunction   func(new *appsv1.Deployment) bool
+	}{
+		{
+			Name:      "json patch removes list item by index",
+			PatchType: unitv1alpha1.JSONPatchType,
+			Patch: `[{"op":"remove","path":"/spec/template/spec/containers/0/args/1"},` +
+				`{"op":"replace","path":"/spec/template/spec/containers/1/image","value":"sidecar:2.0"}]`,
+			ExpectPatchInfo: `JSONPatch:[{"op":"remove","path":"/spec/template/spec/containers/0/args/1"},` +
+				`{"op":"replace","path":"/spec/template/spec/containers/1/image","value":"sidecar:2.0"}]`,
+			EqualFunction: func(new *appsv1.Deployment) bool {
+				containers := new.Spec.Template.Spec.Containers
+				return len(containers) == 2 && len(containers[0].Args) == 1 && containers[0].Args[0] == "--a" &&
+					containers[1].Image == "sidecar:2.0"
+			},
+		},
+		{
+			Name:            "merge patch replaces the whole list",
+			PatchType:       unitv1alpha1.MergePatchType,
+			Patch:           `{"spec":{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx:1.18.0"}]}}}}`,
+			ExpectPatchInfo: `Merge:{"spec":{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx:1.18.0"}]}}}}`,
+			EqualFunction: func(new *appsv1.Deployment) bool {
+				containers := new.Spec.Template.Spec.Containers
+				return len(containers) == 1 && containers[0].Image == "nginx:1.18.0" && len(containers[0].Args) == 0
+			},
+		},
+		{
+			Name:            "strategic merge patch merges the list by name",
+			PatchType:       unitv1alpha1.StrategicMergePatchType,
+			Patch:           `{"spec":{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx:1.18.0"}]}}}}`,
+			ExpectPatchInfo: `{"spec":{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx:1.18.0"}]}}}}`,
+			EqualFunction: func(new *appsv1.Deployment) bool {
+				containers := new.Spec.Template.Spec.Containers
+				return len(containers) == 2 && containers[0].Image == "nginx:1.18.0" && len(containers[0].Args) == 2
+			},
+		},
+		{
+			Name:      "json patch on missing path",
+			PatchType: unitv1alpha1.JSONPatchType,
+			Patch:     `[{"op":"remove","path":"/spec/template/spec/containers/5"}]`,
+			ExpectErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			pool := &unitv1alpha1.Pool{Patch: &runtime.RawExtension{Raw: []byte(c.Patch)}, PatchType: c.PatchType}
+			newObj := &appsv1.Deployment{}
+			err := CreateNewPatchedObject(pool, oldObj.DeepCopy(), newObj)
+			if (err != nil) != c.ExpectErr {
+				t.Fatalf("%s expect error %v, but get %v", c.Name, c.ExpectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !c.EqualFunction(newObj) {
+				t.Fatalf("%s Not Expect equal function, get %v", c.Name, newObj.Spec.Template.Spec.Containers)
+			}
+			if info := newObj.Annotations[unitv1alpha1.AnnotationPatchKey]; info != c.ExpectPatchInfo {
+				t.Fatalf("%s expect patch info %s, but get %s", c.Name, c.ExpectPatchInfo, info)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// DaemonSetAdapter implements the Adapter interface for DaemonSet pools.
+// A DaemonSet runs one pod on every node of its pool, so the replicas
+// allocated to the pool are ignored.
+type DaemonSetAdapter struct {
+	client.Client
+
+	Scheme *runtime.Scheme
+}
+
+var _ Adapter = &DaemonSetAdapter{}
+
+// NewResourceObject creates a empty DaemonSet object.
+func (a *DaemonSetAdapter) NewResourceObject() runtime.Object {
+	return &appsv1.DaemonSet{}
+}
+
+// NewResourceListObject creates a empty DaemonSetList object.
+func (a *DaemonSetAdapter) NewResourceListObject() runtime.Object {
+	return &appsv1.DaemonSetList{}
+}
+
+// GetStatusObservedGeneration returns the observed generation of the pool.
+func (a *DaemonSetAdapter) GetStatusObservedGeneration(obj metav1.Object) int64 {
+	return obj.(*appsv1.DaemonSet).Status.ObservedGeneration
+}
+
+// GetDetails returns the replicas detail the pool needs.
+// The replicas of a DaemonSet are the number of nodes it should be scheduled to.
+func (a *DaemonSetAdapter) GetDetails(obj metav1.Object) (ReplicasInfo, error) {
+	set := obj.(*appsv1.DaemonSet)
+
+	replicasInfo := ReplicasInfo{
+		Replicas:        set.Status.DesiredNumberScheduled,
+		ReadyReplicas:   set.Status.NumberReady,
+		UpdatedReplicas: set.Status.UpdatedNumberScheduled,
+	}
+	return replicasInfo, nil
+}
+
+// GetPoolFailure returns the failure information of the pool.
+// DaemonSet has no condition.
+func (a *DaemonSetAdapter) GetPoolFailure() *string {
+	return nil
+}
+
+// ApplyPoolTemplate updates the pool to the latest revision, depending on the DaemonSetTemplate.
+func (a *DaemonSetAdapter) ApplyPoolTemplate(yas *alpha1.YurtAppSet, poolName, revision string,
+	replicas int32, obj runtime.Object) error {
+	set := obj.(*appsv1.DaemonSet)
+
+	var poolConfig *alpha1.Pool
+	for i, pool := range yas.Spec.Topology.Pools {
+		if pool.Name == poolName {
+			poolConfig = &(yas.Spec.Topology.Pools[i])
+			break
+		}
+	}
+	if poolConfig == nil {
+		return fmt.Errorf("fail to find pool config %s", poolName)
+	}
+
+	set.Namespace = yas.Namespace
+
+	if set.Labels == nil {
+		set.Labels = map[string]string{}
+	}
+	for k, v := range yas.Spec.WorkloadTemplate.DaemonSetTemplate.Labels {
+		set.Labels[k] = v
+	}
+	for k, v := range yas.Spec.Selector.MatchLabels {
+		set.Labels[k] = v
+	}
+	set.Labels[alpha1.ControllerRevisionHashLabelKey] = revision
+	// record the pool name as a label
+	set.Labels[alpha1.PoolNameLabelKey] = poolName
+
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+	for k, v := range yas.Spec.WorkloadTemplate.DaemonSetTemplate.Annotations {
+		set.Annotations[k] = v
+	}
+
+	set.GenerateName = getPoolPrefix(yas.Name, poolName)
+
+	selectors := yas.Spec.Selector.DeepCopy()
+	selectors.MatchLabels[alpha1.PoolNameLabelKey] = poolName
+
+	if err := controllerutil.SetControllerReference(yas, set, a.Scheme); err != nil {
+		return err
+	}
+
+	set.Spec.Selector = selectors
+
+	set.Spec.UpdateStrategy = *yas.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.UpdateStrategy.DeepCopy()
+	set.Spec.Template = *yas.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.Template.DeepCopy()
+	if set.Spec.Template.Labels == nil {
+		set.Spec.Template.Labels = map[string]string{}
+	}
+	set.Spec.Template.Labels[alpha1.PoolNameLabelKey] = poolName
+	set.Spec.Template.Labels[alpha1.ControllerRevisionHashLabelKey] = revision
+
+	set.Spec.RevisionHistoryLimit = yas.Spec.RevisionHistoryLimit
+	set.Spec.MinReadySeconds = yas.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.MinReadySeconds
+
+	attachNodeAffinityAndTolerations(&set.Spec.Template.Spec, poolConfig)
+
+	if !PoolHasPatch(poolConfig, set) {
+		klog.Infof("DaemonSet[%s/%s-] has no patches, do not need strategicmerge", set.Namespace,
+			set.GenerateName)
+		return nil
+	}
+
+	patched := &appsv1.DaemonSet{}
+	if err := CreateNewPatchedObject(poolConfig, set, patched); err != nil {
+		klog.Errorf("DaemonSet[%s/%s-] apply patch %s error %v", set.Namespace,
+			set.GenerateName, string(poolConfig.Patch.Raw), err)
+		return err
+	}
+	patched.DeepCopyInto(set)
+
+	klog.Infof("DaemonSet [%s/%s-] has patches configure successfully:%v", set.Namespace,
+		set.GenerateName, string(poolConfig.Patch.Raw))
+	return nil
+}
+
+// PostUpdate does some works after pool updated.
+func (a *DaemonSetAdapter) PostUpdate(yas *alpha1.YurtAppSet, obj runtime.Object, revision string) error {
+	// Do nothing,
+	return nil
+}
+
+// IsExpected checks the pool is the expected revision or not.
+// The revision label can tell the current pool revision.
+func (a *DaemonSetAdapter) IsExpected(obj metav1.Object, revision string) bool {
+	return obj.GetLabels()[alpha1.ControllerRevisionHashLabelKey] != revision
+}
+
+// ApplyPoolReplicas does nothing as the replicas of DaemonSet are decided by the nodes.
+func (a *DaemonSetAdapter) ApplyPoolReplicas(replicas int32, obj runtime.Object) error {
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakeclint "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestDaemonSetAdapter_ApplyPoolTemplate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+	fc := fakeclint.NewClientBuilder().WithScheme(scheme).Build()
+	da := DaemonSetAdapter{Client: fc, Scheme: scheme}
+
+	poolTerm := corev1.NodeSelectorRequirement{Key: "node-name", Operator: corev1.NodeSelectorOpIn, Values: []string{"nodeA"}}
+	yas := &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"name": "foo"}},
+			WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
+				DaemonSetTemplate: &appsv1alpha1.DaemonSetTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"name": "foo"}},
+					Spec: appsv1.DaemonSetSpec{
+						UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType},
+						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"name": "foo"}},
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "agent", Image: "agent:1.0"}},
+							},
+						},
+					},
+				},
+			},
+			Topology: appsv1alpha1.Topology{
+				Pools: []appsv1alpha1.Pool{{
+					Name:             "hangzhou",
+					NodeSelectorTerm: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{poolTerm}},
+					Tolerations:      []corev1.Toleration{{Key: "edge", Operator: corev1.TolerationOpExists}},
+				}},
+			},
+		},
+	}
+
+	set := &appsv1.DaemonSet{}
+	if err := da.ApplyPoolTemplate(yas, "hangzhou", "1", 3, set); err != nil {
+		t.Fatalf("fail to apply pool template, %v", err)
+	}
+
+	if set.GenerateName != "foo-hangzhou-" || set.Labels[appsv1alpha1.PoolNameLabelKey] != "hangzhou" ||
+		set.Labels[appsv1alpha1.ControllerRevisionHashLabelKey] != "1" {
+		t.Fatalf("unexpected metadata of daemonset %s: %v", set.GenerateName, set.Labels)
+	}
+	expectSelector := map[string]string{"name": "foo", appsv1alpha1.PoolNameLabelKey: "hangzhou"}
+	if !reflect.DeepEqual(set.Spec.Selector.MatchLabels, expectSelector) {
+		t.Fatalf("expect selector %v, but get %v", expectSelector, set.Spec.Selector.MatchLabels)
+	}
+	if set.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType {
+		t.Fatalf("expect update strategy OnDelete, but get %s", set.Spec.UpdateStrategy.Type)
+	}
+	terms := set.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || !reflect.DeepEqual(terms[0].MatchExpressions, []corev1.NodeSelectorRequirement{poolTerm}) {
+		t.Fatalf("expect daemonset pinned to the pool, but get %v", terms)
+	}
+	if len(set.Spec.Template.Spec.Tolerations) != 1 {
+		t.Fatalf("expect the tolerations of the pool, but get %v", set.Spec.Template.Spec.Tolerations)
+	}
+	if len(set.OwnerReferences) != 1 || set.OwnerReferences[0].Name != "foo" {
+		t.Fatalf("expect daemonset owned by foo, but get %v", set.OwnerReferences)
+	}
+	t.Logf("apply pool template to daemonset %s", set.GenerateName)
+}
+
+func TestDaemonSetAdapter_GetDetails(t *testing.T) {
+	da := DaemonSetAdapter{}
+	set := &appsv1.DaemonSet{
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 5,
+			NumberReady:            4,
+			UpdatedNumberScheduled: 3,
+		},
+	}
+
+	expect := ReplicasInfo{Replicas: 5, ReadyReplicas: 4, UpdatedReplicas: 3}
+	get, err := da.GetDetails(set)
+	if err != nil || get != expect {
+		t.Fatalf("expect %v, but get %v, %v", expect, get, err)
+	}
+	t.Logf("expect %v, get %v", expect, get)
+}
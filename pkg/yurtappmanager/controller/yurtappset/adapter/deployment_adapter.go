@@ -61,8 +61,9 @@ func (a *DeploymentAdapter) GetDetails(obj metav1.Object) (ReplicasInfo, error)
 		specReplicas = *set.Spec.Replicas
 	}
 	replicasInfo := ReplicasInfo{
-		Replicas:      specReplicas,
-		ReadyReplicas: set.Status.ReadyReplicas,
+		Replicas:        specReplicas,
+		ReadyReplicas:   set.Status.ReadyReplicas,
+		UpdatedReplicas: set.Status.UpdatedReplicas,
 	}
 	return replicasInfo, nil
 }
@@ -145,8 +146,8 @@ func (a *DeploymentAdapter) ApplyPoolTemplate(yas *alpha1.YurtAppSet, poolName,
 	}
 
 	patched := &appsv1.Deployment{}
-	if err := CreateNewPatchedObject(poolConfig.Patch, set, patched); err != nil {
-		klog.Errorf("Deployment[%s/%s-] strategic merge by patch %s error %v", set.Namespace,
+	if err := CreateNewPatchedObject(poolConfig, set, patched); err != nil {
+		klog.Errorf("Deployment[%s/%s-] apply patch %s error %v", set.Namespace,
 			set.GenerateName, string(poolConfig.Patch.Raw), err)
 		return err
 	}
@@ -168,3 +169,10 @@ func (a *DeploymentAdapter) PostUpdate(yas *alpha1.YurtAppSet, obj runtime.Objec
 func (a *DeploymentAdapter) IsExpected(obj metav1.Object, revision string) bool {
 	return obj.GetLabels()[alpha1.ControllerRevisionHashLabelKey] != revision
 }
+
+// ApplyPoolReplicas sets the replicas of the pool and keeps its revision.
+func (a *DeploymentAdapter) ApplyPoolReplicas(replicas int32, obj runtime.Object) error {
+	set := obj.(*appsv1.Deployment)
+	set.Spec.Replicas = &replicas
+	return nil
+}
@@ -71,8 +71,9 @@ func (a *StatefulSetAdapter) GetDetails(obj metav1.Object) (ReplicasInfo, error)
 		specReplicas = *set.Spec.Replicas
 	}
 	replicasInfo := ReplicasInfo{
-		Replicas:      specReplicas,
-		ReadyReplicas: set.Status.ReadyReplicas,
+		Replicas:        specReplicas,
+		ReadyReplicas:   set.Status.ReadyReplicas,
+		UpdatedReplicas: set.Status.UpdatedReplicas,
 	}
 
 	return replicasInfo, nil
@@ -156,8 +157,8 @@ func (a *StatefulSetAdapter) ApplyPoolTemplate(yas *alpha1.YurtAppSet, poolName,
 	}
 
 	patched := &appsv1.StatefulSet{}
-	if err := CreateNewPatchedObject(poolConfig.Patch, set, patched); err != nil {
-		klog.Errorf("StatefulSet[%s/%s-] strategic merge by patch %s error %v", set.Namespace,
+	if err := CreateNewPatchedObject(poolConfig, set, patched); err != nil {
+		klog.Errorf("StatefulSet[%s/%s-] apply patch %s error %v", set.Namespace,
 			set.GenerateName, string(poolConfig.Patch.Raw), err)
 		return err
 	}
@@ -190,6 +191,13 @@ func (a *StatefulSetAdapter) IsExpected(obj metav1.Object, revision string) bool
 	return obj.GetLabels()[alpha1.ControllerRevisionHashLabelKey] != revision
 }
 
+// ApplyPoolReplicas sets the replicas of the pool and keeps its revision.
+func (a *StatefulSetAdapter) ApplyPoolReplicas(replicas int32, obj runtime.Object) error {
+	set := obj.(*appsv1.StatefulSet)
+	set.Spec.Replicas = &replicas
+	return nil
+}
+
 func (a *StatefulSetAdapter) getStatefulSetPods(set *appsv1.StatefulSet) ([]*corev1.Pod, error) {
 	selector, err := metav1.LabelSelectorAsSelector(set.Spec.Selector)
 	if err != nil {
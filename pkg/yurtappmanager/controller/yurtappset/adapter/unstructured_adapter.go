@@ -0,0 +1,311 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// UnstructuredAdapter implements the Adapter interface for the pools of a
+// third-party workload kind. The workload is handled as unstructured object,
+// and the fields it needs are located by the WorkloadDefinition.
+type UnstructuredAdapter struct {
+	client.Client
+
+	Scheme     *runtime.Scheme
+	Definition *alpha1.WorkloadDefinition
+}
+
+var _ Adapter = &UnstructuredAdapter{}
+
+// GroupVersionKind returns the GroupVersionKind of the workload.
+func (a *UnstructuredAdapter) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(a.Definition.Spec.APIVersion, a.Definition.Spec.Kind)
+}
+
+// NewResourceObject creates a empty workload object.
+func (a *UnstructuredAdapter) NewResourceObject() runtime.Object {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(a.GroupVersionKind())
+	return obj
+}
+
+// NewResourceListObject creates a empty workload list object.
+func (a *UnstructuredAdapter) NewResourceListObject() runtime.Object {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(a.GroupVersionKind().GroupVersion().WithKind(a.Definition.Spec.Kind + "List"))
+	return list
+}
+
+// GetStatusObservedGeneration returns the observed generation of the pool.
+func (a *UnstructuredAdapter) GetStatusObservedGeneration(obj metav1.Object) int64 {
+	set := obj.(*unstructured.Unstructured)
+	generation, err := nestedInt64(set.Object, fieldPath(a.Definition.Spec.ObservedGenerationPath, alpha1.DefaultObservedGenerationPath))
+	if err != nil {
+		klog.Errorf("fail to get observed generation of %s %s/%s, %v", set.GetKind(), set.GetNamespace(), set.GetName(), err)
+	}
+	return generation
+}
+
+// GetDetails returns the replicas detail the pool needs.
+func (a *UnstructuredAdapter) GetDetails(obj metav1.Object) (ReplicasInfo, error) {
+	set := obj.(*unstructured.Unstructured)
+	spec := a.Definition.Spec
+
+	replicas, err := nestedInt64(set.Object, fieldPath(spec.ReplicasPath, alpha1.DefaultReplicasPath))
+	if err != nil {
+		return ReplicasInfo{}, err
+	}
+	readyReplicas, err := nestedInt64(set.Object, fieldPath(spec.ReadyReplicasPath, alpha1.DefaultReadyReplicasPath))
+	if err != nil {
+		return ReplicasInfo{}, err
+	}
+	updatedReplicas, err := nestedInt64(set.Object, fieldPath(spec.UpdatedReplicasPath, alpha1.DefaultUpdatedReplicasPath))
+	if err != nil {
+		return ReplicasInfo{}, err
+	}
+
+	replicasInfo := ReplicasInfo{
+		Replicas:        int32(replicas),
+		ReadyReplicas:   int32(readyReplicas),
+		UpdatedReplicas: int32(updatedReplicas),
+	}
+	return replicasInfo, nil
+}
+
+// GetPoolFailure returns the failure information of the pool.
+// The conditions of a third-party workload are unknown.
+func (a *UnstructuredAdapter) GetPoolFailure() *string {
+	return nil
+}
+
+// ApplyPoolTemplate updates the pool to the latest revision, depending on the CustomTemplate.
+func (a *UnstructuredAdapter) ApplyPoolTemplate(yas *alpha1.YurtAppSet, poolName, revision string,
+	replicas int32, obj runtime.Object) error {
+	set := obj.(*unstructured.Unstructured)
+	template := yas.Spec.WorkloadTemplate.CustomTemplate
+	if template == nil {
+		return fmt.Errorf("customTemplate of YurtAppSet %s/%s is not set", yas.Namespace, yas.Name)
+	}
+
+	var poolConfig *alpha1.Pool
+	for i, pool := range yas.Spec.Topology.Pools {
+		if pool.Name == poolName {
+			poolConfig = &(yas.Spec.Topology.Pools[i])
+			break
+		}
+	}
+	if poolConfig == nil {
+		return fmt.Errorf("fail to find pool config %s", poolName)
+	}
+
+	set.SetGroupVersionKind(a.GroupVersionKind())
+	set.SetNamespace(yas.Namespace)
+
+	labels := set.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range template.Labels {
+		labels[k] = v
+	}
+	for k, v := range yas.Spec.Selector.MatchLabels {
+		labels[k] = v
+	}
+	labels[alpha1.ControllerRevisionHashLabelKey] = revision
+	// record the pool name as a label
+	labels[alpha1.PoolNameLabelKey] = poolName
+	set.SetLabels(labels)
+
+	annotations := set.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for k, v := range template.Annotations {
+		annotations[k] = v
+	}
+	set.SetAnnotations(annotations)
+
+	set.SetGenerateName(getPoolPrefix(yas.Name, poolName))
+
+	if err := controllerutil.SetControllerReference(yas, set, a.Scheme); err != nil {
+		return err
+	}
+
+	spec := map[string]interface{}{}
+	if len(template.Spec.Raw) > 0 {
+		if err := json.Unmarshal(template.Spec.Raw, &spec); err != nil {
+			return fmt.Errorf("fail to unmarshal customTemplate spec, %v", err)
+		}
+	}
+	set.Object["spec"] = spec
+
+	selectors := yas.Spec.Selector.DeepCopy()
+	selectors.MatchLabels[alpha1.PoolNameLabelKey] = poolName
+	selectorMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(selectors)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedMap(set.Object, selectorMap,
+		fieldPath(a.Definition.Spec.SelectorPath, alpha1.DefaultSelectorPath)...); err != nil {
+		return err
+	}
+
+	if err := unstructured.SetNestedField(set.Object, int64(replicas),
+		fieldPath(a.Definition.Spec.ReplicasPath, alpha1.DefaultReplicasPath)...); err != nil {
+		return err
+	}
+
+	if err := a.applyPodTemplate(set, poolConfig, poolName, revision); err != nil {
+		return err
+	}
+
+	if poolConfig.Patch == nil {
+		// If No Patches, Must Set patches annotation to ""
+		annotations[alpha1.AnnotationPatchKey] = ""
+		set.SetAnnotations(annotations)
+		return nil
+	}
+
+	if err := PatchUnstructuredByPatches(set, poolConfig.PatchType, poolConfig.Patch); err != nil {
+		klog.Errorf("%s[%s/%s-] apply patch %s error %v", set.GetKind(), set.GetNamespace(),
+			set.GetGenerateName(), string(poolConfig.Patch.Raw), err)
+		return err
+	}
+
+	annotations = set.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[alpha1.AnnotationPatchKey] = PatchInfo(poolConfig)
+	set.SetAnnotations(annotations)
+
+	klog.Infof("%s [%s/%s-] has patches configure successfully:%v", set.GetKind(), set.GetNamespace(),
+		set.GetGenerateName(), string(poolConfig.Patch.Raw))
+	return nil
+}
+
+// applyPodTemplate labels the pod template of the workload with the pool and
+// the revision, and attaches the node affinity and tolerations of the pool.
+func (a *UnstructuredAdapter) applyPodTemplate(set *unstructured.Unstructured, poolConfig *alpha1.Pool,
+	poolName, revision string) error {
+	path := fieldPath(a.Definition.Spec.PodTemplatePath, alpha1.DefaultPodTemplatePath)
+	templateMap, _, err := unstructured.NestedMap(set.Object, path...)
+	if err != nil {
+		return err
+	}
+
+	podTemplate := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(templateMap, podTemplate); err != nil {
+		return fmt.Errorf("fail to convert %s to pod template, %v", strings.Join(path, "."), err)
+	}
+	if podTemplate.Labels == nil {
+		podTemplate.Labels = map[string]string{}
+	}
+	podTemplate.Labels[alpha1.PoolNameLabelKey] = poolName
+	podTemplate.Labels[alpha1.ControllerRevisionHashLabelKey] = revision
+
+	attachNodeAffinityAndTolerations(&podTemplate.Spec, poolConfig)
+
+	templateMap, err = runtime.DefaultUnstructuredConverter.ToUnstructured(podTemplate)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(set.Object, templateMap, path...)
+}
+
+// PostUpdate does some works after pool updated.
+func (a *UnstructuredAdapter) PostUpdate(yas *alpha1.YurtAppSet, obj runtime.Object, revision string) error {
+	// Do nothing,
+	return nil
+}
+
+// IsExpected checks the pool is the expected revision or not.
+// The revision label can tell the current pool revision.
+func (a *UnstructuredAdapter) IsExpected(obj metav1.Object, revision string) bool {
+	return obj.GetLabels()[alpha1.ControllerRevisionHashLabelKey] != revision
+}
+
+// ApplyPoolReplicas sets the replicas of the pool and keeps its revision.
+func (a *UnstructuredAdapter) ApplyPoolReplicas(replicas int32, obj runtime.Object) error {
+	set := obj.(*unstructured.Unstructured)
+	return unstructured.SetNestedField(set.Object, int64(replicas),
+		fieldPath(a.Definition.Spec.ReplicasPath, alpha1.DefaultReplicasPath)...)
+}
+
+// fieldPath splits the dot separated path, defaultPath is used if path is empty.
+func fieldPath(path, defaultPath string) []string {
+	if path == "" {
+		path = defaultPath
+	}
+	return strings.Split(strings.Trim(path, "."), ".")
+}
+
+// nestedInt64 returns the integer at the path of obj, a missing field is taken as 0.
+func nestedInt64(obj map[string]interface{}, path []string) (int64, error) {
+	val, found, err := unstructured.NestedFieldNoCopy(obj, path...)
+	if err != nil || !found || val == nil {
+		return 0, err
+	}
+
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("%s accessor error: %v is of the type %T, expected integer", strings.Join(path, "."), val, val)
+	}
+}
+
+// PatchUnstructuredByPatches applies the patch of the patch type to obj in place.
+// The schema of a third-party workload is unknown, so the strategic merge
+// patch is applied as a json merge patch.
+func PatchUnstructuredByPatches(obj *unstructured.Unstructured, patchType alpha1.PatchType, patch *runtime.RawExtension) error {
+	original, err := json.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	patched, err := ApplyJSONPatch(patchType, original, patch.Raw)
+	if err != nil {
+		return err
+	}
+
+	object := map[string]interface{}{}
+	if err := json.Unmarshal(patched, &object); err != nil {
+		return err
+	}
+	obj.Object = object
+	return nil
+}
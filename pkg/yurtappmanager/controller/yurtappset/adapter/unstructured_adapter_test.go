@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakeclint "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newUnstructuredAdapter(spec appsv1alpha1.WorkloadDefinitionSpec) *UnstructuredAdapter {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+	fc := fakeclint.NewClientBuilder().WithScheme(scheme).Build()
+	return &UnstructuredAdapter{
+		Client:     fc,
+		Scheme:     scheme,
+		Definition: &appsv1alpha1.WorkloadDefinition{ObjectMeta: metav1.ObjectMeta{Name: "cloneset"}, Spec: spec},
+	}
+}
+
+func TestUnstructuredAdapter_ApplyPoolTemplate(t *testing.T) {
+	ua := newUnstructuredAdapter(appsv1alpha1.WorkloadDefinitionSpec{
+		APIVersion:      "apps.kruise.io/v1alpha1",
+		Kind:            "CloneSet",
+		PodTemplatePath: "spec.podTemplate",
+	})
+
+	poolTerm := corev1.NodeSelectorRequirement{Key: "node-name", Operator: corev1.NodeSelectorOpIn, Values: []string{"nodeA"}}
+	yas := &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"name": "foo"}},
+			WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
+				CustomTemplate: &appsv1alpha1.CustomTemplateSpec{
+					Definition: "cloneset",
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"name": "foo"}},
+					Spec: runtime.RawExtension{Raw: []byte(`{"updateStrategy":{"type":"InPlaceIfPossible"},` +
+						`"podTemplate":{"metadata":{"labels":{"name":"foo"}},"spec":{"containers":[{"name":"nginx","image":"nginx:1.19"}]}}}`)},
+				},
+			},
+			Topology: appsv1alpha1.Topology{
+				Pools: []appsv1alpha1.Pool{{
+					Name:             "hangzhou",
+					NodeSelectorTerm: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{poolTerm}},
+					Tolerations:      []corev1.Toleration{{Key: "edge", Operator: corev1.TolerationOpExists}},
+					Patch:            &runtime.RawExtension{Raw: []byte(`{"spec":{"updateStrategy":{"type":null,"paused":true}}}`)},
+				}},
+			},
+		},
+	}
+
+	set := ua.NewResourceObject().(*unstructured.Unstructured)
+	if err := ua.ApplyPoolTemplate(yas, "hangzhou", "1", 3, set); err != nil {
+		t.Fatalf("fail to apply pool template, %v", err)
+	}
+
+	if set.GetKind() != "CloneSet" || set.GetAPIVersion() != "apps.kruise.io/v1alpha1" {
+		t.Fatalf("unexpected kind of workload %s", set.GroupVersionKind())
+	}
+	if set.GetGenerateName() != "foo-hangzhou-" || set.GetLabels()[appsv1alpha1.PoolNameLabelKey] != "hangzhou" ||
+		set.GetLabels()[appsv1alpha1.ControllerRevisionHashLabelKey] != "1" {
+		t.Fatalf("unexpected metadata of workload %s: %v", set.GetGenerateName(), set.GetLabels())
+	}
+	if len(set.GetOwnerReferences()) != 1 || set.GetOwnerReferences()[0].Name != "foo" {
+		t.Fatalf("unexpected owner references %v", set.GetOwnerReferences())
+	}
+	if set.GetAnnotations()[appsv1alpha1.AnnotationPatchKey] == "" {
+		t.Fatalf("expect the patch annotation, but get %v", set.GetAnnotations())
+	}
+
+	replicas, _, _ := unstructured.NestedInt64(set.Object, "spec", "replicas")
+	if replicas != 3 {
+		t.Fatalf("expect replicas 3, but get %d", replicas)
+	}
+	expectSelector := map[string]string{"name": "foo", appsv1alpha1.PoolNameLabelKey: "hangzhou"}
+	selector, _, _ := unstructured.NestedStringMap(set.Object, "spec", "selector", "matchLabels")
+	if !reflect.DeepEqual(selector, expectSelector) {
+		t.Fatalf("expect selector %v, but get %v", expectSelector, selector)
+	}
+	strategy, _, _ := unstructured.NestedMap(set.Object, "spec", "updateStrategy")
+	if !reflect.DeepEqual(strategy, map[string]interface{}{"paused": true}) {
+		t.Fatalf("expect the patched update strategy, but get %v", strategy)
+	}
+
+	templateMap, _, _ := unstructured.NestedMap(set.Object, "spec", "podTemplate")
+	podTemplate := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(templateMap, podTemplate); err != nil {
+		t.Fatalf("fail to convert pod template, %v", err)
+	}
+	if podTemplate.Labels[appsv1alpha1.PoolNameLabelKey] != "hangzhou" || podTemplate.Spec.Containers[0].Image != "nginx:1.19" {
+		t.Fatalf("unexpected pod template %v", podTemplate)
+	}
+	terms := podTemplate.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || !reflect.DeepEqual(terms[0].MatchExpressions, []corev1.NodeSelectorRequirement{poolTerm}) {
+		t.Fatalf("expect node affinity of the pool, but get %v", terms)
+	}
+	if len(podTemplate.Spec.Tolerations) != 1 {
+		t.Fatalf("expect tolerations of the pool, but get %v", podTemplate.Spec.Tolerations)
+	}
+}
+
+func TestUnstructuredAdapter_GetDetails(t *testing.T) {
+	ua := newUnstructuredAdapter(appsv1alpha1.WorkloadDefinitionSpec{
+		APIVersion:        "apps.kruise.io/v1alpha1",
+		Kind:              "CloneSet",
+		ReadyReplicasPath: "status.availableReplicas",
+	})
+
+	set := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(3)},
+		"status": map[string]interface{}{
+			"readyReplicas":      int64(3),
+			"availableReplicas":  int64(2),
+			"updatedReplicas":    float64(1),
+			"observedGeneration": int64(4),
+		},
+	}}
+
+	details, err := ua.GetDetails(set)
+	if err != nil {
+		t.Fatalf("fail to get details, %v", err)
+	}
+	expect := ReplicasInfo{Replicas: 3, ReadyReplicas: 2, UpdatedReplicas: 1}
+	if details != expect {
+		t.Fatalf("expect details %v, but get %v", expect, details)
+	}
+	if generation := ua.GetStatusObservedGeneration(set); generation != 4 {
+		t.Fatalf("expect observed generation 4, but get %d", generation)
+	}
+
+	if err := unstructured.SetNestedField(set.Object, "three", "spec", "replicas"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ua.GetDetails(set); err == nil {
+		t.Fatalf("expect error for the replicas which is not an integer")
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"sort"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// poolAllocation records the replicas allocated to a weighted or elastic pool
+type poolAllocation struct {
+	name     string
+	replicas int32
+	max      *int32
+	weight   int64
+}
+
+// saturated checks if the replicas of the pool have reached its upper bound
+func (a *poolAllocation) saturated() bool {
+	return a.max != nil && a.replicas >= *a.max
+}
+
+// allocatePoolReplicas distributes the replicas of the YurtAppSet between
+// its pools. If the replicas of the YurtAppSet is not set, each pool gets
+// its own replicas. Otherwise, the pools are allocated in the order of:
+//  1. the pools with fixed replicas,
+//  2. the pools with percentage of the YurtAppSet replicas,
+//  3. the weighted pools share the rest replicas on top of their lower bounds,
+//  4. the elastic pool takes the replicas that can not be placed in the
+//     weighted pools because of their upper bounds.
+//
+// The replicas of every pool are kept in its bounds. Ties are broken by the
+// pool name, so that the same YurtAppSet always gets the same result. The
+// autoscaled pools take no share of the replicas of the YurtAppSet, their
+// replicas are owned by their HorizontalPodAutoscalers.
+func allocatePoolReplicas(yas *unitv1alpha1.YurtAppSet) map[string]int32 {
+	result := make(map[string]int32)
+	if yas.Spec.Replicas == nil {
+		for _, pool := range yas.Spec.Topology.Pools {
+			result[pool.Name] = 0
+			if pool.Replicas != nil {
+				result[pool.Name] = *pool.Replicas
+			}
+		}
+		return result
+	}
+
+	pools := make([]unitv1alpha1.Pool, len(yas.Spec.Topology.Pools))
+	copy(pools, yas.Spec.Topology.Pools)
+	sort.SliceStable(pools, func(i, j int) bool {
+		return pools[i].Name < pools[j].Name
+	})
+
+	total := *yas.Spec.Replicas
+	rest := total
+	var weighted []*poolAllocation
+	var elastic *poolAllocation
+	for _, pool := range pools {
+		if getPoolAutoscaler(yas, pool.Name) != nil {
+			result[pool.Name] = 0
+			continue
+		}
+		switch {
+		case pool.Replicas != nil:
+			result[pool.Name] = boundReplicas(*pool.Replicas, &pool)
+		case pool.Percentage != nil:
+			result[pool.Name] = boundReplicas(int32(int64(total)*int64(*pool.Percentage)/100), &pool)
+		default:
+			a := &poolAllocation{
+				name:     pool.Name,
+				replicas: boundReplicas(0, &pool),
+				max:      pool.MaxReplicas,
+				weight:   1,
+			}
+			if pool.Weight != nil {
+				a.weight = int64(*pool.Weight)
+			}
+			if pool.Elastic {
+				elastic = a
+			} else {
+				weighted = append(weighted, a)
+			}
+			result[pool.Name] = a.replicas
+		}
+		rest -= result[pool.Name]
+	}
+
+	rest = distributeByWeight(weighted, rest)
+	if elastic != nil && rest > 0 {
+		add := rest
+		if elastic.max != nil && elastic.replicas+add > *elastic.max {
+			add = *elastic.max - elastic.replicas
+		}
+		elastic.replicas += add
+	}
+
+	for _, a := range weighted {
+		result[a.name] = a.replicas
+	}
+	if elastic != nil {
+		result[elastic.name] = elastic.replicas
+	}
+	return result
+}
+
+// distributeByWeight distributes the `rest` replicas between the pools in
+// proportion to their weights with the largest remainder method, the
+// replicas exceeding the upper bound of a pool are redistributed between the
+// other pools. It returns the replicas that can not be placed.
+func distributeByWeight(allocs []*poolAllocation, rest int32) int32 {
+	for rest > 0 {
+		var candidates []*poolAllocation
+		var sumWeight int64
+		for _, a := range allocs {
+			if a.weight > 0 && !a.saturated() {
+				candidates = append(candidates, a)
+				sumWeight += a.weight
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		shares := make([]int64, len(candidates))
+		remainders := make([]int64, len(candidates))
+		left := int64(rest)
+		for i, a := range candidates {
+			shares[i] = int64(rest) * a.weight / sumWeight
+			remainders[i] = int64(rest) * a.weight % sumWeight
+			left -= shares[i]
+		}
+		order := make([]int, len(candidates))
+		for i := range order {
+			order[i] = i
+		}
+		// candidates are sorted by name, so the stable sort keeps the name
+		// order between the pools with the same remainder
+		sort.SliceStable(order, func(i, j int) bool {
+			return remainders[order[i]] > remainders[order[j]]
+		})
+		for i := int64(0); i < left; i++ {
+			shares[order[i]]++
+		}
+
+		var placed int32
+		for i, a := range candidates {
+			share := int32(shares[i])
+			if a.max != nil && a.replicas+share > *a.max {
+				share = *a.max - a.replicas
+			}
+			a.replicas += share
+			placed += share
+		}
+		if placed == 0 {
+			break
+		}
+		rest -= placed
+	}
+	return rest
+}
+
+// boundReplicas keeps the replicas in the bounds of the pool
+func boundReplicas(replicas int32, pool *unitv1alpha1.Pool) int32 {
+	if pool.MinReplicas != nil && replicas < *pool.MinReplicas {
+		replicas = *pool.MinReplicas
+	}
+	if pool.MaxReplicas != nil && replicas > *pool.MaxReplicas {
+		replicas = *pool.MaxReplicas
+	}
+	return replicas
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"reflect"
+	"testing"
+
+	utilpointer "k8s.io/utils/pointer"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+const (
+	failed  = "\u2717"
+	succeed = "\u2713"
+)
+
+func TestAllocatePoolReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas *int32
+		pools    []appsv1alpha1.Pool
+		expect   map[string]int32
+	}{
+		{
+			"replicas of yurtappset is not set",
+			nil,
+			[]appsv1alpha1.Pool{
+				{Name: "a", Replicas: utilpointer.Int32Ptr(2)},
+				{Name: "b", Weight: utilpointer.Int32Ptr(1)},
+			},
+			map[string]int32{"a": 2, "b": 0},
+		},
+		{
+			"fixed and percentage pools",
+			utilpointer.Int32Ptr(10),
+			[]appsv1alpha1.Pool{
+				{Name: "a", Replicas: utilpointer.Int32Ptr(2)},
+				{Name: "b", Percentage: utilpointer.Int32Ptr(35)},
+				{Name: "c"},
+			},
+			map[string]int32{"a": 2, "b": 3, "c": 5},
+		},
+		{
+			"weighted pools share the rest replicas",
+			utilpointer.Int32Ptr(10),
+			[]appsv1alpha1.Pool{
+				{Name: "c", Weight: utilpointer.Int32Ptr(1)},
+				{Name: "a", Weight: utilpointer.Int32Ptr(1)},
+				{Name: "b", Weight: utilpointer.Int32Ptr(1)},
+			},
+			map[string]int32{"a": 4, "b": 3, "c": 3},
+		},
+		{
+			"weighted pools with bounds",
+			utilpointer.Int32Ptr(10),
+			[]appsv1alpha1.Pool{
+				{Name: "a", Weight: utilpointer.Int32Ptr(3), MaxReplicas: utilpointer.Int32Ptr(4)},
+				{Name: "b", Weight: utilpointer.Int32Ptr(1), MinReplicas: utilpointer.Int32Ptr(2)},
+			},
+			map[string]int32{"a": 4, "b": 6},
+		},
+		{
+			"elastic pool takes the overflow",
+			utilpointer.Int32Ptr(10),
+			[]appsv1alpha1.Pool{
+				{Name: "a", Weight: utilpointer.Int32Ptr(1), MaxReplicas: utilpointer.Int32Ptr(3)},
+				{Name: "b", Weight: utilpointer.Int32Ptr(1), MaxReplicas: utilpointer.Int32Ptr(3)},
+				{Name: "cloud", Elastic: true, MinReplicas: utilpointer.Int32Ptr(1)},
+			},
+			map[string]int32{"a": 3, "b": 3, "cloud": 4},
+		},
+		{
+			"elastic pool with upper bound",
+			utilpointer.Int32Ptr(10),
+			[]appsv1alpha1.Pool{
+				{Name: "a", Replicas: utilpointer.Int32Ptr(2)},
+				{Name: "cloud", Elastic: true, MaxReplicas: utilpointer.Int32Ptr(5)},
+			},
+			map[string]int32{"a": 2, "cloud": 5},
+		},
+		{
+			"autoscaled pools take no share",
+			utilpointer.Int32Ptr(10),
+			[]appsv1alpha1.Pool{
+				{Name: "a", Weight: utilpointer.Int32Ptr(1)},
+				{Name: "b", Weight: utilpointer.Int32Ptr(1), Autoscaler: &appsv1alpha1.PoolAutoscaler{MaxReplicas: 5}},
+				{Name: "c", Percentage: utilpointer.Int32Ptr(50), Autoscaler: &appsv1alpha1.PoolAutoscaler{MaxReplicas: 5}},
+			},
+			map[string]int32{"a": 10, "b": 0, "c": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yas := &appsv1alpha1.YurtAppSet{
+					Spec: appsv1alpha1.YurtAppSetSpec{
+						Replicas: st.replicas,
+						Topology: appsv1alpha1.Topology{Pools: st.pools},
+					},
+				}
+				get := allocatePoolReplicas(yas)
+				if !reflect.DeepEqual(get, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				// the result is deterministic
+				if again := allocatePoolReplicas(yas); !reflect.DeepEqual(again, get) {
+					t.Fatalf("\t%s\texpect the same result %v, but get %v", failed, get, again)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
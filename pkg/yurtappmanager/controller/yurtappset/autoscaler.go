@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+	autoscalingv2beta2defaults "k8s.io/kubernetes/pkg/apis/autoscaling/v2beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// getPoolAutoscaler returns the autoscaler of the pool, nil if the pool is
+// not autoscaled. The pools of DaemonSet can not be scaled.
+func getPoolAutoscaler(yas *unitv1alpha1.YurtAppSet, poolName string) *unitv1alpha1.PoolAutoscaler {
+	if yas.Spec.WorkloadTemplate.DaemonSetTemplate != nil {
+		return nil
+	}
+	for _, pool := range yas.Spec.Topology.Pools {
+		if pool.Name == poolName {
+			return pool.Autoscaler
+		}
+	}
+	return nil
+}
+
+// respectAutoscaledReplicas keeps the replicas of the autoscaled pools decided
+// by their HorizontalPodAutoscalers, so that the pools are not scaled back by
+// UpdatePool. The pools to be created start with their own replicas bounded
+// by their autoscalers, as they take no share of the YurtAppSet replicas.
+func respectAutoscaledReplicas(yas *unitv1alpha1.YurtAppSet, nameToPool map[string]*Pool,
+	nextPatches map[string]YurtAppSetPatches) {
+	for name, patches := range nextPatches {
+		autoscaler := getPoolAutoscaler(yas, name)
+		if autoscaler == nil {
+			continue
+		}
+
+		if pool, exist := nameToPool[name]; exist {
+			patches.Replicas = pool.Status.Replicas
+		} else {
+			minReplicas := int32(1)
+			if autoscaler.MinReplicas != nil {
+				minReplicas = *autoscaler.MinReplicas
+			}
+			if patches.Replicas < minReplicas {
+				patches.Replicas = minReplicas
+			}
+			if patches.Replicas > autoscaler.MaxReplicas {
+				patches.Replicas = autoscaler.MaxReplicas
+			}
+		}
+		nextPatches[name] = patches
+	}
+}
+
+// manageAutoscalers creates or updates the HorizontalPodAutoscalers of the
+// autoscaled pools, and deletes the ones of the pools which are removed or
+// no longer autoscaled. The autoscaler is named after the pool workload.
+func (r *ReconcileYurtAppSet) manageAutoscalers(yas *unitv1alpha1.YurtAppSet, nameToPool map[string]*Pool) error {
+	expected := make(map[string]*autoscalingv2beta2.HorizontalPodAutoscaler)
+	for name, pool := range nameToPool {
+		autoscaler := getPoolAutoscaler(yas, name)
+		if autoscaler == nil {
+			continue
+		}
+		hpa, err := r.newPoolAutoscaler(yas, pool, autoscaler)
+		if err != nil {
+			return err
+		}
+		expected[hpa.Name] = hpa
+	}
+
+	hpaList := &autoscalingv2beta2.HorizontalPodAutoscalerList{}
+	if err := r.List(context.TODO(), hpaList, client.InNamespace(yas.Namespace)); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range hpaList.Items {
+		hpa := &hpaList.Items[i]
+		if !metav1.IsControlledBy(hpa, yas) {
+			continue
+		}
+
+		desired, exist := expected[hpa.Name]
+		if !exist {
+			klog.Infof("YurtAppSet %s/%s deletes HorizontalPodAutoscaler %s", yas.Namespace, yas.Name, hpa.Name)
+			if err := r.Delete(context.TODO(), hpa); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("fail to delete HorizontalPodAutoscaler %s: %v", hpa.Name, err))
+			}
+			continue
+		}
+		delete(expected, hpa.Name)
+
+		if apiequality.Semantic.DeepEqual(hpa.Spec, desired.Spec) &&
+			apiequality.Semantic.DeepEqual(hpa.Labels, desired.Labels) {
+			continue
+		}
+		hpa.Labels = desired.Labels
+		hpa.Spec = desired.Spec
+		klog.Infof("YurtAppSet %s/%s updates HorizontalPodAutoscaler %s", yas.Namespace, yas.Name, hpa.Name)
+		if err := r.Update(context.TODO(), hpa); err != nil {
+			errs = append(errs, fmt.Errorf("fail to update HorizontalPodAutoscaler %s: %v", hpa.Name, err))
+		}
+	}
+
+	for _, hpa := range expected {
+		klog.Infof("YurtAppSet %s/%s creates HorizontalPodAutoscaler %s", yas.Namespace, yas.Name, hpa.Name)
+		if err := r.Create(context.TODO(), hpa); err != nil && !errors.IsAlreadyExists(err) {
+			errs = append(errs, fmt.Errorf("fail to create HorizontalPodAutoscaler %s: %v", hpa.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// newPoolAutoscaler returns the HorizontalPodAutoscaler which scales the workload of the pool.
+func (r *ReconcileYurtAppSet) newPoolAutoscaler(yas *unitv1alpha1.YurtAppSet, pool *Pool,
+	autoscaler *unitv1alpha1.PoolAutoscaler) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+	workload, ok := pool.Spec.PoolRef.(runtime.Object)
+	if !ok {
+		return nil, fmt.Errorf("fail to convert pool %s to runtime.Object", pool.Name)
+	}
+	gvk, err := apiutil.GVKForObject(workload, r.scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	autoscaler = autoscaler.DeepCopy()
+	labels := map[string]string{}
+	for k, v := range yas.Spec.Selector.MatchLabels {
+		labels[k] = v
+	}
+	labels[unitv1alpha1.PoolNameLabelKey] = pool.Name
+
+	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pool.Spec.PoolRef.GetName(),
+			Namespace: yas.Namespace,
+			Labels:    labels,
+		},
+		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Name:       pool.Spec.PoolRef.GetName(),
+			},
+			MinReplicas: autoscaler.MinReplicas,
+			MaxReplicas: autoscaler.MaxReplicas,
+			Metrics:     autoscaler.Metrics,
+			Behavior:    autoscaler.Behavior,
+		},
+	}
+	// fill the defaults as the apiserver does, so that the autoscaler is not
+	// updated again and again because of the defaulted fields
+	autoscalingv2beta2defaults.SetObjectDefaults_HorizontalPodAutoscaler(hpa)
+	if err := controllerutil.SetControllerReference(yas, hpa, r.scheme); err != nil {
+		return nil, err
+	}
+	return hpa, nil
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
+)
+
+func newAutoscaledYurtAppSet() *appsv1alpha1.YurtAppSet {
+	return &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "foo-uid"},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+			WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
+				DeploymentTemplate: &appsv1alpha1.DeploymentTemplateSpec{},
+			},
+			Topology: appsv1alpha1.Topology{
+				Pools: []appsv1alpha1.Pool{
+					{
+						Name:       "hangzhou",
+						Autoscaler: &appsv1alpha1.PoolAutoscaler{MinReplicas: utilpointer.Int32Ptr(2), MaxReplicas: 5},
+					},
+					{
+						Name:       "shanghai",
+						Autoscaler: &appsv1alpha1.PoolAutoscaler{MaxReplicas: 5},
+					},
+					{Name: "beijing", Replicas: utilpointer.Int32Ptr(3)},
+				},
+			},
+		},
+	}
+}
+
+func newDeploymentPool(poolName, workloadName string, replicas int32) *Pool {
+	return &Pool{
+		Name:      poolName,
+		Namespace: "default",
+		Spec: PoolSpec{
+			PoolRef: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: workloadName, Namespace: "default"}},
+		},
+		Status: PoolStatus{ReplicasInfo: adapter.ReplicasInfo{Replicas: replicas}},
+	}
+}
+
+func TestRespectAutoscaledReplicas(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    appsv1alpha1.WorkloadTemplate
+		nameToPool  map[string]*Pool
+		nextPatches map[string]YurtAppSetPatches
+		expect      map[string]int32
+	}{
+		{
+			"keep the replicas decided by the autoscaler",
+			appsv1alpha1.WorkloadTemplate{DeploymentTemplate: &appsv1alpha1.DeploymentTemplateSpec{}},
+			map[string]*Pool{
+				"hangzhou": newDeploymentPool("hangzhou", "foo-hangzhou-abcde", 4),
+				"shanghai": newDeploymentPool("shanghai", "foo-shanghai-abcde", 1),
+				"beijing":  newDeploymentPool("beijing", "foo-beijing-abcde", 1),
+			},
+			map[string]YurtAppSetPatches{"hangzhou": {Replicas: 1}, "shanghai": {Replicas: 1}, "beijing": {Replicas: 3}},
+			map[string]int32{"hangzhou": 4, "shanghai": 1, "beijing": 3},
+		},
+		{
+			"bound the replicas of the pools to be created",
+			appsv1alpha1.WorkloadTemplate{DeploymentTemplate: &appsv1alpha1.DeploymentTemplateSpec{}},
+			map[string]*Pool{},
+			map[string]YurtAppSetPatches{"hangzhou": {Replicas: 1}, "shanghai": {Replicas: 8}, "beijing": {Replicas: 3}},
+			map[string]int32{"hangzhou": 2, "shanghai": 5, "beijing": 3},
+		},
+		{
+			"daemonset pools are not autoscaled",
+			appsv1alpha1.WorkloadTemplate{DaemonSetTemplate: &appsv1alpha1.DaemonSetTemplateSpec{}},
+			map[string]*Pool{"hangzhou": newDeploymentPool("hangzhou", "foo-hangzhou-abcde", 4)},
+			map[string]YurtAppSetPatches{"hangzhou": {Replicas: 0}, "shanghai": {Replicas: 0}, "beijing": {Replicas: 0}},
+			map[string]int32{"hangzhou": 0, "shanghai": 0, "beijing": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yas := newAutoscaledYurtAppSet()
+				yas.Spec.WorkloadTemplate = st.template
+				respectAutoscaledReplicas(yas, st.nameToPool, st.nextPatches)
+				for name, replicas := range st.expect {
+					if st.nextPatches[name].Replicas != replicas {
+						t.Fatalf("\t%s\texpect pool %s replicas %d, but get %d", failed, name, replicas, st.nextPatches[name].Replicas)
+					}
+				}
+				t.Logf("\t%s\tget %v", succeed, st.nextPatches)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestManageAutoscalers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	clientgoscheme.AddToScheme(scheme)
+
+	yas := newAutoscaledYurtAppSet()
+	outdated := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-hangzhou-abcde", Namespace: "default"},
+		Spec:       autoscalingv2beta2.HorizontalPodAutoscalerSpec{MaxReplicas: 10},
+	}
+	stale := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-beijing-abcde", Namespace: "default"},
+		Spec:       autoscalingv2beta2.HorizontalPodAutoscalerSpec{MaxReplicas: 10},
+	}
+	for _, hpa := range []*autoscalingv2beta2.HorizontalPodAutoscaler{outdated, stale} {
+		if err := controllerutil.SetControllerReference(yas, hpa, scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unowned := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"},
+		Spec:       autoscalingv2beta2.HorizontalPodAutoscalerSpec{MaxReplicas: 10},
+	}
+
+	r := &ReconcileYurtAppSet{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(outdated, stale, unowned).Build(),
+		scheme: scheme,
+	}
+	nameToPool := map[string]*Pool{
+		"hangzhou": newDeploymentPool("hangzhou", "foo-hangzhou-abcde", 2),
+		"shanghai": newDeploymentPool("shanghai", "foo-shanghai-abcde", 1),
+		"beijing":  newDeploymentPool("beijing", "foo-beijing-abcde", 3),
+	}
+	if err := r.manageAutoscalers(yas, nameToPool); err != nil {
+		t.Fatalf("fail to manage autoscalers, %v", err)
+	}
+
+	hangzhou := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo-hangzhou-abcde"}, hangzhou); err != nil {
+		t.Fatal(err)
+	}
+	if hangzhou.Spec.MaxReplicas != 5 || *hangzhou.Spec.MinReplicas != 2 ||
+		hangzhou.Spec.ScaleTargetRef != (autoscalingv2beta2.CrossVersionObjectReference{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "foo-hangzhou-abcde"}) {
+		t.Fatalf("\t%s\tunexpected autoscaler spec %v", failed, hangzhou.Spec)
+	}
+	if hangzhou.Labels[appsv1alpha1.PoolNameLabelKey] != "hangzhou" {
+		t.Fatalf("\t%s\tunexpected autoscaler labels %v", failed, hangzhou.Labels)
+	}
+
+	shanghai := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo-shanghai-abcde"}, shanghai); err != nil {
+		t.Fatal(err)
+	}
+	if !metav1.IsControlledBy(shanghai, yas) || *shanghai.Spec.MinReplicas != 1 || len(shanghai.Spec.Metrics) != 1 {
+		t.Fatalf("\t%s\texpect defaulted autoscaler owned by YurtAppSet, but get %v", failed, shanghai)
+	}
+
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo-beijing-abcde"},
+		&autoscalingv2beta2.HorizontalPodAutoscaler{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect the autoscaler of the pool without autoscaler deleted, but get %v", failed, err)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "bar"},
+		&autoscalingv2beta2.HorizontalPodAutoscaler{}); err != nil {
+		t.Fatalf("\t%s\texpect the autoscaler not owned by YurtAppSet kept, but get %v", failed, err)
+	}
+
+	// the autoscalers are not updated again when nothing changes
+	if err := r.manageAutoscalers(yas, nameToPool); err != nil {
+		t.Fatalf("fail to manage autoscalers, %v", err)
+	}
+	again := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo-shanghai-abcde"}, again); err != nil {
+		t.Fatal(err)
+	}
+	if again.ResourceVersion != shanghai.ResourceVersion {
+		t.Fatalf("\t%s\texpect autoscaler not updated, but resource version changed from %s to %s",
+			failed, shanghai.ResourceVersion, again.ResourceVersion)
+	}
+	t.Logf("\t%s\tmanage autoscalers", succeed)
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
+)
+
+// getCustomPoolControl returns the PoolControl of the workload kind registered
+// by the WorkloadDefinition, and makes sure the workloads of the kind are watched.
+func (r *ReconcileYurtAppSet) getCustomPoolControl(definitionName string) (ControlInterface, error) {
+	workloadAdapter, err := r.getCustomWorkloadAdapter(definitionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.watchCustomWorkload(workloadAdapter.GroupVersionKind()); err != nil {
+		return nil, fmt.Errorf("fail to watch %s, %v", workloadAdapter.GroupVersionKind(), err)
+	}
+	return &PoolControl{Client: r.Client, scheme: r.scheme, adapter: workloadAdapter}, nil
+}
+
+// getCustomWorkloadAdapter returns the adapter of the workload kind registered
+// by the WorkloadDefinition.
+func (r *ReconcileYurtAppSet) getCustomWorkloadAdapter(definitionName string) (*adapter.UnstructuredAdapter, error) {
+	definition := &unitv1alpha1.WorkloadDefinition{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Name: definitionName}, definition); err != nil {
+		return nil, fmt.Errorf("fail to get WorkloadDefinition %s, %v", definitionName, err)
+	}
+	if definition.Spec.APIVersion == "" || definition.Spec.Kind == "" {
+		return nil, fmt.Errorf("WorkloadDefinition %s should provide both apiVersion and kind", definitionName)
+	}
+	return &adapter.UnstructuredAdapter{Client: r.Client, Scheme: r.scheme, Definition: definition}, nil
+}
+
+// getPreviousCustomPoolControls returns the PoolControls of the WorkloadDefinitions
+// referenced by the revisions of the YurtAppSet except the current template, so
+// that the custom workloads are cleaned once the template is changed.
+func (r *ReconcileYurtAppSet) getPreviousCustomPoolControls(yas *unitv1alpha1.YurtAppSet) (map[string]ControlInterface, error) {
+	revisions, err := r.controlledHistories(yas)
+	if err != nil {
+		return nil, err
+	}
+
+	var current string
+	if yas.Spec.WorkloadTemplate.CustomTemplate != nil {
+		current = yas.Spec.WorkloadTemplate.CustomTemplate.Definition
+	}
+	controls := map[string]ControlInterface{}
+	for _, revision := range revisions {
+		definition := getRevisionCustomDefinition(revision)
+		if definition == "" || definition == current {
+			continue
+		}
+		if _, exist := controls[definition]; exist {
+			continue
+		}
+		// the previous kinds are only cleaned, so they are not watched
+		workloadAdapter, err := r.getCustomWorkloadAdapter(definition)
+		if err != nil {
+			// the workloads of the kind can not be found without the definition
+			klog.Warningf("skip cleaning the pools of YurtAppSet %s/%s for WorkloadDefinition %s, %v",
+				yas.Namespace, yas.Name, definition, err)
+			continue
+		}
+		controls[definition] = &PoolControl{Client: r.Client, scheme: r.scheme, adapter: workloadAdapter}
+	}
+	return controls, nil
+}
+
+// getPoolControlGroupKind returns the GroupKind of the workloads managed by the PoolControl.
+func (r *ReconcileYurtAppSet) getPoolControlGroupKind(control ControlInterface) (schema.GroupKind, error) {
+	poolControl, ok := control.(*PoolControl)
+	if !ok {
+		return schema.GroupKind{}, fmt.Errorf("unknown pool control %T", control)
+	}
+	gvk, err := apiutil.GVKForObject(poolControl.adapter.NewResourceObject(), r.scheme)
+	if err != nil {
+		return schema.GroupKind{}, err
+	}
+	return gvk.GroupKind(), nil
+}
+
+// getRevisionCustomDefinition returns the WorkloadDefinition of the custom template
+// recorded in the revision, empty if the revision is not of a custom template.
+func getRevisionCustomDefinition(revision *appsv1.ControllerRevision) string {
+	yas := &unitv1alpha1.YurtAppSet{}
+	if err := json.Unmarshal(revision.Data.Raw, yas); err != nil {
+		klog.Errorf("fail to decode ControllerRevision %s/%s, %v", revision.Namespace, revision.Name, err)
+		return ""
+	}
+	if yas.Spec.WorkloadTemplate.CustomTemplate == nil {
+		return ""
+	}
+	return yas.Spec.WorkloadTemplate.CustomTemplate.Definition
+}
+
+// watchCustomWorkload watches the workloads of the kind, so that the owner
+// YurtAppSets are reconciled when their pools change. The kinds are only known
+// when they are referenced, so the watches are added after the controller started.
+func (r *ReconcileYurtAppSet) watchCustomWorkload(gvk schema.GroupVersionKind) error {
+	if r.controller == nil {
+		return nil
+	}
+
+	r.watchLock.Lock()
+	defer r.watchLock.Unlock()
+	if r.watchedKinds.Has(gvk.String()) {
+		return nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := r.controller.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &unitv1alpha1.YurtAppSet{},
+	}); err != nil {
+		return err
+	}
+	klog.Infof("start to watch workload %s for YurtAppSet", gvk)
+	r.watchedKinds.Insert(gvk.String())
+	return nil
+}
+
+// enqueueYurtAppSetsForDefinition returns the YurtAppSets which distribute
+// the workload kind registered by the WorkloadDefinition.
+func enqueueYurtAppSetsForDefinition(c client.Client) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		yasList := &unitv1alpha1.YurtAppSetList{}
+		if err := c.List(context.TODO(), yasList); err != nil {
+			klog.Errorf("fail to list YurtAppSet for WorkloadDefinition %s, %v", obj.GetName(), err)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, yas := range yasList.Items {
+			template := yas.Spec.WorkloadTemplate.CustomTemplate
+			if template == nil || template.Definition != obj.GetName() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: yas.Namespace, Name: yas.Name},
+			})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
+)
+
+func newWorkloadDefinition(name, apiVersion, kind string) *appsv1alpha1.WorkloadDefinition {
+	return &appsv1alpha1.WorkloadDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       appsv1alpha1.WorkloadDefinitionSpec{APIVersion: apiVersion, Kind: kind},
+	}
+}
+
+func newCustomYurtAppSet(name, definition string) *appsv1alpha1.YurtAppSet {
+	return &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
+				CustomTemplate: &appsv1alpha1.CustomTemplateSpec{Definition: definition},
+			},
+		},
+	}
+}
+
+func TestGetCustomPoolControl(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	r := &ReconcileYurtAppSet{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				newWorkloadDefinition("cloneset", "apps.kruise.io/v1alpha1", "CloneSet"),
+				newWorkloadDefinition("nokind", "apps.kruise.io/v1alpha1", "")).
+			Build(),
+		scheme: scheme,
+	}
+
+	tests := []struct {
+		name       string
+		definition string
+		expectGVK  schema.GroupVersionKind
+		expectErr  bool
+	}{
+		{
+			"registered workload",
+			"cloneset",
+			schema.GroupVersionKind{Group: "apps.kruise.io", Version: "v1alpha1", Kind: "CloneSet"},
+			false,
+		},
+		{
+			"definition not found",
+			"advancedstatefulset",
+			schema.GroupVersionKind{},
+			true,
+		},
+		{
+			"definition without kind",
+			"nokind",
+			schema.GroupVersionKind{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				control, err := r.getCustomPoolControl(st.definition)
+				if (err != nil) != st.expectErr {
+					t.Fatalf("\t%s\texpect error %v, but get %v", failed, st.expectErr, err)
+				}
+				if err != nil {
+					return
+				}
+				gvk := control.(*PoolControl).adapter.(*adapter.UnstructuredAdapter).GroupVersionKind()
+				if gvk != st.expectGVK {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expectGVK, gvk)
+				}
+				t.Logf("\t%s\tget %v", succeed, gvk)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestEnqueueYurtAppSetsForDefinition(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			newCustomYurtAppSet("foo", "cloneset"),
+			newCustomYurtAppSet("bar", "advancedstatefulset"),
+			&appsv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: "default"}}).
+		Build()
+
+	requests := enqueueYurtAppSetsForDefinition(c)(newWorkloadDefinition("cloneset", "apps.kruise.io/v1alpha1", "CloneSet"))
+	expect := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "foo"}}}
+	if !reflect.DeepEqual(requests, expect) {
+		t.Fatalf("\t%s\texpect %v, but get %v", failed, expect, requests)
+	}
+}
+
+func TestCleanPreviousCustomPools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	appsv1alpha1.AddToScheme(scheme)
+
+	tests := []struct {
+		name          string
+		workloadType  appsv1alpha1.TemplateType
+		definition    string
+		expectCleaned bool
+	}{
+		{
+			"template is changed to a built-in type",
+			appsv1alpha1.DeploymentTemplateType,
+			"",
+			true,
+		},
+		{
+			"template is changed to another definition",
+			appsv1alpha1.CustomTemplateType,
+			"statefulset",
+			true,
+		},
+		{
+			"template is changed to another definition of the same kind",
+			appsv1alpha1.CustomTemplateType,
+			"replicaset-v2",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yas := &appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "yas", Namespace: "default", UID: "yas-uid"},
+					Spec: appsv1alpha1.YurtAppSetSpec{
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "demo"}},
+					},
+				}
+				if st.definition != "" {
+					yas.Spec.WorkloadTemplate.CustomTemplate = &appsv1alpha1.CustomTemplateSpec{Definition: st.definition}
+				}
+				controller := true
+				ownerRefs := []metav1.OwnerReference{{
+					APIVersion: "apps.openyurt.io/v1alpha1",
+					Kind:       "YurtAppSet",
+					Name:       yas.Name,
+					UID:        yas.UID,
+					Controller: &controller,
+				}}
+				// the revision of the previous custom template
+				revision := &appsv1.ControllerRevision{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "yas-custom",
+						Namespace:       "default",
+						Labels:          map[string]string{"app": "demo"},
+						OwnerReferences: ownerRefs,
+					},
+					Data: runtime.RawExtension{
+						Raw: []byte(`{"spec":{"workloadTemplate":{"customTemplate":{"definition":"replicaset"},"$patch":"replace"}}}`),
+					},
+				}
+				workload := &appsv1.ReplicaSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "yas-hangzhou",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app":                         "demo",
+							appsv1alpha1.PoolNameLabelKey: "hangzhou",
+						},
+						OwnerReferences: ownerRefs,
+					},
+				}
+				cl := fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(yas.DeepCopy(), revision, workload,
+						newWorkloadDefinition("replicaset", "apps/v1", "ReplicaSet"),
+						newWorkloadDefinition("replicaset-v2", "apps/v1", "ReplicaSet"),
+						newWorkloadDefinition("statefulset", "apps/v1", "StatefulSet")).
+					Build()
+				r := &ReconcileYurtAppSet{
+					Client:   cl,
+					scheme:   scheme,
+					recorder: record.NewFakeRecorder(10),
+					poolControls: map[appsv1alpha1.TemplateType]ControlInterface{
+						appsv1alpha1.StatefulSetTemplateType: &PoolControl{Client: cl, scheme: scheme,
+							adapter: &adapter.StatefulSetAdapter{Client: cl, Scheme: scheme}},
+						appsv1alpha1.DeploymentTemplateType: &PoolControl{Client: cl, scheme: scheme,
+							adapter: &adapter.DeploymentAdapter{Client: cl, Scheme: scheme}},
+					},
+				}
+
+				control := r.poolControls[st.workloadType]
+				if st.definition != "" {
+					var err error
+					if control, err = r.getCustomPoolControl(st.definition); err != nil {
+						t.Fatalf("\t%s\tfail to get custom pool control, %v", failed, err)
+					}
+				}
+				_, cleaned, err := r.managePoolProvision(yas, map[string]*Pool{}, map[string]YurtAppSetPatches{},
+					&appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "yas-current"}},
+					control, st.workloadType)
+				if err != nil {
+					t.Fatalf("\t%s\tfail to manage pool provision, %v", failed, err)
+				}
+				if cleaned != st.expectCleaned {
+					t.Fatalf("\t%s\texpect cleaned %v, but get %v", failed, st.expectCleaned, cleaned)
+				}
+				err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: workload.Name}, &appsv1.ReplicaSet{})
+				if apierrors.IsNotFound(err) != st.expectCleaned {
+					t.Fatalf("\t%s\texpect the custom workload cleaned %v, but get %v", failed, st.expectCleaned, err)
+				}
+				t.Logf("\t%s\texpect cleaned %v, get %v", succeed, st.expectCleaned, cleaned)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
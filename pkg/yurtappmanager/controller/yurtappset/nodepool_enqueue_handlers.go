@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// EnqueueYurtAppSetForNodePool enqueues the YurtAppSets whose nodepool
+// selector matches the nodepool
+type EnqueueYurtAppSetForNodePool struct {
+	client client.Client
+}
+
+// Create implements EventHandler
+func (e *EnqueueYurtAppSetForNodePool) Create(evt event.CreateEvent,
+	q workqueue.RateLimitingInterface) {
+	e.addSelectingYurtAppSetsToWorkQueue(q, evt.Object.GetLabels())
+}
+
+// Update implements EventHandler
+func (e *EnqueueYurtAppSetForNodePool) Update(evt event.UpdateEvent,
+	q workqueue.RateLimitingInterface) {
+	oldNp, ok := evt.ObjectOld.(*unitv1alpha1.NodePool)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to NodePool", evt.ObjectOld.GetName())
+		return
+	}
+	newNp, ok := evt.ObjectNew.(*unitv1alpha1.NodePool)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to NodePool", evt.ObjectNew.GetName())
+		return
+	}
+	if reflect.DeepEqual(oldNp.GetLabels(), newNp.GetLabels()) &&
+		reflect.DeepEqual(oldNp.Spec.Taints, newNp.Spec.Taints) &&
+		(oldNp.DeletionTimestamp == nil) == (newNp.DeletionTimestamp == nil) {
+		return
+	}
+	// both the YurtAppSets that selected the nodepool before and the
+	// YurtAppSets that select the nodepool now need to be reconciled
+	e.addSelectingYurtAppSetsToWorkQueue(q, oldNp.GetLabels(), newNp.GetLabels())
+}
+
+// Delete implements EventHandler
+func (e *EnqueueYurtAppSetForNodePool) Delete(evt event.DeleteEvent,
+	q workqueue.RateLimitingInterface) {
+	e.addSelectingYurtAppSetsToWorkQueue(q, evt.Object.GetLabels())
+}
+
+// Generic implements EventHandler
+func (e *EnqueueYurtAppSetForNodePool) Generic(evt event.GenericEvent,
+	q workqueue.RateLimitingInterface) {
+}
+
+// addSelectingYurtAppSetsToWorkQueue adds the YurtAppSets whose nodepool
+// selector matches any of the nodepool label sets to the workqueue
+func (e *EnqueueYurtAppSetForNodePool) addSelectingYurtAppSetsToWorkQueue(
+	q workqueue.RateLimitingInterface, labelSets ...map[string]string) {
+	var yasList unitv1alpha1.YurtAppSetList
+	if err := e.client.List(context.TODO(), &yasList); err != nil {
+		klog.Errorf("fail to list YurtAppSets, %v", err)
+		return
+	}
+	for _, yas := range yasList.Items {
+		if yas.Spec.Topology.NodePoolSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(yas.Spec.Topology.NodePoolSelector)
+		if err != nil {
+			klog.Errorf("fail to convert the nodepool selector of YurtAppSet(%s/%s), %v",
+				yas.GetNamespace(), yas.GetName(), err)
+			continue
+		}
+		for _, ls := range labelSets {
+			if selector.Matches(labels.Set(ls)) {
+				klog.V(5).Infof("will enqueue YurtAppSet(%s/%s) as its selector matches the nodepool",
+					yas.GetNamespace(), yas.GetName())
+				q.Add(reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: yas.GetNamespace(), Name: yas.GetName()},
+				})
+				break
+			}
+		}
+	}
+}
+
+var _ handler.EventHandler = &EnqueueYurtAppSetForNodePool{}
@@ -57,6 +57,8 @@ type ControlInterface interface {
 	CreatePool(yas *unitv1alpha1.YurtAppSet, unit string, revision string, replicas int32) error
 	// UpdatePool updates the target pool with the input information.
 	UpdatePool(pool *Pool, yas *unitv1alpha1.YurtAppSet, revision string, replicas int32) error
+	// ScalePool updates the replicas of the target pool and keeps its revision.
+	ScalePool(pool *Pool, replicas int32) error
 	// DeletePool is used to delete the input pool.
 	DeletePool(*Pool) error
 	// GetPoolFailure extracts the pool failure message to expose on YurtAppSet status.
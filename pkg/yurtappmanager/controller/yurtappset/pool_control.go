@@ -128,6 +128,31 @@ func (m *PoolControl) UpdatePool(pool *Pool, yas *alpha1.YurtAppSet, revision st
 	return m.adapter.PostUpdate(yas, set, revision)
 }
 
+// ScalePool is used to update the replicas of the pool without updating its revision.
+func (m *PoolControl) ScalePool(pool *Pool, replicas int32) error {
+	set := m.adapter.NewResourceObject()
+	cliSet, ok := set.(client.Object)
+	if !ok {
+		return errors.New("fail to convert runtime.Object to client.Object")
+	}
+	var updateError error
+	for i := 0; i < updateRetries; i++ {
+		getError := m.Client.Get(context.TODO(), m.objectKey(pool), cliSet)
+		if getError != nil {
+			return getError
+		}
+
+		if err := m.adapter.ApplyPoolReplicas(replicas, set); err != nil {
+			return err
+		}
+		updateError = m.Client.Update(context.TODO(), cliSet)
+		if updateError == nil {
+			break
+		}
+	}
+	return updateError
+}
+
 // DeletePool is called to delete the pool. The target Pool workload can be found with the input pool.
 func (m *PoolControl) DeletePool(pool *Pool) error {
 	set := pool.Spec.PoolRef.(runtime.Object)
@@ -232,6 +232,10 @@ func (r *ReconcileYurtAppSet) newRevision(yas *appsalphav1.YurtAppSet, revision
 		selectedLabels = yas.Spec.WorkloadTemplate.StatefulSetTemplate.Labels
 	case yas.Spec.WorkloadTemplate.DeploymentTemplate != nil:
 		selectedLabels = yas.Spec.WorkloadTemplate.DeploymentTemplate.Labels
+	case yas.Spec.WorkloadTemplate.DaemonSetTemplate != nil:
+		selectedLabels = yas.Spec.WorkloadTemplate.DaemonSetTemplate.Labels
+	case yas.Spec.WorkloadTemplate.CustomTemplate != nil:
+		selectedLabels = yas.Spec.WorkloadTemplate.CustomTemplate.Labels
 	default:
 		klog.Errorf("YurtAppSet(%s/%s) need specific WorkloadTemplate", yas.GetNamespace(), yas.GetName())
 		return nil, fmt.Errorf("YurtAppSet(%s/%s) need specific WorkloadTemplate", yas.GetNamespace(), yas.GetName())
@@ -0,0 +1,284 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+const (
+	defaultMaxUnavailablePools     = 1
+	defaultProgressDeadlineSeconds = 600
+
+	eventTypeRolloutPaused = "RolloutPaused"
+	eventTypeRolloutHalted = "RolloutHalted"
+)
+
+// rolloutWave is a group of existing pools to be updated together
+type rolloutWave struct {
+	name  string
+	pools []string
+	pause bool
+}
+
+// rolloutPools filters the pools in `needUpdate` according to the rollout
+// strategy of the YurtAppSet. The pools that are already on the expected
+// revision are always updated, while the outdated pools are updated wave by
+// wave, and no more than MaxUnavailablePools pools are unavailable at the
+// same time. The progress of the rollout is recorded in `newStatus`, and the
+// duration after which the progress should be checked again is returned.
+func (r *ReconcileYurtAppSet) rolloutPools(yas *unitv1alpha1.YurtAppSet, nameToPool map[string]*Pool,
+	needUpdate []string, revision string, control ControlInterface,
+	newStatus *unitv1alpha1.YurtAppSetStatus) ([]string, time.Duration) {
+	strategy := yas.Spec.RolloutStrategy
+	if strategy == nil {
+		newStatus.Rollout = nil
+		RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutPaused)
+		RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutHalted)
+		return needUpdate, 0
+	}
+
+	outdated := sets.NewString()
+	for name, pool := range nameToPool {
+		if control.IsExpected(pool, revision) {
+			outdated.Insert(name)
+		}
+	}
+	var selected []string
+	for _, name := range needUpdate {
+		if !outdated.Has(name) {
+			selected = append(selected, name)
+		}
+	}
+
+	now := metav1.Now()
+	rollout := newStatus.Rollout
+	if rollout == nil || rollout.Revision != revision {
+		rollout = &unitv1alpha1.RolloutStatus{
+			Revision:         revision,
+			LastProgressTime: &now,
+		}
+		RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutPaused)
+		RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutHalted)
+	} else {
+		rollout = rollout.DeepCopy()
+	}
+	newStatus.Rollout = rollout
+	consumeRolloutResume(yas, rollout)
+
+	var completed []string
+	unavailable := 0
+	for name, pool := range nameToPool {
+		if !isPoolAvailable(pool) {
+			unavailable++
+		} else if !outdated.Has(name) {
+			completed = append(completed, name)
+		}
+	}
+	sort.Strings(completed)
+	if len(completed) > len(rollout.CompletedPools) {
+		// more pools become ready, the rollout is making progress again
+		rollout.LastProgressTime = &now
+		if rollout.Phase == unitv1alpha1.RolloutHaltedPhase {
+			rollout.Phase = unitv1alpha1.RolloutProgressing
+			RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutHalted)
+		}
+	}
+	rollout.CompletedPools = completed
+
+	waves := getRolloutWaves(strategy, nameToPool)
+	pendings := make([][]string, len(waves))
+	lastPending := -1
+	for i, wave := range waves {
+		for _, name := range wave.pools {
+			if outdated.Has(name) || !isPoolAvailable(nameToPool[name]) {
+				pendings[i] = append(pendings[i], name)
+				lastPending = i
+			}
+		}
+	}
+
+	for i, wave := range waves {
+		pending := pendings[i]
+		if len(pending) == 0 {
+			// only pause if the following waves are still to be rolled out
+			if wave.pause && i < lastPending && !sets.NewString(rollout.ResumedWaves...).Has(wave.name) {
+				rollout.CurrentWave = wave.name
+				rollout.Message = fmt.Sprintf("rollout is paused after wave %s, annotate %s=%s to resume",
+					wave.name, unitv1alpha1.AnnotationRolloutResume, wave.name)
+				if rollout.Phase != unitv1alpha1.RolloutPausedPhase {
+					r.recorder.Event(yas.DeepCopy(), corev1.EventTypeNormal, eventTypeRolloutPaused, rollout.Message)
+				}
+				rollout.Phase = unitv1alpha1.RolloutPausedPhase
+				SetYurtAppSetCondition(newStatus, NewYurtAppSetCondition(unitv1alpha1.RolloutPaused,
+					corev1.ConditionTrue, "WavePaused", rollout.Message))
+				return selected, 0
+			}
+			continue
+		}
+
+		RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutPaused)
+		if rollout.CurrentWave != wave.name {
+			rollout.CurrentWave = wave.name
+			rollout.LastProgressTime = &now
+		}
+		if rollout.Phase == unitv1alpha1.RolloutHaltedPhase {
+			return selected, 0
+		}
+
+		// halt the rollout if the updated pools fail to become ready in time
+		deadline := time.Duration(defaultProgressDeadlineSeconds) * time.Second
+		if strategy.ProgressDeadlineSeconds != nil {
+			deadline = time.Duration(*strategy.ProgressDeadlineSeconds) * time.Second
+		}
+		var failed []string
+		for _, name := range pending {
+			if !outdated.Has(name) {
+				failed = append(failed, name)
+			}
+		}
+		if len(failed) != 0 && now.Sub(rollout.LastProgressTime.Time) > deadline {
+			rollout.Phase = unitv1alpha1.RolloutHaltedPhase
+			rollout.Message = fmt.Sprintf("rollout is halted in wave %s as pools %s fail to become ready in %v",
+				wave.name, strings.Join(failed, ","), deadline)
+			r.recorder.Event(yas.DeepCopy(), corev1.EventTypeWarning, eventTypeRolloutHalted, rollout.Message)
+			SetYurtAppSetCondition(newStatus, NewYurtAppSetCondition(unitv1alpha1.RolloutHalted,
+				corev1.ConditionTrue, "ProgressDeadlineExceeded", rollout.Message))
+			return selected, 0
+		}
+
+		// update the outdated pools in the wave without exceeding the
+		// max unavailable pools, the unavailable pools can be updated anyway
+		budget := defaultMaxUnavailablePools - unavailable
+		if strategy.MaxUnavailablePools != nil {
+			budget = int(*strategy.MaxUnavailablePools) - unavailable
+		}
+		var updating []string
+		for _, name := range pending {
+			if !outdated.Has(name) {
+				continue
+			}
+			if isPoolAvailable(nameToPool[name]) {
+				if budget <= 0 {
+					continue
+				}
+				budget--
+			}
+			updating = append(updating, name)
+		}
+		if len(updating) != 0 {
+			rollout.LastProgressTime = &now
+		}
+
+		rollout.Phase = unitv1alpha1.RolloutProgressing
+		rollout.Message = fmt.Sprintf("rolling out wave %s, %d pools are pending", wave.name, len(pending))
+		klog.V(4).Infof("YurtAppSet %s/%s rolls out wave %s, update pools %v",
+			yas.Namespace, yas.Name, wave.name, updating)
+		return append(selected, updating...), deadline - now.Sub(rollout.LastProgressTime.Time)
+	}
+
+	rollout.Phase = unitv1alpha1.RolloutCompleted
+	rollout.CurrentWave = ""
+	rollout.Message = "all pools are updated and ready"
+	RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutPaused)
+	RemoveYurtAppSetCondition(newStatus, unitv1alpha1.RolloutHalted)
+	return selected, 0
+}
+
+// consumeRolloutResume records the wave named by the resume annotation as
+// resumed, the annotation is removed by removeRolloutResume once the status
+// is persisted
+func consumeRolloutResume(yas *unitv1alpha1.YurtAppSet, rollout *unitv1alpha1.RolloutStatus) {
+	wave, exist := yas.Annotations[unitv1alpha1.AnnotationRolloutResume]
+	if !exist {
+		return
+	}
+	if !sets.NewString(rollout.ResumedWaves...).Has(wave) {
+		rollout.ResumedWaves = append(rollout.ResumedWaves, wave)
+	}
+}
+
+// removeRolloutResume removes the resume annotation from the YurtAppSet once
+// the wave named by it is recorded as resumed in the persisted `status`,
+// otherwise the annotation is kept to be consumed again
+func (r *ReconcileYurtAppSet) removeRolloutResume(yas *unitv1alpha1.YurtAppSet, status *unitv1alpha1.YurtAppSetStatus) {
+	wave, exist := yas.Annotations[unitv1alpha1.AnnotationRolloutResume]
+	if !exist || status.Rollout == nil || !sets.NewString(status.Rollout.ResumedWaves...).Has(wave) {
+		return
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, unitv1alpha1.AnnotationRolloutResume))
+	obj := &unitv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Namespace: yas.Namespace, Name: yas.Name}}
+	if err := r.Patch(context.TODO(), obj, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		klog.Errorf("fail to remove the rollout resume annotation of YurtAppSet %s/%s: %v", yas.Namespace, yas.Name, err)
+		return
+	}
+	delete(yas.Annotations, unitv1alpha1.AnnotationRolloutResume)
+}
+
+// getRolloutWaves returns the waves of the existing pools, the pools not
+// listed in any wave are grouped in the last wave
+func getRolloutWaves(strategy *unitv1alpha1.RolloutStrategy, nameToPool map[string]*Pool) []rolloutWave {
+	var waves []rolloutWave
+	listed := sets.NewString()
+	for _, w := range strategy.Waves {
+		wave := rolloutWave{name: w.Name, pause: w.Pause}
+		for _, name := range w.Pools {
+			if _, exist := nameToPool[name]; exist && !listed.Has(name) {
+				wave.pools = append(wave.pools, name)
+				listed.Insert(name)
+			}
+		}
+		sort.Strings(wave.pools)
+		waves = append(waves, wave)
+	}
+
+	rest := rolloutWave{name: unitv1alpha1.DefaultRolloutWaveName}
+	for name := range nameToPool {
+		if !listed.Has(name) {
+			rest.pools = append(rest.pools, name)
+		}
+	}
+	if len(rest.pools) != 0 {
+		sort.Strings(rest.pools)
+		waves = append(waves, rest)
+	}
+	return waves
+}
+
+// isPoolAvailable checks if the workload of the pool has observed its latest
+// spec, and all of its replicas are updated and ready
+func isPoolAvailable(pool *Pool) bool {
+	if pool.Spec.PoolRef != nil && pool.Status.ObservedGeneration < pool.Spec.PoolRef.GetGeneration() {
+		return false
+	}
+	return pool.Status.UpdatedReplicas >= pool.Status.Replicas &&
+		pool.Status.ReadyReplicas >= pool.Status.Replicas
+}
@@ -0,0 +1,438 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
+)
+
+const rolloutRevision = "rev2"
+
+func newRolloutPool(name, revision string, ready bool) *Pool {
+	pool := &Pool{
+		Name:      name,
+		Namespace: "default",
+		Spec: PoolSpec{
+			PoolRef: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       name,
+					Namespace:  "default",
+					Generation: 1,
+					Labels:     map[string]string{appsv1alpha1.ControllerRevisionHashLabelKey: revision},
+				},
+			},
+		},
+		Status: PoolStatus{
+			ObservedGeneration: 1,
+			ReplicasInfo:       adapter.ReplicasInfo{Replicas: 2, ReadyReplicas: 2, UpdatedReplicas: 2},
+		},
+	}
+	if !ready {
+		pool.Status.ReadyReplicas = 1
+	}
+	return pool
+}
+
+func TestRolloutPools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	appsv1alpha1.AddToScheme(scheme)
+
+	strategy := &appsv1alpha1.RolloutStrategy{
+		Waves: []appsv1alpha1.RolloutWave{{Name: "canary", Pools: []string{"beijing"}, Pause: true}},
+	}
+	stale := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name           string
+		strategy       *appsv1alpha1.RolloutStrategy
+		resume         string
+		rollout        *appsv1alpha1.RolloutStatus
+		pools          []*Pool
+		needUpdate     []string
+		expectSelected []string
+		expectPhase    appsv1alpha1.RolloutPhase
+		expectWave     string
+	}{
+		{
+			"no rollout strategy",
+			nil,
+			"",
+			nil,
+			[]*Pool{newRolloutPool("beijing", "rev1", true), newRolloutPool("hangzhou", "rev1", true)},
+			[]string{"beijing", "hangzhou"},
+			[]string{"beijing", "hangzhou"},
+			"",
+			"",
+		},
+		{
+			"update the canary wave",
+			strategy,
+			"",
+			nil,
+			[]*Pool{
+				newRolloutPool("beijing", "rev1", true),
+				newRolloutPool("hangzhou", "rev1", true),
+				newRolloutPool("shanghai", "rev1", true),
+			},
+			[]string{"beijing", "hangzhou", "shanghai"},
+			[]string{"beijing"},
+			appsv1alpha1.RolloutProgressing,
+			"canary",
+		},
+		{
+			"pause after the canary wave",
+			strategy,
+			"",
+			nil,
+			[]*Pool{
+				newRolloutPool("beijing", rolloutRevision, true),
+				newRolloutPool("hangzhou", "rev1", true),
+				newRolloutPool("shanghai", "rev1", true),
+			},
+			[]string{"beijing", "hangzhou", "shanghai"},
+			[]string{"beijing"},
+			appsv1alpha1.RolloutPausedPhase,
+			"canary",
+		},
+		{
+			"resume the default wave",
+			strategy,
+			"canary",
+			nil,
+			[]*Pool{
+				newRolloutPool("beijing", rolloutRevision, true),
+				newRolloutPool("hangzhou", "rev1", true),
+				newRolloutPool("shanghai", "rev1", true),
+			},
+			[]string{"hangzhou", "shanghai"},
+			[]string{"hangzhou"},
+			appsv1alpha1.RolloutProgressing,
+			appsv1alpha1.DefaultRolloutWaveName,
+		},
+		{
+			"unavailable pools are updated anyway",
+			&appsv1alpha1.RolloutStrategy{},
+			"",
+			nil,
+			[]*Pool{
+				newRolloutPool("beijing", "rev1", false),
+				newRolloutPool("hangzhou", "rev1", true),
+			},
+			[]string{"beijing", "hangzhou"},
+			[]string{"beijing"},
+			appsv1alpha1.RolloutProgressing,
+			appsv1alpha1.DefaultRolloutWaveName,
+		},
+		{
+			"halt when the progress deadline is exceeded",
+			strategy,
+			"",
+			&appsv1alpha1.RolloutStatus{
+				Revision:         rolloutRevision,
+				Phase:            appsv1alpha1.RolloutProgressing,
+				CurrentWave:      "canary",
+				LastProgressTime: &stale,
+			},
+			[]*Pool{
+				newRolloutPool("beijing", rolloutRevision, false),
+				newRolloutPool("hangzhou", "rev1", true),
+			},
+			[]string{"hangzhou"},
+			nil,
+			appsv1alpha1.RolloutHaltedPhase,
+			"canary",
+		},
+		{
+			"all waves are completed",
+			strategy,
+			"",
+			nil,
+			[]*Pool{
+				newRolloutPool("beijing", rolloutRevision, true),
+				newRolloutPool("hangzhou", rolloutRevision, true),
+			},
+			nil,
+			nil,
+			appsv1alpha1.RolloutCompleted,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yas := &appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "yas", Namespace: "default"},
+					Spec:       appsv1alpha1.YurtAppSetSpec{RolloutStrategy: st.strategy},
+				}
+				if st.resume != "" {
+					yas.Annotations = map[string]string{appsv1alpha1.AnnotationRolloutResume: st.resume}
+				}
+				cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(yas.DeepCopy()).Build()
+				r := &ReconcileYurtAppSet{
+					Client:   cl,
+					scheme:   scheme,
+					recorder: record.NewFakeRecorder(10),
+					poolControls: map[appsv1alpha1.TemplateType]ControlInterface{
+						appsv1alpha1.DeploymentTemplateType: &PoolControl{Client: cl, scheme: scheme,
+							adapter: &adapter.DeploymentAdapter{Client: cl, Scheme: scheme}},
+					},
+				}
+				nameToPool := map[string]*Pool{}
+				for _, pool := range st.pools {
+					nameToPool[pool.Name] = pool
+				}
+				newStatus := &appsv1alpha1.YurtAppSetStatus{Rollout: st.rollout}
+
+				selected, _ := r.rolloutPools(yas, nameToPool, st.needUpdate, rolloutRevision,
+					r.poolControls[appsv1alpha1.DeploymentTemplateType], newStatus)
+				if !reflect.DeepEqual(selected, st.expectSelected) {
+					t.Fatalf("\t%s\texpect selected pools %v, but get %v", failed, st.expectSelected, selected)
+				}
+				var phase appsv1alpha1.RolloutPhase
+				var wave string
+				if newStatus.Rollout != nil {
+					phase, wave = newStatus.Rollout.Phase, newStatus.Rollout.CurrentWave
+				}
+				if phase != st.expectPhase || wave != st.expectWave {
+					t.Fatalf("\t%s\texpect phase %q in wave %q, but get %q in wave %q",
+						failed, st.expectPhase, st.expectWave, phase, wave)
+				}
+
+				if st.resume != "" {
+					if !reflect.DeepEqual(newStatus.Rollout.ResumedWaves, []string{st.resume}) {
+						t.Fatalf("\t%s\texpect resumed waves %v, but get %v", failed, []string{st.resume}, newStatus.Rollout.ResumedWaves)
+					}
+					var get appsv1alpha1.YurtAppSet
+					if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "yas"}, &get); err != nil {
+						t.Fatalf("\t%s\tfail to get YurtAppSet, %v", failed, err)
+					}
+					if _, exist := get.Annotations[appsv1alpha1.AnnotationRolloutResume]; !exist {
+						t.Fatalf("\t%s\texpect the resume annotation to be kept until the status is updated", failed)
+					}
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expectSelected, selected)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestRemoveRolloutResume(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+
+	tests := []struct {
+		name   string
+		status *appsv1alpha1.YurtAppSetStatus
+		expect bool
+	}{
+		{
+			"no rollout status",
+			&appsv1alpha1.YurtAppSetStatus{},
+			true,
+		},
+		{
+			"wave not resumed in status",
+			&appsv1alpha1.YurtAppSetStatus{Rollout: &appsv1alpha1.RolloutStatus{ResumedWaves: []string{"other"}}},
+			true,
+		},
+		{
+			"wave resumed in status",
+			&appsv1alpha1.YurtAppSetStatus{Rollout: &appsv1alpha1.RolloutStatus{ResumedWaves: []string{"canary"}}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yas := &appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "yas",
+						Namespace:   "default",
+						Annotations: map[string]string{appsv1alpha1.AnnotationRolloutResume: "canary"},
+					},
+				}
+				cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(yas.DeepCopy()).Build()
+				r := &ReconcileYurtAppSet{Client: cl, scheme: scheme}
+
+				r.removeRolloutResume(yas, st.status)
+				var get appsv1alpha1.YurtAppSet
+				if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "yas"}, &get); err != nil {
+					t.Fatalf("\t%s\tfail to get YurtAppSet, %v", failed, err)
+				}
+				_, exist := get.Annotations[appsv1alpha1.AnnotationRolloutResume]
+				if exist != st.expect {
+					t.Fatalf("\t%s\texpect the resume annotation to exist %v, but get %v", failed, st.expect, exist)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, exist)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestManagePoolsScaleHeldBackPools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	appsv1alpha1.AddToScheme(scheme)
+
+	yas := &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "yas", Namespace: "default"},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			Topology: appsv1alpha1.Topology{Pools: []appsv1alpha1.Pool{{Name: "beijing"}, {Name: "hangzhou"}}},
+			RolloutStrategy: &appsv1alpha1.RolloutStrategy{
+				Waves: []appsv1alpha1.RolloutWave{{Name: "canary", Pools: []string{"beijing"}, Pause: true}},
+			},
+		},
+	}
+	beijing := newRolloutPool("beijing", rolloutRevision, true)
+	hangzhou := newRolloutPool("hangzhou", "rev1", true)
+	nameToPool := map[string]*Pool{"beijing": beijing, "hangzhou": hangzhou}
+	nextPatches := map[string]YurtAppSetPatches{
+		"beijing":  {Replicas: 2},
+		"hangzhou": {Replicas: 4},
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(yas.DeepCopy(),
+		beijing.Spec.PoolRef.(*appsv1.Deployment).DeepCopy(), hangzhou.Spec.PoolRef.(*appsv1.Deployment).DeepCopy()).Build()
+	control := &PoolControl{Client: cl, scheme: scheme, adapter: &adapter.DeploymentAdapter{Client: cl, Scheme: scheme}}
+	r := &ReconcileYurtAppSet{
+		Client:   cl,
+		scheme:   scheme,
+		recorder: record.NewFakeRecorder(10),
+		poolControls: map[appsv1alpha1.TemplateType]ControlInterface{
+			appsv1alpha1.DeploymentTemplateType: control,
+		},
+	}
+
+	newStatus, _, err := r.managePools(yas, nameToPool, nextPatches,
+		&appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: rolloutRevision}},
+		control, appsv1alpha1.DeploymentTemplateType)
+	if err != nil {
+		t.Fatalf("\t%s\tfail to manage pools, %v", failed, err)
+	}
+	if newStatus.Rollout == nil || newStatus.Rollout.Phase != appsv1alpha1.RolloutPausedPhase {
+		t.Fatalf("\t%s\texpect the rollout to be paused, but get %v", failed, newStatus.Rollout)
+	}
+
+	var get appsv1.Deployment
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "hangzhou"}, &get); err != nil {
+		t.Fatalf("\t%s\tfail to get Deployment, %v", failed, err)
+	}
+	if get.Spec.Replicas == nil || *get.Spec.Replicas != 4 {
+		t.Fatalf("\t%s\texpect the held back pool to be scaled to 4, but get %v", failed, get.Spec.Replicas)
+	}
+	if revision := get.Labels[appsv1alpha1.ControllerRevisionHashLabelKey]; revision != "rev1" {
+		t.Fatalf("\t%s\texpect the held back pool to keep revision rev1, but get %s", failed, revision)
+	}
+	t.Logf("\t%s\texpect the held back pool to be scaled on its revision", succeed)
+}
+
+func TestManagePoolsSkipDaemonSetReplicas(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	appsv1alpha1.AddToScheme(scheme)
+
+	yas := &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "yas", Namespace: "default"},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "demo"}},
+			WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
+				DaemonSetTemplate: &appsv1alpha1.DaemonSetTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+				},
+			},
+			Topology: appsv1alpha1.Topology{Pools: []appsv1alpha1.Pool{{Name: "beijing"}}},
+		},
+	}
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "beijing",
+			Namespace:  "default",
+			Generation: 1,
+			Labels:     map[string]string{appsv1alpha1.ControllerRevisionHashLabelKey: rolloutRevision},
+		},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 3, UpdatedNumberScheduled: 3},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(yas.DeepCopy(), ds.DeepCopy()).Build()
+	var current appsv1.DaemonSet
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "beijing"}, &current); err != nil {
+		t.Fatalf("\t%s\tfail to get DaemonSet, %v", failed, err)
+	}
+
+	nameToPool := map[string]*Pool{
+		"beijing": {
+			Name:      "beijing",
+			Namespace: "default",
+			Spec:      PoolSpec{PoolRef: current.DeepCopy()},
+			Status: PoolStatus{
+				ObservedGeneration: 1,
+				ReplicasInfo:       adapter.ReplicasInfo{Replicas: 3, ReadyReplicas: 3, UpdatedReplicas: 3},
+			},
+		},
+	}
+	// the replicas distributed to the pool differ from the nodes of the pool
+	nextPatches := map[string]YurtAppSetPatches{"beijing": {Replicas: 1}}
+
+	control := &PoolControl{Client: cl, scheme: scheme, adapter: &adapter.DaemonSetAdapter{Client: cl, Scheme: scheme}}
+	r := &ReconcileYurtAppSet{
+		Client:   cl,
+		scheme:   scheme,
+		recorder: record.NewFakeRecorder(10),
+		poolControls: map[appsv1alpha1.TemplateType]ControlInterface{
+			appsv1alpha1.DaemonSetTemplateType: control,
+		},
+	}
+
+	if _, _, err := r.managePools(yas, nameToPool, nextPatches,
+		&appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: rolloutRevision}},
+		control, appsv1alpha1.DaemonSetTemplateType); err != nil {
+		t.Fatalf("\t%s\tfail to manage pools, %v", failed, err)
+	}
+
+	var get appsv1.DaemonSet
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "beijing"}, &get); err != nil {
+		t.Fatalf("\t%s\tfail to get DaemonSet, %v", failed, err)
+	}
+	if get.ResourceVersion != current.ResourceVersion {
+		t.Fatalf("\t%s\texpect the up-to-date DaemonSet pool not to be updated, but get resourceVersion %s", failed, get.ResourceVersion)
+	}
+	t.Logf("\t%s\texpect the up-to-date DaemonSet pool not to be updated", succeed)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// resolveTopology appends the nodepools selected by the NodePoolSelector to
+// the pools of the YurtAppSet. The pools listed in the topology override the
+// default configuration of the selected nodepools with the same name. The
+// resolved topology is only kept in memory and never written back.
+func (r *ReconcileYurtAppSet) resolveTopology(yas *unitv1alpha1.YurtAppSet) error {
+	topology := &yas.Spec.Topology
+	if topology.NodePoolSelector == nil {
+		return nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(topology.NodePoolSelector)
+	if err != nil {
+		return err
+	}
+	var npList unitv1alpha1.NodePoolList
+	if err := r.List(context.TODO(), &npList, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return err
+	}
+	sort.Slice(npList.Items, func(i, j int) bool {
+		return npList.Items[i].Name < npList.Items[j].Name
+	})
+
+	listed := make(map[string]int)
+	for i, pool := range topology.Pools {
+		listed[pool.Name] = i
+	}
+	for _, np := range npList.Items {
+		if np.DeletionTimestamp != nil {
+			continue
+		}
+		if i, exist := listed[np.Name]; exist {
+			pool := &topology.Pools[i]
+			if len(pool.NodeSelectorTerm.MatchExpressions) == 0 && len(pool.NodeSelectorTerm.MatchFields) == 0 {
+				pool.NodeSelectorTerm = newNodePoolSelectorTerm(np.Name)
+			}
+			if pool.Tolerations == nil {
+				pool.Tolerations = taintsToTolerations(np.Spec.Taints)
+			}
+			continue
+		}
+		pool := unitv1alpha1.Pool{
+			Name:             np.Name,
+			NodeSelectorTerm: newNodePoolSelectorTerm(np.Name),
+			Tolerations:      taintsToTolerations(np.Spec.Taints),
+		}
+		if topology.DefaultReplicas != nil {
+			replicas := *topology.DefaultReplicas
+			pool.Replicas = &replicas
+		}
+		if topology.DefaultAutoscaler != nil {
+			pool.Autoscaler = topology.DefaultAutoscaler.DeepCopy()
+		}
+		topology.Pools = append(topology.Pools, pool)
+	}
+	return nil
+}
+
+// newNodePoolSelectorTerm returns the node selector term that selects the
+// nodes in the nodepool
+func newNodePoolSelectorTerm(npName string) corev1.NodeSelectorTerm {
+	return corev1.NodeSelectorTerm{
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{
+				Key:      unitv1alpha1.LabelCurrentNodePool,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{npName},
+			},
+		},
+	}
+}
+
+// taintsToTolerations returns the tolerations that tolerate the taints of
+// the nodepool
+func taintsToTolerations(taints []corev1.Taint) []corev1.Toleration {
+	var tolerations []corev1.Toleration
+	for _, taint := range taints {
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      taint.Key,
+			Operator: corev1.TolerationOpExists,
+			Effect:   taint.Effect,
+		})
+	}
+	return tolerations
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yurtappset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newSelectedNodePool(name, region string, taints ...corev1.Taint) *appsv1alpha1.NodePool {
+	return &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"region": region},
+		},
+		Spec: appsv1alpha1.NodePoolSpec{Taints: taints},
+	}
+}
+
+func TestResolveTopology(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	taint := corev1.Taint{Key: "edge", Effect: corev1.TaintEffectNoSchedule}
+	r := &ReconcileYurtAppSet{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				newSelectedNodePool("hangzhou", "east", taint),
+				newSelectedNodePool("shanghai", "east"),
+				newSelectedNodePool("beijing", "north")).
+			Build(),
+		scheme: scheme,
+	}
+
+	tests := []struct {
+		name            string
+		topology        appsv1alpha1.Topology
+		expectPools     []string
+		expectReplicas  map[string]*int32
+		expectTolerated map[string]bool
+	}{
+		{
+			"no nodepool selector",
+			appsv1alpha1.Topology{
+				Pools: []appsv1alpha1.Pool{{Name: "beijing", Replicas: utilpointer.Int32Ptr(1)}},
+			},
+			[]string{"beijing"},
+			map[string]*int32{"beijing": utilpointer.Int32Ptr(1)},
+			map[string]bool{"beijing": false},
+		},
+		{
+			"select nodepools with default replicas",
+			appsv1alpha1.Topology{
+				NodePoolSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}},
+				DefaultReplicas:  utilpointer.Int32Ptr(2),
+			},
+			[]string{"hangzhou", "shanghai"},
+			map[string]*int32{"hangzhou": utilpointer.Int32Ptr(2), "shanghai": utilpointer.Int32Ptr(2)},
+			map[string]bool{"hangzhou": true, "shanghai": false},
+		},
+		{
+			"override the selected nodepool",
+			appsv1alpha1.Topology{
+				Pools: []appsv1alpha1.Pool{
+					{Name: "hangzhou", Replicas: utilpointer.Int32Ptr(5)},
+					{Name: "beijing", Replicas: utilpointer.Int32Ptr(1)},
+				},
+				NodePoolSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}},
+			},
+			[]string{"hangzhou", "beijing", "shanghai"},
+			map[string]*int32{"hangzhou": utilpointer.Int32Ptr(5), "beijing": utilpointer.Int32Ptr(1), "shanghai": nil},
+			map[string]bool{"hangzhou": true, "beijing": false, "shanghai": false},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				yas := &appsv1alpha1.YurtAppSet{
+					Spec: appsv1alpha1.YurtAppSetSpec{Topology: *st.topology.DeepCopy()},
+				}
+				if err := r.resolveTopology(yas); err != nil {
+					t.Fatalf("\t%s\tunexpected error %v", failed, err)
+				}
+				var pools []string
+				for _, pool := range yas.Spec.Topology.Pools {
+					pools = append(pools, pool.Name)
+					if !reflect.DeepEqual(pool.Replicas, st.expectReplicas[pool.Name]) {
+						t.Fatalf("\t%s\texpect replicas of pool %s %v, but get %v", failed, pool.Name,
+							st.expectReplicas[pool.Name], pool.Replicas)
+					}
+					if tolerated := len(pool.Tolerations) != 0; tolerated != st.expectTolerated[pool.Name] {
+						t.Fatalf("\t%s\texpect pool %s tolerated %v, but get %v", failed, pool.Name,
+							st.expectTolerated[pool.Name], tolerated)
+					}
+				}
+				if !reflect.DeepEqual(pools, st.expectPools) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expectPools, pools)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expectPools, pools)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestUpdateNodePool(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1alpha1.AddToScheme(scheme)
+	e := EnqueueYurtAppSetForNodePool{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				&appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "east", Namespace: "default"},
+					Spec: appsv1alpha1.YurtAppSetSpec{
+						Topology: appsv1alpha1.Topology{
+							NodePoolSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}},
+						},
+					},
+				},
+				&appsv1alpha1.YurtAppSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "static", Namespace: "default"},
+				}).
+			Build(),
+	}
+
+	tests := []struct {
+		name   string
+		oldNp  *appsv1alpha1.NodePool
+		newNp  *appsv1alpha1.NodePool
+		expect int
+	}{
+		{
+			"labels are not changed",
+			newSelectedNodePool("hangzhou", "east"),
+			newSelectedNodePool("hangzhou", "east"),
+			0,
+		},
+		{
+			"nodepool is selected",
+			newSelectedNodePool("hangzhou", "north"),
+			newSelectedNodePool("hangzhou", "east"),
+			1,
+		},
+		{
+			"taints of the selected nodepool are changed",
+			newSelectedNodePool("hangzhou", "east"),
+			newSelectedNodePool("hangzhou", "east", corev1.Taint{Key: "edge", Effect: corev1.TaintEffectNoSchedule}),
+			1,
+		},
+		{
+			"nodepool is not selected",
+			newSelectedNodePool("hangzhou", "north"),
+			newSelectedNodePool("hangzhou", "south"),
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				q := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(1*time.Millisecond, 1*time.Second))
+				e.Update(event.UpdateEvent{ObjectOld: st.oldNp, ObjectNew: st.newNp}, q)
+				if get := q.Len(); get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, q.Len())
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
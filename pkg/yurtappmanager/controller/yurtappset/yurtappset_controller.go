@@ -26,11 +26,15 @@ import (
 	"flag"
 	"fmt"
 	"reflect"
+	"sync"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -61,6 +65,7 @@ const (
 	eventTypeDupPoolsDelete     = "DeleteDuplicatedPools"
 	eventTypePoolsUpdate        = "UpdatePool"
 	eventTypeTemplateController = "TemplateController"
+	eventTypeAutoscalersManage  = "ManageAutoscalers"
 
 	slowStartInitialBatchSize = 1
 )
@@ -80,12 +85,15 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 		Client: mgr.GetClient(),
 		scheme: mgr.GetScheme(),
 
-		recorder: mgr.GetEventRecorderFor(controllerName),
+		recorder:     mgr.GetEventRecorderFor(controllerName),
+		watchedKinds: sets.NewString(),
 		poolControls: map[unitv1alpha1.TemplateType]ControlInterface{
 			unitv1alpha1.StatefulSetTemplateType: &PoolControl{Client: mgr.GetClient(), scheme: mgr.GetScheme(),
 				adapter: &adapter.StatefulSetAdapter{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}},
 			unitv1alpha1.DeploymentTemplateType: &PoolControl{Client: mgr.GetClient(), scheme: mgr.GetScheme(),
 				adapter: &adapter.DeploymentAdapter{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}},
+			unitv1alpha1.DaemonSetTemplateType: &PoolControl{Client: mgr.GetClient(), scheme: mgr.GetScheme(),
+				adapter: &adapter.DaemonSetAdapter{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}},
 		},
 	}
 }
@@ -120,6 +128,43 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(&source.Kind{Type: &appsv1.DaemonSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &unitv1alpha1.YurtAppSet{},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &autoscalingv2beta2.HorizontalPodAutoscaler{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &unitv1alpha1.YurtAppSet{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to NodePool that may be selected by YurtAppSet
+	err = c.Watch(&source.Kind{Type: &unitv1alpha1.NodePool{}}, &EnqueueYurtAppSetForNodePool{client: mgr.GetClient()})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to WorkloadDefinition that may be referenced by YurtAppSet
+	if gate.ResourceEnabled(&unitv1alpha1.WorkloadDefinition{}) {
+		err = c.Watch(&source.Kind{Type: &unitv1alpha1.WorkloadDefinition{}},
+			handler.EnqueueRequestsFromMapFunc(enqueueYurtAppSetsForDefinition(mgr.GetClient())))
+		if err != nil {
+			return err
+		}
+	}
+
+	// the workloads of the kinds registered by WorkloadDefinition are
+	// watched by the reconciler once they are referenced
+	if reconciler, ok := r.(*ReconcileYurtAppSet); ok {
+		reconciler.controller = c
+	}
+
 	return nil
 }
 
@@ -132,14 +177,24 @@ type ReconcileYurtAppSet struct {
 
 	recorder     record.EventRecorder
 	poolControls map[unitv1alpha1.TemplateType]ControlInterface
+
+	// controller is used to watch the workloads of the kinds registered by WorkloadDefinition
+	controller   controller.Controller
+	watchLock    sync.Mutex
+	watchedKinds sets.String
 }
 
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=workloaddefinitions,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=daemonsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
@@ -162,6 +217,11 @@ func (r *ReconcileYurtAppSet) Reconcile(_ context.Context, request reconcile.Req
 	if instance.DeletionTimestamp != nil {
 		return reconcile.Result{}, nil
 	}
+
+	if err := r.resolveTopology(instance); err != nil {
+		klog.Errorf("Fail to resolve the topology of YurtAppSet %s/%s: %s", instance.Namespace, instance.Name, err)
+		return reconcile.Result{}, err
+	}
 	oldStatus := instance.Status.DeepCopy()
 
 	currentRevision, updatedRevision, collisionCount, err := r.constructYurtAppSetRevisions(instance)
@@ -188,19 +248,34 @@ func (r *ReconcileYurtAppSet) Reconcile(_ context.Context, request reconcile.Req
 	}
 
 	nextPatches := GetNextPatches(instance)
+	respectAutoscaledReplicas(instance, nameToPool, nextPatches)
 	klog.V(4).Infof("Get YurtAppSet %s/%s next Patches %v", instance.Namespace, instance.Name, nextPatches)
 
 	expectedRevision := currentRevision
 	if updatedRevision != nil {
 		expectedRevision = updatedRevision
 	}
-	newStatus, err := r.managePools(instance, nameToPool, nextPatches, expectedRevision, poolType)
+	newStatus, requeueAfter, err := r.managePools(instance, nameToPool, nextPatches, expectedRevision, control, poolType)
 	if err != nil {
 		klog.Errorf("Fail to update YurtAppSet %s/%s: %s", instance.Namespace, instance.Name, err)
 		r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypePoolsUpdate), err.Error())
 	}
 
-	return r.updateStatus(instance, newStatus, oldStatus, nameToPool, currentRevision, collisionCount, control)
+	autoscaleErr := r.manageAutoscalers(instance, nameToPool)
+	if autoscaleErr != nil {
+		klog.Errorf("Fail to manage autoscalers of YurtAppSet %s/%s: %s", instance.Namespace, instance.Name, autoscaleErr)
+		r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypeAutoscalersManage), autoscaleErr.Error())
+	}
+
+	result, err := r.updateStatus(instance, newStatus, oldStatus, nameToPool, currentRevision, collisionCount, control)
+	if err == nil && autoscaleErr != nil {
+		return result, autoscaleErr
+	}
+	if err == nil && requeueAfter > 0 {
+		// check the progress of the rollout later
+		result.RequeueAfter = requeueAfter
+	}
+	return result, err
 }
 
 func (r *ReconcileYurtAppSet) getNameToPool(instance *unitv1alpha1.YurtAppSet, control ControlInterface) (map[string]*Pool, error) {
@@ -253,10 +328,16 @@ func (r *ReconcileYurtAppSet) getPoolControls(instance *unitv1alpha1.YurtAppSet)
 		return r.poolControls[unitv1alpha1.StatefulSetTemplateType], unitv1alpha1.StatefulSetTemplateType, nil
 	case instance.Spec.WorkloadTemplate.DeploymentTemplate != nil:
 		return r.poolControls[unitv1alpha1.DeploymentTemplateType], unitv1alpha1.DeploymentTemplateType, nil
+	case instance.Spec.WorkloadTemplate.DaemonSetTemplate != nil:
+		return r.poolControls[unitv1alpha1.DaemonSetTemplateType], unitv1alpha1.DaemonSetTemplateType, nil
+	case instance.Spec.WorkloadTemplate.CustomTemplate != nil:
+		control, err := r.getCustomPoolControl(instance.Spec.WorkloadTemplate.CustomTemplate.Definition)
+		return control, unitv1alpha1.CustomTemplateType, err
 	default:
 		klog.Errorf("The appropriate WorkloadTemplate was not found")
-		return nil, "", fmt.Errorf("The appropriate WorkloadTemplate was not found, Now Support(%s/%s)",
-			unitv1alpha1.StatefulSetTemplateType, unitv1alpha1.DeploymentTemplateType)
+		return nil, "", fmt.Errorf("The appropriate WorkloadTemplate was not found, Now Support(%s/%s/%s/%s)",
+			unitv1alpha1.StatefulSetTemplateType, unitv1alpha1.DeploymentTemplateType, unitv1alpha1.DaemonSetTemplateType,
+			unitv1alpha1.CustomTemplateType)
 	}
 }
 
@@ -276,6 +357,9 @@ func (r *ReconcileYurtAppSet) updateStatus(instance *unitv1alpha1.YurtAppSet, ne
 
 	newStatus = r.calculateStatus(instance, newStatus, nameToPool, currentRevision, collisionCount, control)
 	_, err := r.updateYurtAppSet(instance, oldStatus, newStatus)
+	if err == nil {
+		r.removeRolloutResume(instance, newStatus)
+	}
 
 	return reconcile.Result{}, err
 }
@@ -303,6 +387,9 @@ func (r *ReconcileYurtAppSet) calculateStatus(instance *unitv1alpha1.YurtAppSet,
 
 	newStatus.TemplateType = getPoolTemplateType(instance)
 
+	// the label selector is exposed for the scale subresource
+	newStatus.LabelSelector = metav1.FormatLabelSelector(instance.Spec.Selector)
+
 	var poolFailure *string
 	for _, pool := range nameToPool {
 		failureMessage := control.GetPoolFailure(pool)
@@ -328,6 +415,10 @@ func getPoolTemplateType(obj *unitv1alpha1.YurtAppSet) (templateType unitv1alpha
 		templateType = unitv1alpha1.StatefulSetTemplateType
 	case template.DeploymentTemplate != nil:
 		templateType = unitv1alpha1.DeploymentTemplateType
+	case template.DaemonSetTemplate != nil:
+		templateType = unitv1alpha1.DaemonSetTemplateType
+	case template.CustomTemplate != nil:
+		templateType = unitv1alpha1.CustomTemplateType
 	default:
 		klog.Warning("YurtAppSet.Spec.WorkloadTemplate exist wrong template")
 	}
@@ -339,8 +430,10 @@ func (r *ReconcileYurtAppSet) updateYurtAppSet(yas *unitv1alpha1.YurtAppSet, old
 		oldStatus.CollisionCount == newStatus.CollisionCount &&
 		oldStatus.Replicas == newStatus.Replicas &&
 		oldStatus.ReadyReplicas == newStatus.ReadyReplicas &&
+		oldStatus.LabelSelector == newStatus.LabelSelector &&
 		yas.Generation == newStatus.ObservedGeneration &&
 		reflect.DeepEqual(oldStatus.PoolReplicas, newStatus.PoolReplicas) &&
+		reflect.DeepEqual(oldStatus.Rollout, newStatus.Rollout) &&
 		reflect.DeepEqual(oldStatus.Conditions, newStatus.Conditions) {
 		return yas, nil
 	}
@@ -29,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
 )
 
 const updateRetries = 5
@@ -104,16 +105,15 @@ func filterOutCondition(conditions []unitv1alpha1.YurtAppSetCondition, condType
 	return newConditions
 }
 
+// GetNextPatches returns the replicas and the patch of each pool, the
+// replicas of the YurtAppSet are distributed between the pools if set
 func GetNextPatches(yas *unitv1alpha1.YurtAppSet) map[string]YurtAppSetPatches {
 	next := make(map[string]YurtAppSetPatches)
-	for _, pool := range yas.Spec.Topology.Pools {
+	replicas := allocatePoolReplicas(yas)
+	for i, pool := range yas.Spec.Topology.Pools {
 		t := YurtAppSetPatches{}
-		if pool.Replicas != nil {
-			t.Replicas = *pool.Replicas
-		}
-		if pool.Patch != nil {
-			t.Patch = string(pool.Patch.Raw)
-		}
+		t.Replicas = replicas[pool.Name]
+		t.Patch = adapter.PatchInfo(&yas.Spec.Topology.Pools[i])
 		next[pool.Name] = t
 	}
 	return next
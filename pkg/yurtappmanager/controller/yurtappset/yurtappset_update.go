@@ -23,6 +23,7 @@ package yurtappset
 
 import (
 	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -38,13 +39,13 @@ import (
 func (r *ReconcileYurtAppSet) managePools(yas *unitv1alpha1.YurtAppSet,
 	nameToPool map[string]*Pool, nextPatches map[string]YurtAppSetPatches,
 	expectedRevision *appsv1.ControllerRevision,
-	poolType unitv1alpha1.TemplateType) (newStatus *unitv1alpha1.YurtAppSetStatus, updateErr error) {
+	control ControlInterface, poolType unitv1alpha1.TemplateType) (newStatus *unitv1alpha1.YurtAppSetStatus, requeueAfter time.Duration, updateErr error) {
 
 	newStatus = yas.Status.DeepCopy()
-	exists, provisioned, err := r.managePoolProvision(yas, nameToPool, nextPatches, expectedRevision, poolType)
+	exists, provisioned, err := r.managePoolProvision(yas, nameToPool, nextPatches, expectedRevision, control, poolType)
 	if err != nil {
 		SetYurtAppSetCondition(newStatus, NewYurtAppSetCondition(unitv1alpha1.PoolProvisioned, corev1.ConditionFalse, "Error", err.Error()))
-		return newStatus, fmt.Errorf("fail to manage Pool provision: %s", err)
+		return newStatus, 0, fmt.Errorf("fail to manage Pool provision: %s", err)
 	}
 
 	if provisioned {
@@ -54,13 +55,31 @@ func (r *ReconcileYurtAppSet) managePools(yas *unitv1alpha1.YurtAppSet,
 	var needUpdate []string
 	for _, name := range exists.List() {
 		pool := nameToPool[name]
-		if r.poolControls[poolType].IsExpected(pool, expectedRevision.Name) ||
-			pool.Status.ReplicasInfo.Replicas != nextPatches[name].Replicas ||
+		// the replicas of DaemonSet pools are decided by their nodes, not by
+		// the replicas distributed to them
+		replicasChanged := poolType != unitv1alpha1.DaemonSetTemplateType &&
+			pool.Status.ReplicasInfo.Replicas != nextPatches[name].Replicas
+		if control.IsExpected(pool, expectedRevision.Name) || replicasChanged ||
 			pool.Status.PatchInfo != nextPatches[name].Patch {
 			needUpdate = append(needUpdate, name)
 		}
 	}
 
+	selected, requeueAfter := r.rolloutPools(yas, nameToPool, needUpdate, expectedRevision.Name, control, newStatus)
+
+	// the pools held back by the rollout keep their revision, but are still
+	// scaled to the replicas distributed to them
+	var needScale []string
+	if poolType != unitv1alpha1.DaemonSetTemplateType {
+		selectedPools := sets.NewString(selected...)
+		for _, name := range needUpdate {
+			if !selectedPools.Has(name) && nameToPool[name].Status.ReplicasInfo.Replicas != nextPatches[name].Replicas {
+				needScale = append(needScale, name)
+			}
+		}
+	}
+	needUpdate = selected
+
 	if len(needUpdate) > 0 {
 		_, updateErr = util.SlowStartBatch(len(needUpdate), slowStartInitialBatchSize, func(index int) error {
 			cell := needUpdate[index]
@@ -70,7 +89,7 @@ func (r *ReconcileYurtAppSet) managePools(yas *unitv1alpha1.YurtAppSet,
 			klog.Infof("YurtAppSet %s/%s needs to update Pool (%s) %s/%s with revision %s, replicas %d ",
 				yas.Namespace, yas.Name, poolType, pool.Namespace, pool.Name, expectedRevision.Name, replicas)
 
-			updatePoolErr := r.poolControls[poolType].UpdatePool(pool, yas, expectedRevision.Name, replicas)
+			updatePoolErr := control.UpdatePool(pool, yas, expectedRevision.Name, replicas)
 			if updatePoolErr != nil {
 				r.recorder.Event(yas.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypePoolsUpdate), fmt.Sprintf("Error updating PodSet (%s) %s when updating: %s", poolType, pool.Name, updatePoolErr))
 			}
@@ -78,6 +97,22 @@ func (r *ReconcileYurtAppSet) managePools(yas *unitv1alpha1.YurtAppSet,
 		})
 	}
 
+	if updateErr == nil && len(needScale) > 0 {
+		_, updateErr = util.SlowStartBatch(len(needScale), slowStartInitialBatchSize, func(index int) error {
+			pool := nameToPool[needScale[index]]
+			replicas := nextPatches[needScale[index]].Replicas
+
+			klog.Infof("YurtAppSet %s/%s needs to scale Pool (%s) %s/%s held back by the rollout to replicas %d",
+				yas.Namespace, yas.Name, poolType, pool.Namespace, pool.Name, replicas)
+
+			scalePoolErr := control.ScalePool(pool, replicas)
+			if scalePoolErr != nil {
+				r.recorder.Event(yas.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypePoolsUpdate), fmt.Sprintf("Error scaling PodSet (%s) %s: %s", poolType, pool.Name, scalePoolErr))
+			}
+			return scalePoolErr
+		})
+	}
+
 	if updateErr == nil {
 		SetYurtAppSetCondition(newStatus, NewYurtAppSetCondition(unitv1alpha1.PoolUpdated, corev1.ConditionTrue, "", ""))
 	} else {
@@ -88,7 +123,8 @@ func (r *ReconcileYurtAppSet) managePools(yas *unitv1alpha1.YurtAppSet,
 
 func (r *ReconcileYurtAppSet) managePoolProvision(yas *unitv1alpha1.YurtAppSet,
 	nameToPool map[string]*Pool, nextPatches map[string]YurtAppSetPatches,
-	expectedRevision *appsv1.ControllerRevision, workloadType unitv1alpha1.TemplateType) (sets.String, bool, error) {
+	expectedRevision *appsv1.ControllerRevision, control ControlInterface,
+	workloadType unitv1alpha1.TemplateType) (sets.String, bool, error) {
 	expectedPools := sets.String{}
 	gotPools := sets.String{}
 
@@ -137,7 +173,7 @@ func (r *ReconcileYurtAppSet) managePoolProvision(yas *unitv1alpha1.YurtAppSet,
 			poolName := createdPools[idx]
 
 			replicas := nextPatches[poolName].Replicas
-			err := r.poolControls[workloadType].CreatePool(yas, poolName, revision, replicas)
+			err := control.CreatePool(yas, poolName, revision, replicas)
 			if err != nil {
 				if !errors.IsTimeout(err) {
 					return fmt.Errorf("fail to create Pool (%s) %s: %s", workloadType, poolName, err.Error())
@@ -159,7 +195,7 @@ func (r *ReconcileYurtAppSet) managePoolProvision(yas *unitv1alpha1.YurtAppSet,
 		var deleteErrs []error
 		for _, poolName := range deletes {
 			pool := nameToPool[poolName]
-			if err := r.poolControls[workloadType].DeletePool(pool); err != nil {
+			if err := control.DeletePool(pool); err != nil {
 				deleteErrs = append(deleteErrs, fmt.Errorf("fail to delete Pool (%s) %s/%s for %s: %s", workloadType, pool.Namespace, pool.Name, poolName, err))
 			}
 		}
@@ -173,13 +209,33 @@ func (r *ReconcileYurtAppSet) managePoolProvision(yas *unitv1alpha1.YurtAppSet,
 
 	// clean the other kind of pools
 	// maybe user can chagne yas.Spec.WorkloadTemplate
+	otherControls := map[string]ControlInterface{}
+	for t, otherControl := range r.poolControls {
+		if t != workloadType {
+			otherControls[string(t)] = otherControl
+		}
+	}
+	// the custom workloads of the previous WorkloadDefinitions
+	customControls, err := r.getPreviousCustomPoolControls(yas)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("fail to get previous custom Pool controls for YurtAppSet %s/%s: %s", yas.Namespace, yas.Name, err))
+	}
+	for definition, otherControl := range customControls {
+		otherControls[fmt.Sprintf("%s(%s)", unitv1alpha1.CustomTemplateType, definition)] = otherControl
+	}
+	currentKind, err := r.getPoolControlGroupKind(control)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("fail to get the kind of Pool %s for YurtAppSet %s/%s: %s", workloadType, yas.Namespace, yas.Name, err))
+	}
+
 	cleaned := false
-	for t, control := range r.poolControls {
-		if t == workloadType {
+	for t, otherControl := range otherControls {
+		// the pools of the same kind are managed by the current control
+		if kind, err := r.getPoolControlGroupKind(otherControl); err == nil && kind == currentKind {
 			continue
 		}
 
-		pools, err := control.GetAllPools(yas)
+		pools, err := otherControl.GetAllPools(yas)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("fail to list Pool of other type %s for YurtAppSet %s/%s: %s", t, yas.Namespace, yas.Name, err))
 			continue
@@ -187,7 +243,7 @@ func (r *ReconcileYurtAppSet) managePoolProvision(yas *unitv1alpha1.YurtAppSet,
 
 		for _, pool := range pools {
 			cleaned = true
-			if err := control.DeletePool(pool); err != nil {
+			if err := otherControl.DeletePool(pool); err != nil {
 				errs = append(errs, fmt.Errorf("fail to delete Pool %s of other type %s for YurtAppSet %s/%s: %s", pool.Name, t, yas.Namespace, yas.Name, err))
 				continue
 			}
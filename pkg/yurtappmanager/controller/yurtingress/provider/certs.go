@@ -0,0 +1,316 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// the keys of the webhook certificate secrets, the same as the certgen jobs.
+	certSecretCAKey   = "ca"
+	certSecretCertKey = "cert"
+	certSecretKeyKey  = "key"
+	// the key of the CA secret keeping the rotated CA, which is still trusted
+	// by the webhooks until the serving certificates signed by it are replaced.
+	certSecretPreviousCAKey = "previous-ca"
+
+	webhookCAValidity       = 10 * 365 * 24 * time.Hour
+	webhookCertValidity     = 365 * 24 * time.Hour
+	webhookCertRotateBefore = 30 * 24 * time.Hour
+	// webhookCertCheckInterval is the max interval to check the webhook certificates.
+	webhookCertCheckInterval = 24 * time.Hour
+)
+
+// webhookCert is a certificate and its private key.
+type webhookCert struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// ensureWebhookCA returns the CA signing the webhook serving certificates from
+// the secret, the CA is generated if it does not exist or is about to expire,
+// the returned bundle contains the CA and the rotated CA which is still valid.
+func ensureWebhookCA(c client.Client, namespace, name string, now time.Time) (*webhookCert, []byte, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	exists := err == nil
+	if exists {
+		ca, err := parseWebhookCert(secret.Data[certSecretCertKey], secret.Data[certSecretKeyKey])
+		if err == nil && !needRotateCert(ca.cert, now) {
+			return ca, webhookCABundle(ca.certPEM, secret.Data[certSecretPreviousCAKey], now), nil
+		}
+		if err != nil {
+			klog.Infof("invalid webhook CA in secret %s/%s is regenerated: %v", namespace, name, err)
+		}
+	}
+	ca, err := newWebhookCA(name, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	previousCA := secret.Data[certSecretCertKey]
+	data := map[string][]byte{
+		certSecretCertKey: ca.certPEM,
+		certSecretKeyKey:  ca.keyPEM,
+	}
+	if len(previousCA) > 0 {
+		data[certSecretPreviousCAKey] = previousCA
+	}
+	if !exists {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       data,
+		}
+		if err := c.Create(context.Background(), secret); err != nil {
+			return nil, nil, fmt.Errorf("fail to create the secret/%s: %v", name, err)
+		}
+		klog.V(4).Infof("webhook CA secret/%s is created", name)
+	} else {
+		secret.Data = data
+		if err := c.Update(context.Background(), secret); err != nil {
+			return nil, nil, fmt.Errorf("fail to update the secret/%s: %v", name, err)
+		}
+		klog.Infof("webhook CA secret/%s is rotated", name)
+	}
+	return ca, webhookCABundle(ca.certPEM, previousCA, now), nil
+}
+
+// ensureWebhookServingCert makes sure the secret holds a serving certificate
+// signed by the CA for the hosts, the certificate is regenerated if it does not
+// exist, is about to expire or is not signed by the CA. It returns the expiry
+// time of the serving certificate.
+func ensureWebhookServingCert(c client.Client, namespace, name string, hosts []string,
+	ca *webhookCert, now time.Time) (time.Time, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return time.Time{}, err
+	}
+	exists := err == nil
+	if exists && bytes.Equal(secret.Data[certSecretCAKey], ca.certPEM) {
+		serving, err := parseWebhookCert(secret.Data[certSecretCertKey], secret.Data[certSecretKeyKey])
+		if err == nil && !needRotateCert(serving.cert, now) && serving.cert.CheckSignatureFrom(ca.cert) == nil {
+			return serving.cert.NotAfter, nil
+		}
+	}
+	serving, err := newWebhookServingCert(hosts, ca, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	data := map[string][]byte{
+		certSecretCAKey:   ca.certPEM,
+		certSecretCertKey: serving.certPEM,
+		certSecretKeyKey:  serving.keyPEM,
+	}
+	if !exists {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       data,
+		}
+		if err := c.Create(context.Background(), secret); err != nil {
+			return time.Time{}, fmt.Errorf("fail to create the secret/%s: %v", name, err)
+		}
+		klog.V(4).Infof("webhook certificate secret/%s is created", name)
+	} else {
+		secret.Data = data
+		if err := c.Update(context.Background(), secret); err != nil {
+			return time.Time{}, fmt.Errorf("fail to update the secret/%s: %v", name, err)
+		}
+		klog.Infof("webhook certificate secret/%s is rotated", name)
+	}
+	return serving.cert.NotAfter, nil
+}
+
+// patchWebhookCABundle sets the caBundle of all the webhooks in the ValidatingWebhookConfiguration.
+func patchWebhookCABundle(c client.Client, name string, caBundle []byte) error {
+	vwc := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: name}, vwc); err != nil {
+		return err
+	}
+	changed := false
+	for i := range vwc.Webhooks {
+		if !bytes.Equal(vwc.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			vwc.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := c.Update(context.Background(), vwc); err != nil {
+		return fmt.Errorf("fail to update the caBundle of validatingwebhookconfiguration/%s: %v", name, err)
+	}
+	klog.V(4).Infof("caBundle of validatingwebhookconfiguration/%s is updated", name)
+	return nil
+}
+
+// needRotateCert checks if the certificate is about to expire.
+func needRotateCert(cert *x509.Certificate, now time.Time) bool {
+	return now.Add(webhookCertRotateBefore).After(cert.NotAfter)
+}
+
+// webhookCertRequeueAfter returns when the certificate expiring at notAfter should be checked again.
+func webhookCertRequeueAfter(notAfter, now time.Time) time.Duration {
+	after := notAfter.Add(-webhookCertRotateBefore).Sub(now)
+	if after <= 0 {
+		return time.Second
+	}
+	if after > webhookCertCheckInterval {
+		return webhookCertCheckInterval
+	}
+	return after
+}
+
+// webhookCABundle appends the previous CA to the bundle if it is still valid.
+func webhookCABundle(caPEM, previousCAPEM []byte, now time.Time) []byte {
+	bundle := append([]byte{}, caPEM...)
+	if len(previousCAPEM) == 0 {
+		return bundle
+	}
+	block, _ := pem.Decode(previousCAPEM)
+	if block == nil {
+		return bundle
+	}
+	previous, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || now.After(previous.NotAfter) {
+		return bundle
+	}
+	return append(bundle, previousCAPEM...)
+}
+
+func newWebhookCA(commonName string, now time.Time) (*webhookCert, error) {
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(webhookCAValidity),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	return newWebhookCert(template, nil)
+}
+
+func newWebhookServingCert(hosts []string, ca *webhookCert, now time.Time) (*webhookCert, error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0]},
+		DNSNames:    hosts,
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(webhookCertValidity),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	return newWebhookCert(template, ca)
+}
+
+// newWebhookCert generates a key and a certificate from the template, which is
+// signed by the CA, or self-signed if the CA is nil.
+func newWebhookCert(template *x509.Certificate, ca *webhookCert) (*webhookCert, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	parent, signer := template, crypto.Signer(key)
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// parseWebhookCert parses the PEM encoded certificate and private key.
+func parseWebhookCert(certPEM, keyPEM []byte) (*webhookCert, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("no private key found")
+	}
+	var key crypto.Signer
+	switch keyBlock.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	default:
+		var parsed interface{}
+		parsed, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+		if err == nil {
+			signer, ok := parsed.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("unsupported private key type %T", parsed)
+			}
+			key = signer
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhookCert{cert: cert, key: key, certPEM: certPEM, keyPEM: keyPEM}, nil
+}
@@ -0,0 +1,602 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/constant"
+	yurtapputil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/kubernetes"
+)
+
+const (
+	// nginxIngressNamespace is the default namespace of ingress-nginx.
+	nginxIngressNamespace = "ingress-nginx"
+	nginxConfigMapArg     = "--configmap="
+	// nginxWebhookCASecret keeps the CA signing the webhook certificates of all
+	// the pools when the certificates are managed by yurt-app-manager.
+	nginxWebhookCASecret = "ingress-nginx-admission-ca"
+)
+
+// nginxLegacyClusterRoleBindings are the names of the ClusterRoleBindings
+// created by the earlier versions, before they were prefixed with the provider.
+var nginxLegacyClusterRoleBindings = []string{"ingress-nginx", "ingress-nginx-admission"}
+
+// nginxHostPorts maps the container ports of ingress-nginx to the host ports in the HostPort mode.
+var nginxHostPorts = map[string]int32{"http": 80, "https": 443}
+
+// NginxProvider deploys ingress-nginx on the nodepools, each pool has its own
+// ingress controller and admission webhook.
+type NginxProvider struct{}
+
+var _ Provider = &NginxProvider{}
+
+// IsCommonResourceReady checks if the namespace of ingress-nginx is active.
+func (p *NginxProvider) IsCommonResourceReady(c client.Client, namespace string) bool {
+	return isNamespaceReady(c, namespace)
+}
+
+// CreateCommonResource creates the namespace, rbac and configmap of ingress-nginx.
+func (p *NginxProvider) CreateCommonResource(cli client.Client, namespace string) error {
+	ownerRefs := commonResourceOwnerReferences(cli)
+	ctx := commonTemplateContext(namespace)
+
+	// 1. Create Namespace
+	if err := yurtapputil.CreateNamespaceFromYaml(cli, constant.NginxIngressControllerNamespace, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Create ClusterRole
+	if err := yurtapputil.CreateClusterRoleFromYaml(cli, constant.NginxIngressControllerClusterRole, ownerRefs); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateClusterRoleFromYaml(cli, constant.NginxIngressAdmissionWebhookClusterRole, ownerRefs); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 3. Create ClusterRoleBinding
+	if err := yurtapputil.CreateClusterRoleBindingFromYaml(cli,
+		constant.NginxIngressControllerClusterRoleBinding, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateClusterRoleBindingFromYaml(cli,
+		constant.NginxIngressAdmissionWebhookClusterRoleBinding, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 4. Create Role
+	if err := yurtapputil.CreateRoleFromYaml(cli,
+		constant.NginxIngressControllerRole, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateRoleFromYaml(cli,
+		constant.NginxIngressAdmissionWebhookRole, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 5. Create RoleBinding
+	if err := yurtapputil.CreateRoleBindingFromYaml(cli,
+		constant.NginxIngressControllerRoleBinding, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateRoleBindingFromYaml(cli,
+		constant.NginxIngressAdmissionWebhookRoleBinding, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 6. Create ServiceAccount
+	if err := yurtapputil.CreateServiceAccountFromYaml(cli,
+		constant.NginxIngressControllerServiceAccount, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateServiceAccountFromYaml(cli,
+		constant.NginxIngressAdmissionWebhookServiceAccount, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 7. Create Configmap
+	if err := yurtapputil.CreateConfigMapFromYaml(cli,
+		constant.NginxIngressControllerConfigMap, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteCommonResource deletes the namespace, rbac and configmap of ingress-nginx,
+// the ClusterRoles are deleted only if cleanupCluster is true.
+func (p *NginxProvider) DeleteCommonResource(client client.Client, namespace string, cleanupCluster bool) error {
+	ctx := commonTemplateContext(namespace)
+	// 1. Delete Configmap
+	if err := yurtapputil.DeleteConfigMapFromYaml(client,
+		constant.NginxIngressControllerConfigMap, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Delete RoleBinding
+	if err := yurtapputil.DeleteRoleBindingFromYaml(client,
+		constant.NginxIngressControllerRoleBinding, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.DeleteRoleBindingFromYaml(client,
+		constant.NginxIngressAdmissionWebhookRoleBinding, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 3. Delete Role
+	if err := yurtapputil.DeleteRoleFromYaml(client,
+		constant.NginxIngressControllerRole, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.DeleteRoleFromYaml(client,
+		constant.NginxIngressAdmissionWebhookRole, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 4. Delete ClusterRoleBinding
+	if err := yurtapputil.DeleteClusterRoleBindingFromYaml(client,
+		constant.NginxIngressControllerClusterRoleBinding, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.DeleteClusterRoleBindingFromYaml(client,
+		constant.NginxIngressAdmissionWebhookClusterRoleBinding, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	for _, name := range nginxLegacyClusterRoleBindings {
+		if err := yurtapputil.DeleteLegacyClusterRoleBinding(client, name); err != nil {
+			klog.Errorf("%v", err)
+			return err
+		}
+	}
+	// 5. Delete ClusterRole
+	if cleanupCluster {
+		if err := yurtapputil.DeleteClusterRoleFromYaml(client, constant.NginxIngressControllerClusterRole); err != nil {
+			klog.Errorf("%v", err)
+			return err
+		}
+		if err := yurtapputil.DeleteClusterRoleFromYaml(client, constant.NginxIngressAdmissionWebhookClusterRole); err != nil {
+			klog.Errorf("%v", err)
+			return err
+		}
+	}
+	// 6. Delete ServiceAccount
+	if err := yurtapputil.DeleteServiceAccountFromYaml(client,
+		constant.NginxIngressControllerServiceAccount, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.DeleteServiceAccountFromYaml(client,
+		constant.NginxIngressAdmissionWebhookServiceAccount, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 7. Delete the Secret of webhook CA
+	secret := &corev1.Secret{}
+	secret.Namespace, secret.Name = namespace, nginxWebhookCASecret
+	if err := client.Delete(context.Background(), secret); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("fail to delete the secret/%s: %v", secret.Name, err)
+		return err
+	}
+	// 8. Delete Namespace
+	if err := yurtapputil.DeleteNamespaceFromYaml(client, constant.NginxIngressControllerNamespace, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// CreatePoolResource creates the ingress controller, the admission webhook and
+// the jobs generating the webhook certificates on the pool, the jobs are not
+// created if the webhook certificates are managed by yurt-app-manager.
+func (p *NginxProvider) CreatePoolResource(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool, ownerRef *metav1.OwnerReference) error {
+	poolname := pool.Name
+	namespace := GetNamespace(ying)
+	ingressWebhookCertGenImage := ying.Spec.IngressWebhookCertGenImage
+	// 1. Create ConfigMap
+	if err := applyNginxPoolConfigMap(client, namespace, pool); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Create Deployment or DaemonSet
+	dply, err := renderNginxPoolDeployment(ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := createPoolWorkload(client, newPoolWorkload(dply, pool, nginxHostPorts), ownerRef); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateDeployFromYaml(client,
+		constant.NginxIngressAdmissionWebhookDeployment,
+		getPoolImage(ying, pool),
+		1,
+		nil,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 3. Create Service
+	svc, err := renderPoolService(constant.NginxIngressControllerService, ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := createPoolService(client, svc); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateServiceFromYaml(client,
+		constant.NginxIngressAdmissionWebhookService,
+		nil,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 4. Create ValidatingWebhookConfiguration
+	if err := yurtapputil.CreateValidatingWebhookConfigurationFromYaml(client,
+		constant.NginxIngressValidatingWebhookConfiguration,
+		ownerRef,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 5. Create Job, the certificates managed by yurt-app-manager are generated
+	// by EnsurePoolWebhookCert instead
+	if isControllerManagedWebhookCert(ying) {
+		return nil
+	}
+	if err := yurtapputil.CreateJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJob,
+		ingressWebhookCertGenImage,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 6. Create Job Patch
+	if err := yurtapputil.CreateJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJobPatch,
+		ingressWebhookCertGenImage,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// DeletePoolResource deletes the ingress controller, the admission webhook and
+// the jobs generating the webhook certificates on the pool.
+func (p *NginxProvider) DeletePoolResource(client client.Client, ying *appsv1alpha1.YurtIngress,
+	poolname string, cleanup bool) error {
+	namespace := GetNamespace(ying)
+	// 1. Delete Deployment and DaemonSet
+	if err := yurtapputil.DeleteDeployFromYaml(client,
+		constant.NginxIngressControllerNodePoolDeployment,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := deletePoolWorkload(client, &appsv1.DaemonSet{}, namespace,
+		poolname+"-ingress-nginx-controller"); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.DeleteDeployFromYaml(client,
+		constant.NginxIngressAdmissionWebhookDeployment,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Delete Service
+	if err := yurtapputil.DeleteServiceFromYaml(client,
+		constant.NginxIngressControllerService,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.DeleteServiceFromYaml(client,
+		constant.NginxIngressAdmissionWebhookService,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 3. Delete ValidatingWebhookConfiguration
+	if err := yurtapputil.DeleteValidatingWebhookConfigurationFromYaml(client,
+		constant.NginxIngressValidatingWebhookConfiguration,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 4. Delete Job
+	if err := yurtapputil.DeleteJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJob,
+		cleanup,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 5. Delete Job Patch
+	if err := yurtapputil.DeleteJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJobPatch,
+		cleanup,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 6. Delete ConfigMap
+	if err := deleteNginxPoolConfigMap(client, namespace, poolname); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 7. Delete Secret
+	secret := &corev1.Secret{}
+	secret.Namespace, secret.Name = namespace, poolname+"-ingress-nginx-admission"
+	if err := client.Delete(context.Background(), secret); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("fail to delete the secret/%s: %v", secret.Name, err)
+		return err
+	}
+	return nil
+}
+
+// UpdatePoolController updates the configuration and the workload of the
+// ingress controller, and the image of the admission webhook on the pool.
+func (p *NginxProvider) UpdatePoolController(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool, ownerRef *metav1.OwnerReference) error {
+	var webhookReplicas int32 = 1
+	namespace := GetNamespace(ying)
+	if err := applyNginxPoolConfigMap(client, namespace, pool); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	dply, err := renderNginxPoolDeployment(ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := updatePoolWorkload(client, newPoolWorkload(dply, pool, nginxHostPorts), ownerRef); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.UpdateDeployFromYaml(client,
+		constant.NginxIngressAdmissionWebhookDeployment,
+		getPoolImage(ying, pool),
+		&webhookReplicas,
+		poolTemplateContext(namespace, pool.Name)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// ScalePoolController scales the ingress controller on the pool.
+func (p *NginxProvider) ScalePoolController(client client.Client, ying *appsv1alpha1.YurtIngress,
+	poolname string, replicas int32) error {
+	namespace := GetNamespace(ying)
+	if err := yurtapputil.UpdateDeployFromYaml(client,
+		constant.NginxIngressControllerNodePoolDeployment,
+		"",
+		&replicas,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// UpdatePoolWebhookCertGen recreates the jobs generating the webhook certificates on the pool,
+// the jobs are only deleted if the webhook certificates are managed by yurt-app-manager.
+func (p *NginxProvider) UpdatePoolWebhookCertGen(client client.Client, ying *appsv1alpha1.YurtIngress, poolname string) error {
+	image := ying.Spec.IngressWebhookCertGenImage
+	namespace := GetNamespace(ying)
+	if err := yurtapputil.DeleteJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJob,
+		false,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.DeleteJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJobPatch,
+		false,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if isControllerManagedWebhookCert(ying) {
+		return nil
+	}
+	time.Sleep(3 * time.Second)
+	if err := yurtapputil.CreateJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJob,
+		image,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := yurtapputil.CreateJobFromYaml(client,
+		constant.NginxIngressAdmissionWebhookJobPatch,
+		image,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// EnsurePoolWebhookCert generates the webhook serving certificate of the pool signed
+// by the CA of yurt-app-manager, rotates the CA and the certificate before they
+// expire and patches the caBundle of the ValidatingWebhookConfiguration.
+func (p *NginxProvider) EnsurePoolWebhookCert(client client.Client, ying *appsv1alpha1.YurtIngress,
+	poolname string) (time.Duration, error) {
+	if !isControllerManagedWebhookCert(ying) {
+		return 0, nil
+	}
+	now := time.Now()
+	namespace := GetNamespace(ying)
+	ca, caBundle, err := ensureWebhookCA(client, namespace, nginxWebhookCASecret, now)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return 0, err
+	}
+	service := poolname + "-ingress-nginx-controller-admission"
+	hosts := []string{service, service + "." + namespace + ".svc"}
+	notAfter, err := ensureWebhookServingCert(client, namespace, poolname+"-ingress-nginx-admission",
+		hosts, ca, now)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return 0, err
+	}
+	if err := patchWebhookCABundle(client, poolname+"-ingress-nginx-admission", caBundle); err != nil {
+		klog.Errorf("%v", err)
+		return 0, err
+	}
+	requeueAfter := webhookCertRequeueAfter(notAfter, now)
+	if caRequeueAfter := webhookCertRequeueAfter(ca.cert.NotAfter, now); caRequeueAfter < requeueAfter {
+		requeueAfter = caRequeueAfter
+	}
+	return requeueAfter, nil
+}
+
+// UpdatePoolService updates the ingress controller service on the pool.
+func (p *NginxProvider) UpdatePoolService(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool) error {
+	svc, err := renderPoolService(constant.NginxIngressControllerService, ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := updatePoolService(client, svc); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// GetPoolReadiness checks the readiness of the ingress controller on the pool.
+func (p *NginxProvider) GetPoolReadiness(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool) (bool, *appsv1alpha1.IngressNotReadyConditionInfo, error) {
+	return getPoolReadiness(client, GetNamespace(ying), pool.Name+"-ingress-nginx-controller", ying, pool)
+}
+
+// renderNginxPoolDeployment renders the ingress controller deployment of the
+// pool, which uses the ConfigMap of the pool if the pool has its own configuration.
+func renderNginxPoolDeployment(ying *appsv1alpha1.YurtIngress, pool *appsv1alpha1.IngressPool) (*appsv1.Deployment, error) {
+	dply, err := renderPoolDeployment(constant.NginxIngressControllerNodePoolDeployment, ying, pool)
+	if err != nil {
+		return nil, err
+	}
+	if len(pool.ConfigMapData) == 0 {
+		return dply, nil
+	}
+	container := &dply.Spec.Template.Spec.Containers[len(dply.Spec.Template.Spec.Containers)-1]
+	for i, arg := range container.Args {
+		if strings.HasPrefix(arg, nginxConfigMapArg) {
+			container.Args[i] = nginxConfigMapArg + "$(POD_NAMESPACE)/" + pool.Name + "-ingress-nginx-controller"
+		}
+	}
+	return dply, nil
+}
+
+// renderNginxPoolConfigMap renders the ConfigMap of the pool, the data of the
+// pool overrides the default configuration of ingress-nginx.
+func renderNginxPoolConfigMap(namespace string, pool *appsv1alpha1.IngressPool) (*corev1.ConfigMap, error) {
+	content, err := yurtapputil.SubsituteTemplate(constant.NginxIngressControllerNodePoolConfigMap,
+		poolTemplateContext(namespace, pool.Name))
+	if err != nil {
+		return nil, err
+	}
+	obj, err := yurtapputil.YamlToObject([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return nil, fmt.Errorf("fail to assert configmap")
+	}
+	for k, v := range pool.ConfigMapData {
+		cm.Data[k] = v
+	}
+	return cm, nil
+}
+
+// applyNginxPoolConfigMap creates or updates the ConfigMap of the pool if the
+// pool has its own configuration, otherwise the ConfigMap is deleted.
+func applyNginxPoolConfigMap(c client.Client, namespace string, pool *appsv1alpha1.IngressPool) error {
+	if len(pool.ConfigMapData) == 0 {
+		return deleteNginxPoolConfigMap(c, namespace, pool.Name)
+	}
+	desired, err := renderNginxPoolConfigMap(namespace, pool)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	err = c.Get(context.Background(), client.ObjectKey{Namespace: desired.Namespace, Name: desired.Name}, cm)
+	if apierrors.IsNotFound(err) {
+		if err := c.Create(context.Background(), desired); err != nil {
+			return fmt.Errorf("fail to create the configmap/%s: %v", desired.Name, err)
+		}
+		klog.V(4).Infof("configmap/%s is created", desired.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	cm.Data = desired.Data
+	if err := c.Update(context.Background(), cm); err != nil {
+		return fmt.Errorf("fail to update the configmap/%s: %v", cm.Name, err)
+	}
+	klog.V(4).Infof("configmap/%s is updated", cm.Name)
+	return nil
+}
+
+// isControllerManagedWebhookCert checks if the webhook certificates are managed by
+// yurt-app-manager instead of the certgen jobs.
+func isControllerManagedWebhookCert(ying *appsv1alpha1.YurtIngress) bool {
+	return ying.Spec.WebhookCertManagement == appsv1alpha1.ControllerWebhookCertManagement
+}
+
+// deleteNginxPoolConfigMap deletes the ConfigMap of the pool.
+func deleteNginxPoolConfigMap(c client.Client, namespace, poolname string) error {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      poolname + "-ingress-nginx-controller",
+	}}
+	if err := c.Delete(context.Background(), cm); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("fail to delete the configmap/%s: %v", cm.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	yurtapputil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/kubernetes"
+)
+
+const (
+	httpServicePort  = "http"
+	httpsServicePort = "https"
+)
+
+// GetPoolReplicas returns the replicas of the ingress controller on the pool,
+// the replicas of the pool overrides the replicas per pool of YurtIngress.
+func GetPoolReplicas(ying *appsv1alpha1.YurtIngress, pool *appsv1alpha1.IngressPool) int32 {
+	if pool.Replicas != nil {
+		return *pool.Replicas
+	}
+	return ying.Spec.Replicas
+}
+
+// getPoolImage returns the ingress controller image used on the pool, the
+// image of the pool overrides the ingress controller image of YurtIngress.
+func getPoolImage(ying *appsv1alpha1.YurtIngress, pool *appsv1alpha1.IngressPool) string {
+	if pool.IngressControllerImage != "" {
+		return pool.IngressControllerImage
+	}
+	return ying.Spec.IngressControllerImage
+}
+
+// getPoolExposeMode returns how the ingress controller on the pool is exposed.
+func getPoolExposeMode(pool *appsv1alpha1.IngressPool) appsv1alpha1.IngressExposeMode {
+	if pool.Exposure == nil || pool.Exposure.Mode == "" {
+		return appsv1alpha1.NodePortExposeMode
+	}
+	return pool.Exposure.Mode
+}
+
+// isDaemonSetExposeMode checks if the ingress controller on the pool runs as a
+// DaemonSet, which is the case when it listens on the host.
+func isDaemonSetExposeMode(mode appsv1alpha1.IngressExposeMode) bool {
+	return mode == appsv1alpha1.HostNetworkExposeMode || mode == appsv1alpha1.HostPortExposeMode
+}
+
+// renderPoolDeployment renders the ingress controller deployment of the pool
+// from the template, and applies the overrides of the pool on it.
+func renderPoolDeployment(dplyTmpl string, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool) (*appsv1.Deployment, error) {
+	dp, err := yurtapputil.SubsituteTemplate(dplyTmpl, poolTemplateContext(GetNamespace(ying), pool.Name))
+	if err != nil {
+		return nil, err
+	}
+	dpObj, err := yurtapputil.YamlToObject([]byte(dp))
+	if err != nil {
+		return nil, err
+	}
+	dply, ok := dpObj.(*appsv1.Deployment)
+	if !ok {
+		return nil, fmt.Errorf("fail to assert deployment")
+	}
+
+	replicas := GetPoolReplicas(ying, pool)
+	dply.Spec.Replicas = &replicas
+	podSpec := &dply.Spec.Template.Spec
+	container := &podSpec.Containers[len(podSpec.Containers)-1]
+	if image := getPoolImage(ying, pool); image != "" {
+		container.Image = image
+	}
+	if pool.Resources != nil {
+		container.Resources = *pool.Resources.DeepCopy()
+	}
+	container.Args = append(container.Args, pool.ExtraArgs...)
+	// the node selector of the template keeps the ingress controller in the pool
+	for k, v := range pool.NodeSelector {
+		if _, exist := podSpec.NodeSelector[k]; !exist {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+	if pool.Tolerations != nil {
+		podSpec.Tolerations = nil
+		for i := range pool.Tolerations {
+			podSpec.Tolerations = append(podSpec.Tolerations, *pool.Tolerations[i].DeepCopy())
+		}
+	}
+	return dply, nil
+}
+
+// newPoolWorkload returns the workload running the ingress controller of the
+// pool. The rendered deployment is converted to a DaemonSet when the ingress
+// controller listens on the host, hostPorts maps the names of the container
+// ports to the host ports they listen on in the HostPort mode.
+func newPoolWorkload(dply *appsv1.Deployment, pool *appsv1alpha1.IngressPool,
+	hostPorts map[string]int32) client.Object {
+	mode := getPoolExposeMode(pool)
+	if !isDaemonSetExposeMode(mode) {
+		return dply
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: dply.ObjectMeta,
+		Spec: appsv1.DaemonSetSpec{
+			Selector:             dply.Spec.Selector,
+			Template:             dply.Spec.Template,
+			RevisionHistoryLimit: dply.Spec.RevisionHistoryLimit,
+		},
+	}
+	podSpec := &ds.Spec.Template.Spec
+	if mode == appsv1alpha1.HostNetworkExposeMode {
+		podSpec.HostNetwork = true
+		podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+		return ds
+	}
+	for i := range podSpec.Containers {
+		for j := range podSpec.Containers[i].Ports {
+			port := &podSpec.Containers[i].Ports[j]
+			if hostPort, ok := hostPorts[port.Name]; ok {
+				port.HostPort = hostPort
+			}
+		}
+	}
+	return ds
+}
+
+// createPoolWorkload creates the rendered workload of the ingress controller on the pool.
+func createPoolWorkload(c client.Client, obj client.Object, ownerRef *metav1.OwnerReference) error {
+	if ownerRef != nil {
+		obj.SetOwnerReferences(append(obj.GetOwnerReferences(), *ownerRef))
+	}
+	if err := c.Create(context.Background(), obj); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("fail to create the workload/%s: %v", obj.GetName(), err)
+		}
+	}
+	klog.V(4).Infof("workload/%s is created", obj.GetName())
+	return nil
+}
+
+// updatePoolWorkload updates the workload of the ingress controller on the pool
+// to the rendered one. When the expose mode is switched between the Deployment
+// and the DaemonSet, the old workload is deleted and the new one is created.
+func updatePoolWorkload(c client.Client, obj client.Object, ownerRef *metav1.OwnerReference) error {
+	switch desired := obj.(type) {
+	case *appsv1.Deployment:
+		if err := deletePoolWorkload(c, &appsv1.DaemonSet{}, desired.Namespace, desired.Name); err != nil {
+			return err
+		}
+		dply := &appsv1.Deployment{}
+		if err := c.Get(context.Background(), client.ObjectKey{Namespace: desired.Namespace, Name: desired.Name}, dply); err != nil {
+			if apierrors.IsNotFound(err) {
+				return createPoolWorkload(c, desired, ownerRef)
+			}
+			return err
+		}
+		dply.Spec.Replicas = desired.Spec.Replicas
+		dply.Spec.Template.Spec.Containers = desired.Spec.Template.Spec.Containers
+		dply.Spec.Template.Spec.NodeSelector = desired.Spec.Template.Spec.NodeSelector
+		dply.Spec.Template.Spec.Tolerations = desired.Spec.Template.Spec.Tolerations
+		if err := c.Update(context.Background(), dply); err != nil {
+			return fmt.Errorf("fail to update the deployment/%s: %v", dply.Name, err)
+		}
+		klog.V(4).Infof("deployment/%s is updated", dply.Name)
+	case *appsv1.DaemonSet:
+		if err := deletePoolWorkload(c, &appsv1.Deployment{}, desired.Namespace, desired.Name); err != nil {
+			return err
+		}
+		ds := &appsv1.DaemonSet{}
+		if err := c.Get(context.Background(), client.ObjectKey{Namespace: desired.Namespace, Name: desired.Name}, ds); err != nil {
+			if apierrors.IsNotFound(err) {
+				return createPoolWorkload(c, desired, ownerRef)
+			}
+			return err
+		}
+		ds.Spec.Template = desired.Spec.Template
+		if err := c.Update(context.Background(), ds); err != nil {
+			return fmt.Errorf("fail to update the daemonset/%s: %v", ds.Name, err)
+		}
+		klog.V(4).Infof("daemonset/%s is updated", ds.Name)
+	default:
+		return fmt.Errorf("unsupported workload %T", obj)
+	}
+	return nil
+}
+
+// deletePoolWorkload deletes the workload of the ingress controller on the pool.
+func deletePoolWorkload(c client.Client, obj client.Object, namespace, name string) error {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	if err := c.Delete(context.Background(), obj); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("fail to delete the workload/%s: %v", name, err)
+	}
+	return nil
+}
+
+// renderPoolService renders the Service of the ingress controller on the pool
+// from the template, and applies the ingress ips and the exposure of the pool on it.
+func renderPoolService(svcTmpl string, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool) (*corev1.Service, error) {
+	sv, err := yurtapputil.SubsituteTemplate(svcTmpl, poolTemplateContext(GetNamespace(ying), pool.Name))
+	if err != nil {
+		return nil, err
+	}
+	svcObj, err := yurtapputil.YamlToObject([]byte(sv))
+	if err != nil {
+		return nil, err
+	}
+	svc, ok := svcObj.(*corev1.Service)
+	if !ok {
+		return nil, fmt.Errorf("fail to assert service")
+	}
+	svc.Spec.ExternalIPs = pool.IngressIPs
+
+	mode := getPoolExposeMode(pool)
+	if isDaemonSetExposeMode(mode) {
+		// the ingress controller is reached on the nodes directly
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		return svc, nil
+	}
+	if mode == appsv1alpha1.LoadBalancerExposeMode {
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+	} else {
+		svc.Spec.Type = corev1.ServiceTypeNodePort
+	}
+	// the policy defaulted by the apiserver
+	svc.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+
+	exposure := pool.Exposure
+	if exposure == nil {
+		return svc, nil
+	}
+	if len(exposure.Annotations) > 0 && svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	for k, v := range exposure.Annotations {
+		svc.Annotations[k] = v
+	}
+	if exposure.ExternalTrafficPolicy != "" {
+		svc.Spec.ExternalTrafficPolicy = exposure.ExternalTrafficPolicy
+	}
+	if mode == appsv1alpha1.LoadBalancerExposeMode {
+		svc.Spec.LoadBalancerIP = exposure.LoadBalancerIP
+	}
+	for i := range svc.Spec.Ports {
+		switch svc.Spec.Ports[i].Name {
+		case httpServicePort:
+			svc.Spec.Ports[i].NodePort = exposure.HTTPNodePort
+		case httpsServicePort:
+			svc.Spec.Ports[i].NodePort = exposure.HTTPSNodePort
+		}
+	}
+	return svc, nil
+}
+
+// createPoolService creates the rendered Service of the ingress controller on the pool.
+func createPoolService(c client.Client, svc *corev1.Service) error {
+	if err := c.Create(context.Background(), svc); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("fail to create the service/%s: %v", svc.Name, err)
+		}
+	}
+	klog.V(4).Infof("service/%s is created", svc.Name)
+	return nil
+}
+
+// updatePoolService updates the Service of the ingress controller on the pool
+// in place to the rendered one. The node ports allocated by kubernetes are kept
+// unless they are fixed by the pool or no longer needed.
+func updatePoolService(c client.Client, desired *corev1.Service) error {
+	svc := &corev1.Service{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: desired.Namespace, Name: desired.Name}, svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return createPoolService(c, desired)
+		}
+		return err
+	}
+
+	ports := desired.Spec.Ports
+	if desired.Spec.Type != corev1.ServiceTypeClusterIP {
+		for i := range ports {
+			if ports[i].NodePort != 0 {
+				continue
+			}
+			for _, current := range svc.Spec.Ports {
+				if current.Name == ports[i].Name {
+					ports[i].NodePort = current.NodePort
+				}
+			}
+		}
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || desired.Spec.Type != corev1.ServiceTypeLoadBalancer ||
+		desired.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+		svc.Spec.HealthCheckNodePort = 0
+	}
+	if len(desired.Annotations) > 0 && svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	for k, v := range desired.Annotations {
+		svc.Annotations[k] = v
+	}
+	svc.Spec.Type = desired.Spec.Type
+	svc.Spec.Ports = ports
+	svc.Spec.ExternalIPs = desired.Spec.ExternalIPs
+	svc.Spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+	svc.Spec.LoadBalancerIP = desired.Spec.LoadBalancerIP
+	if err := c.Update(context.Background(), svc); err != nil {
+		return fmt.Errorf("fail to update the service/%s: %v", svc.Name, err)
+	}
+	klog.V(4).Infof("service/%s is updated", svc.Name)
+	return nil
+}
+
+// getPoolReadiness checks if the ingress controller on the pool is ready. The
+// workload and the Service of the ingress controller share the same name, and
+// the pool exposed by the load balancer is ready after the load balancer is provisioned.
+func getPoolReadiness(c client.Client, namespace, name string, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool) (bool, *appsv1alpha1.IngressNotReadyConditionInfo, error) {
+	var ready bool
+	var info *appsv1alpha1.IngressNotReadyConditionInfo
+	var err error
+	mode := getPoolExposeMode(pool)
+	if isDaemonSetExposeMode(mode) {
+		ready, info, err = getDaemonSetReadiness(c, namespace, name)
+	} else {
+		ready, info, err = getDeploymentReadiness(c, namespace, name, GetPoolReplicas(ying, pool))
+	}
+	if !ready || err != nil || mode != appsv1alpha1.LoadBalancerExposeMode {
+		return ready, info, err
+	}
+
+	svc := &corev1.Service{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	if len(svc.Status.LoadBalancer.Ingress) == 0 {
+		return false, &appsv1alpha1.IngressNotReadyConditionInfo{
+			Type:    appsv1alpha1.IngressPending,
+			Reason:  "LoadBalancerPending",
+			Message: fmt.Sprintf("the load balancer of service %s is not provisioned", name),
+		}, nil
+	}
+	return true, nil, nil
+}
+
+// getDaemonSetReadiness checks if the ingress controllers of the DaemonSet are
+// ready on all the nodes they are scheduled to.
+func getDaemonSetReadiness(c client.Client, namespace, name string) (bool,
+	*appsv1alpha1.IngressNotReadyConditionInfo, error) {
+	ds := &appsv1.DaemonSet{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, ds); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	if ds.Status.DesiredNumberScheduled > 0 && ds.Status.NumberReady == ds.Status.DesiredNumberScheduled {
+		return true, nil, nil
+	}
+	return false, &appsv1alpha1.IngressNotReadyConditionInfo{
+		Type:   appsv1alpha1.IngressPending,
+		Reason: "DaemonSetNotReady",
+		Message: fmt.Sprintf("%d of %d ingress controllers of daemonset %s are ready",
+			ds.Status.NumberReady, ds.Status.DesiredNumberScheduled, name),
+	}, nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// Provider deploys an ingress controller implementation on the nodepools of
+// YurtIngress. The common resources in a namespace are shared by all the pools
+// deployed in the namespace, and each pool has its own ingress controller and
+// the resources the ingress controller depends on.
+type Provider interface {
+	// IsCommonResourceReady checks if the common resources in the namespace have been created.
+	IsCommonResourceReady(c client.Client, namespace string) bool
+	// CreateCommonResource creates the resources shared by all the pools in the namespace.
+	CreateCommonResource(c client.Client, namespace string) error
+	// DeleteCommonResource deletes the resources shared by all the pools in the namespace,
+	// the cluster scoped resources shared by all the namespaces are deleted only if
+	// cleanupCluster is true.
+	DeleteCommonResource(c client.Client, namespace string, cleanupCluster bool) error
+	// CreatePoolResource creates the ingress controller and its resources on the pool.
+	CreatePoolResource(c client.Client, ying *appsv1alpha1.YurtIngress, pool *appsv1alpha1.IngressPool,
+		ownerRef *metav1.OwnerReference) error
+	// DeletePoolResource deletes the ingress controller and its resources on the pool,
+	// cleanup indicates the common resources are going to be deleted too.
+	DeletePoolResource(c client.Client, ying *appsv1alpha1.YurtIngress, poolName string, cleanup bool) error
+	// UpdatePoolController updates the ingress controller on the pool to the
+	// configuration of YurtIngress overridden by the pool, the workload of the
+	// ingress controller is recreated if the expose mode requires another kind.
+	UpdatePoolController(c client.Client, ying *appsv1alpha1.YurtIngress, pool *appsv1alpha1.IngressPool,
+		ownerRef *metav1.OwnerReference) error
+	// ScalePoolController scales the ingress controller on the pool.
+	ScalePoolController(c client.Client, ying *appsv1alpha1.YurtIngress, poolName string, replicas int32) error
+	// UpdatePoolWebhookCertGen regenerates the certificates of the admission webhook on the pool.
+	UpdatePoolWebhookCertGen(c client.Client, ying *appsv1alpha1.YurtIngress, poolName string) error
+	// EnsurePoolWebhookCert generates or rotates the certificates of the admission webhook
+	// on the pool if they are managed by yurt-app-manager, it returns when the certificates
+	// should be checked again, or zero if there is nothing to check.
+	EnsurePoolWebhookCert(c client.Client, ying *appsv1alpha1.YurtIngress, poolName string) (time.Duration, error)
+	// UpdatePoolService updates the ingress ips and the exposure of the ingress
+	// controller Service on the pool in place.
+	UpdatePoolService(c client.Client, ying *appsv1alpha1.YurtIngress, pool *appsv1alpha1.IngressPool) error
+	// GetPoolReadiness checks if the ingress controller on the pool is ready, the
+	// condition tells why the ingress controller is not ready if it is known.
+	GetPoolReadiness(c client.Client, ying *appsv1alpha1.YurtIngress, pool *appsv1alpha1.IngressPool) (bool,
+		*appsv1alpha1.IngressNotReadyConditionInfo, error)
+}
+
+// NewProviders returns all the supported providers.
+func NewProviders() map[appsv1alpha1.IngressProvider]Provider {
+	return map[appsv1alpha1.IngressProvider]Provider{
+		appsv1alpha1.NginxIngressProvider:   &NginxProvider{},
+		appsv1alpha1.TraefikIngressProvider: &TraefikProvider{},
+	}
+}
+
+// GetNamespace returns the namespace in which the ingress controllers of the YurtIngress are
+// deployed, YurtIngress created before the namespace is introduced uses the provider's namespace.
+func GetNamespace(ying *appsv1alpha1.YurtIngress) string {
+	if ying.Spec.Namespace != "" {
+		return ying.Spec.Namespace
+	}
+	if ying.Spec.Provider == appsv1alpha1.TraefikIngressProvider {
+		return traefikIngressNamespace
+	}
+	return nginxIngressNamespace
+}
+
+// commonTemplateContext returns the context to render the templates of the common resources.
+func commonTemplateContext(namespace string) map[string]string {
+	return map[string]string{"namespace": namespace}
+}
+
+// poolTemplateContext returns the context to render the templates of the pool resources.
+func poolTemplateContext(namespace, poolname string) map[string]string {
+	return map[string]string{"namespace": namespace, "nodepool_name": poolname}
+}
+
+// commonResourceOwnerReferences returns the owner references of the common
+// ingress resources. They are owned by yurt-app-manager-role so they can be
+// garbage collected when yurt-app-manager is deleted.
+func commonResourceOwnerReferences(c client.Client) []metav1.OwnerReference {
+	cr := new(rbacv1.ClusterRole)
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: "", Name: "yurt-app-manager-role"}, cr)
+	if err != nil {
+		klog.V(4).Infof("fail get yurt-app-manager role: %v", err)
+	}
+	isController := true
+	isBlockOwnerDeletion := true
+	ownerRef := metav1.OwnerReference{
+		APIVersion:         cr.APIVersion,
+		Kind:               cr.Kind,
+		Name:               cr.Name,
+		UID:                cr.UID,
+		Controller:         &isController,
+		BlockOwnerDeletion: &isBlockOwnerDeletion,
+	}
+	return []metav1.OwnerReference{ownerRef}
+}
+
+// isNamespaceReady checks if the namespace exists and is active.
+func isNamespaceReady(c client.Client, name string) bool {
+	ns := new(corev1.Namespace)
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: "", Name: name}, ns)
+	if err != nil {
+		return false
+	}
+	return ns.Status.Phase == corev1.NamespaceActive
+}
+
+// getDeploymentReadiness checks if the ingress controller deployment has the
+// desired ready replicas.
+func getDeploymentReadiness(c client.Client, namespace, name string, replicas int32) (bool,
+	*appsv1alpha1.IngressNotReadyConditionInfo, error) {
+	dply := &appsv1.Deployment{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, dply); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	if dply.Status.ReadyReplicas == replicas {
+		return true, nil, nil
+	}
+	return false, GetUnreadyDeploymentCondition(dply), nil
+}
+
+// GetUnreadyDeploymentCondition converts the latest condition of the deployment
+// to the condition of the ingress not ready pool.
+func GetUnreadyDeploymentCondition(dply *appsv1.Deployment) (info *appsv1alpha1.IngressNotReadyConditionInfo) {
+	len := len(dply.Status.Conditions)
+	if len == 0 {
+		return nil
+	}
+	var conditionInfo appsv1alpha1.IngressNotReadyConditionInfo
+	condition := dply.Status.Conditions[len-1]
+	if condition.Type == appsv1.DeploymentReplicaFailure {
+		conditionInfo.Type = appsv1alpha1.IngressFailure
+	} else {
+		conditionInfo.Type = appsv1alpha1.IngressPending
+	}
+	conditionInfo.LastTransitionTime = condition.LastTransitionTime
+	conditionInfo.Message = condition.Message
+	conditionInfo.Reason = condition.Reason
+	return &conditionInfo
+}
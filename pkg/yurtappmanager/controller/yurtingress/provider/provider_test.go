@@ -0,0 +1,694 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"reflect"
+	"testing"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+const (
+	failed  = "\u2717"
+	succeed = "\u2713"
+)
+
+func TestProviderPoolResource(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   Provider
+		namespace  string
+		controller string
+		service    string
+	}{
+		{
+			"nginx",
+			&NginxProvider{},
+			"ingress-nginx",
+			"hangzhou-ingress-nginx-controller",
+			"hangzhou-ingress-nginx-controller",
+		},
+		{
+			"traefik",
+			&TraefikProvider{},
+			"ingress-traefik",
+			"hangzhou-ingress-traefik-controller",
+			"hangzhou-ingress-traefik-controller",
+		},
+		{
+			"nginx in custom namespace",
+			&NginxProvider{},
+			"ingress-edge",
+			"hangzhou-ingress-nginx-controller",
+			"hangzhou-ingress-nginx-controller",
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				scheme := runtime.NewScheme()
+				_ = clientgoscheme.AddToScheme(scheme)
+				_ = alpha1.AddToScheme(scheme)
+				c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+				ying := &alpha1.YurtIngress{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-uid"},
+					Spec: alpha1.YurtIngressSpec{Namespace: st.namespace, Replicas: 2,
+						IngressControllerImage: "foo:v1"},
+				}
+				pool := &alpha1.IngressPool{Name: "hangzhou", IngressIPs: []string{"10.0.0.1"}}
+
+				if err := st.provider.CreateCommonResource(c, st.namespace); err != nil {
+					t.Fatalf("\t%s\tfail to create common resource, %v", failed, err)
+				}
+				ns := &corev1.Namespace{}
+				if err := c.Get(context.TODO(), client.ObjectKey{Name: st.namespace}, ns); err != nil {
+					t.Fatalf("\t%s\texpect namespace %s created, but get %v", failed, st.namespace, err)
+				}
+
+				if err := st.provider.CreatePoolResource(c, ying, pool, nil); err != nil {
+					t.Fatalf("\t%s\tfail to create pool resource, %v", failed, err)
+				}
+				svc := &corev1.Service{}
+				if err := c.Get(context.TODO(), client.ObjectKey{Namespace: st.namespace, Name: st.service}, svc); err != nil {
+					t.Fatalf("\t%s\texpect service %s created, but get %v", failed, st.service, err)
+				}
+				if !reflect.DeepEqual(svc.Spec.ExternalIPs, pool.IngressIPs) {
+					t.Fatalf("\t%s\texpect service external ips %v, but get %v", failed, pool.IngressIPs, svc.Spec.ExternalIPs)
+				}
+
+				dply := &appsv1.Deployment{}
+				key := client.ObjectKey{Namespace: st.namespace, Name: st.controller}
+				if err := c.Get(context.TODO(), key, dply); err != nil {
+					t.Fatalf("\t%s\texpect deployment %s created, but get %v", failed, st.controller, err)
+				}
+				containers := dply.Spec.Template.Spec.Containers
+				if *dply.Spec.Replicas != 2 || containers[len(containers)-1].Image != "foo:v1" {
+					t.Fatalf("\t%s\tunexpected deployment %v", failed, dply.Spec)
+				}
+
+				if ready, _, err := st.provider.GetPoolReadiness(c, ying, pool); err != nil || ready {
+					t.Fatalf("\t%s\texpect pool not ready, but get %v, %v", failed, ready, err)
+				}
+				dply.Status.ReadyReplicas = 2
+				if err := c.Status().Update(context.TODO(), dply); err != nil {
+					t.Fatal(err)
+				}
+				if ready, _, err := st.provider.GetPoolReadiness(c, ying, pool); err != nil || !ready {
+					t.Fatalf("\t%s\texpect pool ready, but get %v, %v", failed, ready, err)
+				}
+
+				if err := st.provider.DeletePoolResource(c, ying, pool.Name, true); err != nil {
+					t.Fatalf("\t%s\tfail to delete pool resource, %v", failed, err)
+				}
+				if ready, info, err := st.provider.GetPoolReadiness(c, ying, pool); err != nil || ready || info != nil {
+					t.Fatalf("\t%s\texpect pool not ready without condition, but get %v, %v, %v", failed, ready, info, err)
+				}
+				t.Logf("\t%s\tmanage pool resource of %s", succeed, st.name)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestNginxPoolOverrides(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	p := &NginxProvider{}
+
+	ying := &alpha1.YurtIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-uid"},
+		Spec:       alpha1.YurtIngressSpec{Replicas: 2, IngressControllerImage: "foo:v1"},
+	}
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")},
+	}
+	tolerations := []corev1.Toleration{{Key: "edge", Operator: corev1.TolerationOpExists}}
+	pool := &alpha1.IngressPool{
+		Name:                   "hangzhou",
+		Replicas:               utilpointer.Int32Ptr(1),
+		IngressControllerImage: "foo:v2",
+		Resources:              &resources,
+		NodeSelector:           map[string]string{"apps.openyurt.io/nodepool": "shanghai", "size": "small"},
+		Tolerations:            tolerations,
+		ConfigMapData:          map[string]string{"use-gzip": "true"},
+		ExtraArgs:              []string{"--enable-ssl-passthrough"},
+	}
+	if err := p.CreatePoolResource(c, ying, pool, nil); err != nil {
+		t.Fatalf("\t%s\tfail to create pool resource, %v", failed, err)
+	}
+
+	dply := &appsv1.Deployment{}
+	key := client.ObjectKey{Namespace: "ingress-nginx", Name: "hangzhou-ingress-nginx-controller"}
+	if err := c.Get(context.TODO(), key, dply); err != nil {
+		t.Fatal(err)
+	}
+	podSpec := dply.Spec.Template.Spec
+	container := podSpec.Containers[len(podSpec.Containers)-1]
+	if *dply.Spec.Replicas != 1 || container.Image != "foo:v2" || !reflect.DeepEqual(container.Resources, resources) {
+		t.Fatalf("\t%s\tunexpected deployment %v", failed, dply.Spec)
+	}
+	if podSpec.NodeSelector["apps.openyurt.io/nodepool"] != "hangzhou" || podSpec.NodeSelector["size"] != "small" {
+		t.Fatalf("\t%s\tunexpected node selector %v", failed, podSpec.NodeSelector)
+	}
+	if !reflect.DeepEqual(podSpec.Tolerations, tolerations) {
+		t.Fatalf("\t%s\tunexpected tolerations %v", failed, podSpec.Tolerations)
+	}
+	expectArgs := []string{
+		"/nginx-ingress-controller",
+		"--election-id=ingress-controller-leader-edge",
+		"--ingress-class=hangzhou",
+		"--configmap=$(POD_NAMESPACE)/hangzhou-ingress-nginx-controller",
+		"--enable-ssl-passthrough",
+	}
+	if !reflect.DeepEqual(container.Args, expectArgs) {
+		t.Fatalf("\t%s\texpect args %v, but get %v", failed, expectArgs, container.Args)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), key, cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["use-gzip"] != "true" || cm.Data["allow-snippet-annotations"] != "true" {
+		t.Fatalf("\t%s\tunexpected configmap data %v", failed, cm.Data)
+	}
+
+	dply.Status.ReadyReplicas = 1
+	if err := c.Status().Update(context.TODO(), dply); err != nil {
+		t.Fatal(err)
+	}
+	if ready, _, err := p.GetPoolReadiness(c, ying, pool); err != nil || !ready {
+		t.Fatalf("\t%s\texpect pool ready with its own replicas, but get %v, %v", failed, ready, err)
+	}
+
+	// the pool falls back to the configuration of YurtIngress without overrides
+	if err := p.UpdatePoolController(c, ying, &alpha1.IngressPool{Name: "hangzhou"}, nil); err != nil {
+		t.Fatalf("\t%s\tfail to update pool controller, %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), key, dply); err != nil {
+		t.Fatal(err)
+	}
+	podSpec = dply.Spec.Template.Spec
+	container = podSpec.Containers[len(podSpec.Containers)-1]
+	if *dply.Spec.Replicas != 2 || container.Image != "foo:v1" || len(container.Args) != 4 ||
+		container.Args[3] != "--configmap=$(POD_NAMESPACE)/ingress-nginx-controller" {
+		t.Fatalf("\t%s\tunexpected deployment %v", failed, dply.Spec)
+	}
+	if _, exist := podSpec.NodeSelector["size"]; exist || podSpec.Tolerations[0].Key != "" {
+		t.Fatalf("\t%s\texpect node selector and tolerations of template, but get %v, %v",
+			failed, podSpec.NodeSelector, podSpec.Tolerations)
+	}
+	if err := c.Get(context.TODO(), key, &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect configmap of the pool deleted, but get %v", failed, err)
+	}
+	t.Logf("\t%s\tapply pool overrides", succeed)
+}
+
+// provisionFakeLoadBalancers stands in for the load balancer provider of the
+// cluster, and assigns an ingress ip to all the LoadBalancer Services.
+func provisionFakeLoadBalancers(c client.Client) error {
+	svcList := &corev1.ServiceList{}
+	if err := c.List(context.TODO(), svcList); err != nil {
+		return err
+	}
+	for i := range svcList.Items {
+		svc := &svcList.Items[i]
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || len(svc.Status.LoadBalancer.Ingress) > 0 {
+			continue
+		}
+		ip := svc.Spec.LoadBalancerIP
+		if ip == "" {
+			ip = "192.0.2.1"
+		}
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: ip}}
+		if err := c.Status().Update(context.TODO(), svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPoolExposure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	p := &TraefikProvider{}
+
+	ying := &alpha1.YurtIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-uid"},
+		Spec: alpha1.YurtIngressSpec{Provider: alpha1.TraefikIngressProvider, Replicas: 1,
+			IngressControllerImage: "foo:v1"},
+	}
+	pool := &alpha1.IngressPool{
+		Name:       "hangzhou",
+		IngressIPs: []string{"10.0.0.1"},
+		Exposure: &alpha1.IngressPoolExposure{
+			Mode:                  alpha1.LoadBalancerExposeMode,
+			HTTPNodePort:          30080,
+			LoadBalancerIP:        "192.168.0.100",
+			Annotations:           map[string]string{"lb.example.com/internal": "true"},
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+		},
+	}
+	if err := p.CreatePoolResource(c, ying, pool, nil); err != nil {
+		t.Fatalf("\t%s\tfail to create pool resource, %v", failed, err)
+	}
+
+	key := client.ObjectKey{Namespace: "ingress-traefik", Name: "hangzhou-ingress-traefik-controller"}
+	svc := &corev1.Service{}
+	if err := c.Get(context.TODO(), key, svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || svc.Spec.LoadBalancerIP != "192.168.0.100" ||
+		svc.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal ||
+		svc.Spec.Ports[0].NodePort != 30080 || svc.Spec.Ports[1].NodePort != 0 ||
+		svc.Annotations["lb.example.com/internal"] != "true" {
+		t.Fatalf("\t%s\tunexpected service %v", failed, svc)
+	}
+
+	dply := &appsv1.Deployment{}
+	if err := c.Get(context.TODO(), key, dply); err != nil {
+		t.Fatal(err)
+	}
+	dply.Status.ReadyReplicas = 1
+	if err := c.Status().Update(context.TODO(), dply); err != nil {
+		t.Fatal(err)
+	}
+	ready, info, err := p.GetPoolReadiness(c, ying, pool)
+	if err != nil || ready || info == nil || info.Reason != "LoadBalancerPending" {
+		t.Fatalf("\t%s\texpect pool waiting for load balancer, but get %v, %v, %v", failed, ready, info, err)
+	}
+	if err := provisionFakeLoadBalancers(c); err != nil {
+		t.Fatal(err)
+	}
+	if ready, _, err := p.GetPoolReadiness(c, ying, pool); err != nil || !ready {
+		t.Fatalf("\t%s\texpect pool ready, but get %v, %v", failed, ready, err)
+	}
+
+	// switch to the HostPort mode in place
+	pool.Exposure = &alpha1.IngressPoolExposure{Mode: alpha1.HostPortExposeMode}
+	if err := p.UpdatePoolController(c, ying, pool, nil); err != nil {
+		t.Fatalf("\t%s\tfail to update pool controller, %v", failed, err)
+	}
+	if err := p.UpdatePoolService(c, ying, pool); err != nil {
+		t.Fatalf("\t%s\tfail to update pool service, %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), key, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect deployment deleted, but get %v", failed, err)
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := c.Get(context.TODO(), key, ds); err != nil {
+		t.Fatal(err)
+	}
+	hostPorts := map[string]int32{}
+	for _, port := range ds.Spec.Template.Spec.Containers[0].Ports {
+		hostPorts[port.Name] = port.HostPort
+	}
+	if !reflect.DeepEqual(hostPorts, map[string]int32{"traefik": 0, "web": 80, "websecure": 443}) {
+		t.Fatalf("\t%s\tunexpected host ports %v", failed, hostPorts)
+	}
+	if err := c.Get(context.TODO(), key, svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP || svc.Spec.ExternalTrafficPolicy != "" ||
+		svc.Spec.Ports[0].NodePort != 0 || svc.Spec.LoadBalancerIP != "" ||
+		!reflect.DeepEqual(svc.Spec.ExternalIPs, pool.IngressIPs) {
+		t.Fatalf("\t%s\tunexpected service %v", failed, svc.Spec)
+	}
+
+	if ready, _, err := p.GetPoolReadiness(c, ying, pool); err != nil || ready {
+		t.Fatalf("\t%s\texpect pool not ready, but get %v, %v", failed, ready, err)
+	}
+	ds.Status.DesiredNumberScheduled = 2
+	ds.Status.NumberReady = 2
+	if err := c.Status().Update(context.TODO(), ds); err != nil {
+		t.Fatal(err)
+	}
+	if ready, _, err := p.GetPoolReadiness(c, ying, pool); err != nil || !ready {
+		t.Fatalf("\t%s\texpect pool ready, but get %v, %v", failed, ready, err)
+	}
+
+	// switch back to the NodePort mode
+	pool.Exposure = nil
+	if err := p.UpdatePoolController(c, ying, pool, nil); err != nil {
+		t.Fatalf("\t%s\tfail to update pool controller, %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), key, &appsv1.DaemonSet{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect daemonset deleted, but get %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), key, &appsv1.Deployment{}); err != nil {
+		t.Fatalf("\t%s\texpect deployment created, but get %v", failed, err)
+	}
+
+	if err := p.DeletePoolResource(c, ying, pool.Name, true); err != nil {
+		t.Fatalf("\t%s\tfail to delete pool resource, %v", failed, err)
+	}
+	t.Logf("\t%s\texpose pool", succeed)
+}
+
+func TestGetUnreadyDeploymentCondition(t *testing.T) {
+	type Result struct {
+		conditionType alpha1.IngressNotReadyType
+	}
+
+	tests := []struct {
+		name   string
+		dply   *appsv1.Deployment
+		expect *Result
+	}{
+		{
+			"nil",
+			&appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{},
+			},
+			nil,
+		},
+		{
+			"fail",
+			&appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{
+					Conditions: []appsv1.DeploymentCondition{
+						{
+							Type: appsv1.DeploymentReplicaFailure,
+						},
+					},
+				},
+			},
+			&Result{
+				conditionType: alpha1.IngressFailure,
+			},
+		},
+		{
+			"pending",
+			&appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{
+					Conditions: []appsv1.DeploymentCondition{
+						{
+							Type: appsv1.DeploymentAvailable,
+						},
+					},
+				},
+			},
+			&Result{
+				conditionType: alpha1.IngressPending,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", tt.name)
+
+			get := GetUnreadyDeploymentCondition(tt.dply)
+			if get == nil {
+				if tt.expect != nil {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, tt.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, tt.expect, get)
+			} else {
+				result := Result{
+					get.Type,
+				}
+				if !reflect.DeepEqual(&result, tt.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, tt.expect, result)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, tt.expect, result)
+			}
+		})
+	}
+}
+
+func TestNginxWebhookCert(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	p := &NginxProvider{}
+
+	ying := &alpha1.YurtIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-uid"},
+		Spec: alpha1.YurtIngressSpec{
+			Replicas:               1,
+			IngressControllerImage: "foo:v1",
+			WebhookCertManagement:  alpha1.ControllerWebhookCertManagement,
+		},
+	}
+	pool := &alpha1.IngressPool{Name: "hangzhou"}
+	if err := p.CreateCommonResource(c, "ingress-nginx"); err != nil {
+		t.Fatalf("\t%s\tfail to create common resource, %v", failed, err)
+	}
+	if err := p.CreatePoolResource(c, ying, pool, nil); err != nil {
+		t.Fatalf("\t%s\tfail to create pool resource, %v", failed, err)
+	}
+	jobs := &batchv1.JobList{}
+	if err := c.List(context.TODO(), jobs); err != nil || len(jobs.Items) != 0 {
+		t.Fatalf("\t%s\texpect no certgen job, but get %v, %v", failed, jobs.Items, err)
+	}
+
+	// a secret generated by the certgen jobs is replaced
+	secretKey := client.ObjectKey{Namespace: "ingress-nginx", Name: "hangzhou-ingress-nginx-admission"}
+	if err := c.Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretKey.Namespace, Name: secretKey.Name},
+		Data:       map[string][]byte{"ca": []byte("foo"), "cert": []byte("foo"), "key": []byte("foo")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := p.EnsurePoolWebhookCert(c, ying, pool.Name)
+	if err != nil || after <= 0 || after > webhookCertCheckInterval {
+		t.Fatalf("\t%s\tfail to ensure webhook cert, %v, %v", failed, after, err)
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(context.TODO(), secretKey, secret); err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(secret.Data["ca"]) {
+		t.Fatalf("\t%s\tinvalid ca %s", failed, secret.Data["ca"])
+	}
+	serving, err := parseWebhookCert(secret.Data["cert"], secret.Data["key"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := serving.cert.Verify(x509.VerifyOptions{
+		DNSName: "hangzhou-ingress-nginx-controller-admission.ingress-nginx.svc",
+		Roots:   roots,
+	}); err != nil {
+		t.Fatalf("\t%s\texpect serving cert verified, but get %v", failed, err)
+	}
+	vwc := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "hangzhou-ingress-nginx-admission"}, vwc); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(vwc.Webhooks[0].ClientConfig.CABundle, secret.Data["ca"]) {
+		t.Fatalf("\t%s\texpect caBundle %s, but get %s", failed, secret.Data["ca"], vwc.Webhooks[0].ClientConfig.CABundle)
+	}
+
+	// the certificate is kept until it is about to expire
+	if _, err := p.EnsurePoolWebhookCert(c, ying, pool.Name); err != nil {
+		t.Fatal(err)
+	}
+	kept := &corev1.Secret{}
+	if err := c.Get(context.TODO(), secretKey, kept); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(kept.Data["cert"], secret.Data["cert"]) {
+		t.Fatalf("\t%s\texpect serving cert kept", failed)
+	}
+	ca, _, err := ensureWebhookCA(c, "ingress-nginx", nginxWebhookCASecret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := []string{"hangzhou-ingress-nginx-controller-admission"}
+	if _, err := ensureWebhookServingCert(c, secretKey.Namespace, secretKey.Name, hosts, ca,
+		serving.cert.NotAfter.Add(-webhookCertRotateBefore/2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.TODO(), secretKey, kept); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(kept.Data["cert"], secret.Data["cert"]) {
+		t.Fatalf("\t%s\texpect serving cert rotated", failed)
+	}
+
+	// the rotated CA is still trusted until it expires
+	rotated, bundle, err := ensureWebhookCA(c, "ingress-nginx", nginxWebhookCASecret,
+		ca.cert.NotAfter.Add(-webhookCertRotateBefore/2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(rotated.certPEM, ca.certPEM) || !bytes.Equal(bundle, append(rotated.certPEM, ca.certPEM...)) {
+		t.Fatalf("\t%s\texpect CA rotated and bundled with the previous CA", failed)
+	}
+
+	if err := p.DeletePoolResource(c, ying, pool.Name, true); err != nil {
+		t.Fatalf("\t%s\tfail to delete pool resource, %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), secretKey, kept); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect secret deleted, but get %v", failed, err)
+	}
+	t.Logf("\t%s\tmanage webhook cert of nginx", succeed)
+}
+
+func TestProviderCommonResource(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	p := &NginxProvider{}
+
+	for _, ns := range []string{"ingress-nginx", "ingress-edge"} {
+		if err := p.CreateCommonResource(c, ns); err != nil {
+			t.Fatalf("\t%s\tfail to create common resource in %s, %v", failed, ns, err)
+		}
+	}
+	crb := &rbacv1.ClusterRoleBinding{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-nginx-ingress-edge"}, crb); err != nil {
+		t.Fatal(err)
+	}
+	if crb.Subjects[0].Namespace != "ingress-edge" {
+		t.Fatalf("\t%s\texpect subject in ingress-edge, but get %v", failed, crb.Subjects)
+	}
+	if _, _, err := ensureWebhookCA(c, "ingress-edge", nginxWebhookCASecret, time.Now()); err != nil {
+		t.Fatalf("\t%s\tfail to create webhook CA, %v", failed, err)
+	}
+
+	if err := p.DeleteCommonResource(c, "ingress-edge", false); err != nil {
+		t.Fatalf("\t%s\tfail to delete common resource, %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "ingress-edge", Name: nginxWebhookCASecret},
+		&corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect webhook CA secret deleted, but get %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-nginx-ingress-edge"}, crb); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect clusterrolebinding deleted, but get %v", failed, err)
+	}
+	key := client.ObjectKey{Name: "ingress-nginx"}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-nginx-ingress-nginx"}, &rbacv1.ClusterRoleBinding{}); err != nil {
+		t.Fatalf("\t%s\texpect clusterrolebinding of another namespace kept, but get %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), key, &rbacv1.ClusterRole{}); err != nil {
+		t.Fatalf("\t%s\texpect clusterrole kept, but get %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "ingress-nginx", Name: "ingress-nginx"},
+		&corev1.ServiceAccount{}); err != nil {
+		t.Fatalf("\t%s\texpect serviceaccount of another namespace kept, but get %v", failed, err)
+	}
+
+	if err := p.DeleteCommonResource(c, "ingress-nginx", true); err != nil {
+		t.Fatalf("\t%s\tfail to delete common resource, %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), key, &rbacv1.ClusterRole{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect clusterrole deleted, but get %v", failed, err)
+	}
+	t.Logf("\t%s\tshare common resource of nginx", succeed)
+}
+
+func TestProviderNamespaceOwnership(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = alpha1.AddToScheme(scheme)
+	existing := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress-existing"},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+	p := &TraefikProvider{}
+
+	for _, ns := range []string{"ingress-existing", "ingress-created"} {
+		if err := p.CreateCommonResource(c, ns); err != nil {
+			t.Fatalf("\t%s\tfail to create common resource in %s, %v", failed, ns, err)
+		}
+		if err := p.DeleteCommonResource(c, ns, false); err != nil {
+			t.Fatalf("\t%s\tfail to delete common resource in %s, %v", failed, ns, err)
+		}
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-existing"}, &corev1.Namespace{}); err != nil {
+		t.Fatalf("\t%s\texpect existing namespace kept, but get %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-created"}, &corev1.Namespace{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect created namespace deleted, but get %v", failed, err)
+	}
+	t.Logf("\t%s\tdelete the namespace created by the provider only", succeed)
+}
+
+func TestProviderLegacyCommonResource(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = alpha1.AddToScheme(scheme)
+	legacyOwner := []metav1.OwnerReference{{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole",
+		Name: "yurt-app-manager-role", UID: "uid"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		// created by the earlier versions, which only set the owner reference
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ingress-nginx", OwnerReferences: legacyOwner}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "ingress-nginx", OwnerReferences: legacyOwner}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "ingress-nginx-admission", OwnerReferences: legacyOwner}},
+		// created by the users
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ingress-traefik"}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "ingress-traefik"}},
+	).Build()
+
+	if err := (&NginxProvider{}).DeleteCommonResource(c, "ingress-nginx", false); err != nil {
+		t.Fatalf("\t%s\tfail to delete nginx common resource, %v", failed, err)
+	}
+	if err := (&TraefikProvider{}).DeleteCommonResource(c, "ingress-traefik", false); err != nil {
+		t.Fatalf("\t%s\tfail to delete traefik common resource, %v", failed, err)
+	}
+
+	for _, name := range []string{"ingress-nginx", "ingress-nginx-admission"} {
+		if err := c.Get(context.TODO(), client.ObjectKey{Name: name}, &rbacv1.ClusterRoleBinding{}); !errors.IsNotFound(err) {
+			t.Fatalf("\t%s\texpect legacy clusterrolebinding %s deleted, but get %v", failed, name, err)
+		}
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-nginx"}, &corev1.Namespace{}); !errors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect legacy namespace deleted, but get %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-traefik"}, &rbacv1.ClusterRoleBinding{}); err != nil {
+		t.Fatalf("\t%s\texpect clusterrolebinding of the user kept, but get %v", failed, err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "ingress-traefik"}, &corev1.Namespace{}); err != nil {
+		t.Fatalf("\t%s\texpect namespace of the user kept, but get %v", failed, err)
+	}
+	t.Logf("\t%s\tdelete the legacy common resources created by yurt-app-manager only", succeed)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/constant"
+	yurtapputil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/kubernetes"
+)
+
+// traefikIngressNamespace is the default namespace of traefik.
+const traefikIngressNamespace = "ingress-traefik"
+
+// traefikHostPorts maps the container ports of traefik to the host ports in the HostPort mode.
+var traefikHostPorts = map[string]int32{"web": 80, "websecure": 443}
+
+// TraefikProvider deploys traefik on the nodepools. The traefik of each pool
+// serves the ingresses whose ingress class is the name of the pool, and no
+// admission webhook is deployed.
+type TraefikProvider struct{}
+
+var _ Provider = &TraefikProvider{}
+
+// IsCommonResourceReady checks if the namespace of traefik is active.
+func (p *TraefikProvider) IsCommonResourceReady(c client.Client, namespace string) bool {
+	return isNamespaceReady(c, namespace)
+}
+
+// CreateCommonResource creates the namespace and rbac of traefik.
+func (p *TraefikProvider) CreateCommonResource(cli client.Client, namespace string) error {
+	ownerRefs := commonResourceOwnerReferences(cli)
+	ctx := commonTemplateContext(namespace)
+
+	// 1. Create Namespace
+	if err := yurtapputil.CreateNamespaceFromYaml(cli, constant.TraefikIngressControllerNamespace, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Create ClusterRole
+	if err := yurtapputil.CreateClusterRoleFromYaml(cli, constant.TraefikIngressControllerClusterRole, ownerRefs); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 3. Create ClusterRoleBinding
+	if err := yurtapputil.CreateClusterRoleBindingFromYaml(cli,
+		constant.TraefikIngressControllerClusterRoleBinding, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 4. Create ServiceAccount
+	if err := yurtapputil.CreateServiceAccountFromYaml(cli,
+		constant.TraefikIngressControllerServiceAccount, ownerRefs, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteCommonResource deletes the namespace and rbac of traefik, the ClusterRole
+// is deleted only if cleanupCluster is true.
+func (p *TraefikProvider) DeleteCommonResource(client client.Client, namespace string, cleanupCluster bool) error {
+	ctx := commonTemplateContext(namespace)
+	// 1. Delete ClusterRoleBinding
+	if err := yurtapputil.DeleteClusterRoleBindingFromYaml(client,
+		constant.TraefikIngressControllerClusterRoleBinding, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// the ClusterRoleBinding was named after the namespace before
+	if err := yurtapputil.DeleteLegacyClusterRoleBinding(client, namespace); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Delete ClusterRole
+	if cleanupCluster {
+		if err := yurtapputil.DeleteClusterRoleFromYaml(client, constant.TraefikIngressControllerClusterRole); err != nil {
+			klog.Errorf("%v", err)
+			return err
+		}
+	}
+	// 3. Delete ServiceAccount
+	if err := yurtapputil.DeleteServiceAccountFromYaml(client,
+		constant.TraefikIngressControllerServiceAccount, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 4. Delete Namespace
+	if err := yurtapputil.DeleteNamespaceFromYaml(client, constant.TraefikIngressControllerNamespace, ctx); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// CreatePoolResource creates the traefik workload and service on the pool.
+func (p *TraefikProvider) CreatePoolResource(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool, ownerRef *metav1.OwnerReference) error {
+	// 1. Create Deployment or DaemonSet
+	dply, err := renderPoolDeployment(constant.TraefikIngressControllerNodePoolDeployment, ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := createPoolWorkload(client, newPoolWorkload(dply, pool, traefikHostPorts), ownerRef); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Create Service
+	svc, err := renderPoolService(constant.TraefikIngressControllerService, ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := createPoolService(client, svc); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// DeletePoolResource deletes the traefik workload and service on the pool.
+func (p *TraefikProvider) DeletePoolResource(client client.Client, ying *appsv1alpha1.YurtIngress,
+	poolname string, cleanup bool) error {
+	namespace := GetNamespace(ying)
+	// 1. Delete Deployment and DaemonSet
+	if err := yurtapputil.DeleteDeployFromYaml(client,
+		constant.TraefikIngressControllerNodePoolDeployment,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := deletePoolWorkload(client, &appsv1.DaemonSet{}, namespace,
+		poolname+"-ingress-traefik-controller"); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	// 2. Delete Service
+	if err := yurtapputil.DeleteServiceFromYaml(client,
+		constant.TraefikIngressControllerService,
+		poolTemplateContext(namespace, poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// UpdatePoolController updates the traefik workload on the pool.
+func (p *TraefikProvider) UpdatePoolController(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool, ownerRef *metav1.OwnerReference) error {
+	dply, err := renderPoolDeployment(constant.TraefikIngressControllerNodePoolDeployment, ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := updatePoolWorkload(client, newPoolWorkload(dply, pool, traefikHostPorts), ownerRef); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// ScalePoolController scales traefik on the pool.
+func (p *TraefikProvider) ScalePoolController(client client.Client, ying *appsv1alpha1.YurtIngress,
+	poolname string, replicas int32) error {
+	if err := yurtapputil.UpdateDeployFromYaml(client,
+		constant.TraefikIngressControllerNodePoolDeployment,
+		"",
+		&replicas,
+		poolTemplateContext(GetNamespace(ying), poolname)); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// UpdatePoolWebhookCertGen does nothing, traefik has no admission webhook.
+func (p *TraefikProvider) UpdatePoolWebhookCertGen(client client.Client, ying *appsv1alpha1.YurtIngress, poolname string) error {
+	return nil
+}
+
+// EnsurePoolWebhookCert does nothing, traefik has no admission webhook.
+func (p *TraefikProvider) EnsurePoolWebhookCert(client client.Client, ying *appsv1alpha1.YurtIngress,
+	poolname string) (time.Duration, error) {
+	return 0, nil
+}
+
+// UpdatePoolService updates the traefik service on the pool.
+func (p *TraefikProvider) UpdatePoolService(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool) error {
+	svc, err := renderPoolService(constant.TraefikIngressControllerService, ying, pool)
+	if err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	if err := updatePoolService(client, svc); err != nil {
+		klog.Errorf("%v", err)
+		return err
+	}
+	return nil
+}
+
+// GetPoolReadiness checks the readiness of traefik on the pool.
+func (p *TraefikProvider) GetPoolReadiness(client client.Client, ying *appsv1alpha1.YurtIngress,
+	pool *appsv1alpha1.IngressPool) (bool, *appsv1alpha1.IngressNotReadyConditionInfo, error) {
+	return getPoolReadiness(client, GetNamespace(ying), pool.Name+"-ingress-traefik-controller", ying, pool)
+}
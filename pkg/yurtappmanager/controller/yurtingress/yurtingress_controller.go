@@ -18,8 +18,11 @@ package yurtingress
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -36,14 +39,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtingress/provider"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/gate"
-	yurtapputil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/kubernetes"
-	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/refmanager"
 )
 
 const (
-	controllerName         = "yurtingress-controller"
-	ingressDeploymentLabel = "yurtingress.io/nodepool"
+	controllerName = "yurtingress-controller"
 )
 
 const updateRetries = 5
@@ -53,8 +54,9 @@ var concurrentReconciles = 3
 // YurtIngressReconciler reconciles a YurtIngress object
 type YurtIngressReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	Scheme    *runtime.Scheme
+	recorder  record.EventRecorder
+	providers map[appsv1alpha1.IngressProvider]provider.Provider
 }
 
 // Add creates a new YurtIngress Controller and adds it to the Manager with default RBAC.
@@ -70,9 +72,10 @@ func Add(mgr manager.Manager, ctx context.Context) error {
 //func newReconciler(mgr manager.Manager, createSingletonPoolIngress bool) reconcile.Reconciler {
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 	return &YurtIngressReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		recorder: mgr.GetEventRecorderFor(controllerName),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor(controllerName),
+		providers: provider.NewProviders(),
 	}
 }
 
@@ -100,6 +103,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	if err != nil {
 		return err
 	}
+	err = c.Watch(&source.Kind{Type: &appsv1.DaemonSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &appsv1alpha1.YurtIngress{},
+	})
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -136,48 +146,51 @@ func (r *YurtIngressReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{}, err
 		}
 	}
+	p, err := r.getProvider(instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 	// Handle ingress controller resources cleanup
 	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
-		return r.cleanupIngressResources(instance)
+		return r.cleanupIngressResources(instance, p)
 	}
 
 	var desiredPools, currentPools []appsv1alpha1.IngressPool
 	desiredPools = getDesiredPools(instance)
 	currentPools = getCurrentPools(instance)
 	isYurtIngressCRChanged := false
+	namespace := provider.GetNamespace(instance)
 
 	addedPools, removedPools, unchangedPools := getPools(desiredPools, currentPools)
 	if addedPools != nil {
-		klog.V(4).Infof("added pool list is %s", addedPools)
+		klog.V(4).Infof("added pool list is %v", addedPools)
 		isYurtIngressCRChanged = true
 		ownerRef := prepareDeploymentOwnerReferences(instance)
-		if currentPools == nil && !yurtapputil.IsIngressNamespaceReady(r.Client) {
-			if err := yurtapputil.CreateNginxIngressCommonResource(r.Client); err != nil {
+		if currentPools == nil && !p.IsCommonResourceReady(r.Client, namespace) {
+			if err := p.CreateCommonResource(r.Client, namespace); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
-		replicas := instance.Spec.Replicas
-		ingress_controller_image := instance.Spec.IngressControllerImage
-		ingress_webhook_certgen_image := instance.Spec.IngressWebhookCertGenImage
-		for _, pool := range addedPools {
-			if err := yurtapputil.CreateNginxIngressSpecificResource(r.Client, pool.Name, &pool.IngressIPs, ingress_controller_image, ingress_webhook_certgen_image, replicas, ownerRef); err != nil {
+		for i := range addedPools {
+			pool := addedPools[i]
+			if err := p.CreatePoolResource(r.Client, instance, &pool, ownerRef); err != nil {
 				return ctrl.Result{}, err
 			}
-			notReadyPool := appsv1alpha1.IngressNotReadyPool{Pool: appsv1alpha1.IngressPool{Name: pool.Name, IngressIPs: pool.IngressIPs}, Info: nil}
+			notReadyPool := appsv1alpha1.IngressNotReadyPool{Pool: pool, Info: nil}
 			instance.Status.Conditions.IngressNotReadyPools = append(instance.Status.Conditions.IngressNotReadyPools, notReadyPool)
 			instance.Status.UnreadyNum += 1
 		}
 	}
 	if removedPools != nil {
-		klog.V(4).Infof("removed pool list is %s", removedPools)
+		klog.V(4).Infof("removed pool list is %v", removedPools)
 		isYurtIngressCRChanged = true
 		for _, pool := range removedPools {
 			if desiredPools == nil {
-				if err := yurtapputil.DeleteNginxIngressSpecificResource(r.Client, pool.Name, true); err != nil {
+				if err := p.DeletePoolResource(r.Client, instance, pool.Name, true); err != nil {
 					return ctrl.Result{}, err
 				}
 			} else {
-				if err := yurtapputil.DeleteNginxIngressSpecificResource(r.Client, pool.Name, false); err != nil {
+				if err := p.DeletePoolResource(r.Client, instance, pool.Name, false); err != nil {
 					return ctrl.Result{}, err
 				}
 			}
@@ -185,10 +198,16 @@ func (r *YurtIngressReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				klog.V(4).Infof("Pool/%s is not found from conditions!", pool.Name)
 			}
 		}
-		if desiredPools == nil && isOnlyYurtIngressCR(r.Client) {
-			if err := yurtapputil.DeleteNginxIngressCommonResource(r.Client); err != nil {
+		if desiredPools == nil {
+			namespaceRefs, providerRefs, err := getCommonResourceRefs(r.Client, instance)
+			if err != nil {
 				return ctrl.Result{}, err
 			}
+			if namespaceRefs == 0 {
+				if err := p.DeleteCommonResource(r.Client, namespace, providerRefs == 0); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
 			instance.Status.Conditions.IngressReadyPools = nil
 			instance.Status.Conditions.IngressNotReadyPools = nil
 			instance.Status.ReadyNum = 0
@@ -196,55 +215,101 @@ func (r *YurtIngressReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 	if unchangedPools != nil {
-		klog.V(4).Infof("unchanged pool list is %s", unchangedPools)
+		klog.V(4).Infof("unchanged pool list is %v", unchangedPools)
 		desiredReplicas := instance.Spec.Replicas
 		currentReplicas := instance.Status.Replicas
 		desiredIngressControllerImage := instance.Spec.IngressControllerImage
 		currentIngressControllerImage := instance.Status.IngressControllerImage
-		desiredNginxWebhookCertGenImage := instance.Spec.IngressWebhookCertGenImage
-		currentNginxWebhookCertGenImage := instance.Status.IngressWebhookCertGenImage
+		desiredWebhookCertGenImage := instance.Spec.IngressWebhookCertGenImage
+		currentWebhookCertGenImage := instance.Status.IngressWebhookCertGenImage
 		if desiredIngressControllerImage != currentIngressControllerImage {
 			klog.V(4).Infof("Ingress controller image is changed!")
 			isYurtIngressCRChanged = true
 			instance.Status.ReadyNum = 0
 			instance.Status.UnreadyNum = int32(len(instance.Spec.Pools))
-			for _, pool := range unchangedPools {
-				if err := yurtapputil.UpdateNginxIngressControllerDeploymment(r.Client, pool.Name, desiredReplicas, desiredIngressControllerImage); err != nil {
+			ownerRef := prepareDeploymentOwnerReferences(instance)
+			for i := range unchangedPools {
+				if err := p.UpdatePoolController(r.Client, instance, &unchangedPools[i], ownerRef); err != nil {
 					return ctrl.Result{}, err
 				}
 			}
 		} else if desiredReplicas != currentReplicas {
 			klog.V(4).Infof("Ingress controller replicas is changed!")
 			isYurtIngressCRChanged = true
-			for _, pool := range unchangedPools {
-				if err := yurtapputil.ScaleNginxIngressControllerDeploymment(r.Client, pool.Name, desiredReplicas); err != nil {
+			for i, pool := range unchangedPools {
+				if err := p.ScalePoolController(r.Client, instance, pool.Name,
+					provider.GetPoolReplicas(instance, &unchangedPools[i])); err != nil {
 					return ctrl.Result{}, err
 				}
 			}
 		}
-		if desiredNginxWebhookCertGenImage != currentNginxWebhookCertGenImage {
-			klog.V(4).Infof("Nginx ingress controller webhook certgen image is changed!")
+		if desiredWebhookCertGenImage != currentWebhookCertGenImage || isWebhookCertManagementChanged(instance) {
+			klog.V(4).Infof("Ingress controller webhook certgen image or cert management is changed!")
 			isYurtIngressCRChanged = true
 			for _, pool := range unchangedPools {
-				if err := yurtapputil.RecreateNginxWebhookJob(r.Client, pool.Name, desiredNginxWebhookCertGenImage); err != nil {
+				if err := p.UpdatePoolWebhookCertGen(r.Client, instance, pool.Name); err != nil {
 					return ctrl.Result{}, err
 				}
 			}
 		}
-		for _, pool := range unchangedPools {
+		for i, pool := range unchangedPools {
 			currentPool := getCurrentPool(instance, pool.Name)
 			if currentPool != nil {
-				if !isStrArrayEqual(pool.IngressIPs, currentPool.IngressIPs) {
-					klog.V(4).Infof("pool %s ingressIPs is changed", pool.Name)
-					if err := yurtapputil.UpdateNginxServiceExternalIPs(r.Client, pool.Name, pool.IngressIPs); err != nil {
+				if desiredIngressControllerImage == currentIngressControllerImage && isPoolConfigChanged(pool, *currentPool) {
+					klog.V(4).Infof("pool %s ingress controller configuration is changed", pool.Name)
+					if err := p.UpdatePoolController(r.Client, instance, &unchangedPools[i],
+						prepareDeploymentOwnerReferences(instance)); err != nil {
+						return ctrl.Result{}, err
+					}
+				}
+				if !isStrArrayEqual(pool.IngressIPs, currentPool.IngressIPs) ||
+					!apiequality.Semantic.DeepEqual(pool.Exposure, currentPool.Exposure) {
+					klog.V(4).Infof("pool %s ingressIPs or exposure is changed", pool.Name)
+					if err := p.UpdatePoolService(r.Client, instance, &unchangedPools[i]); err != nil {
 						return ctrl.Result{}, err
 					}
 				}
 			}
 		}
 	}
-	r.updateStatus(instance, isYurtIngressCRChanged)
-	return ctrl.Result{}, nil
+	var requeueAfter time.Duration
+	for _, pool := range instance.Spec.Pools {
+		after, err := p.EnsurePoolWebhookCert(r.Client, instance, pool.Name)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+			requeueAfter = after
+		}
+	}
+	r.updateStatus(instance, p, isYurtIngressCRChanged)
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// getProvider returns the provider deploying the ingress controllers of the YurtIngress,
+// YurtIngress created before the provider is introduced uses nginx.
+func (r *YurtIngressReconciler) getProvider(ying *appsv1alpha1.YurtIngress) (provider.Provider, error) {
+	name := getProviderName(ying)
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ingress provider %s of YurtIngress %s", name, ying.Name)
+	}
+	return p, nil
+}
+
+// isWebhookCertManagementChanged checks if the management of the webhook certificates is
+// changed, YurtIngress created before the management is introduced uses the certgen jobs.
+func isWebhookCertManagementChanged(ying *appsv1alpha1.YurtIngress) bool {
+	desired, current := ying.Spec.WebhookCertManagement, ying.Status.WebhookCertManagement
+	if ying.Spec.Provider != appsv1alpha1.TraefikIngressProvider {
+		if desired == "" {
+			desired = appsv1alpha1.JobWebhookCertManagement
+		}
+		if current == "" {
+			current = appsv1alpha1.JobWebhookCertManagement
+		}
+	}
+	return desired != current
 }
 
 func isStrArrayEqual(strList1, strList2 []string) bool {
@@ -262,6 +327,13 @@ func isStrArrayEqual(strList1, strList2 []string) bool {
 	return true
 }
 
+// isPoolConfigChanged checks if the ingress controller configuration of the pool
+// is changed, the ingress ips are handled separately by the service.
+func isPoolConfigChanged(desired, current appsv1alpha1.IngressPool) bool {
+	desired.IngressIPs, current.IngressIPs = nil, nil
+	return !apiequality.Semantic.DeepEqual(desired, current)
+}
+
 func getPools(desired, current []appsv1alpha1.IngressPool) (added, removed, unchanged []appsv1alpha1.IngressPool) {
 	swap := false
 	for i := 0; i < 2; i++ {
@@ -359,33 +431,31 @@ func removePoolfromCondition(ying *appsv1alpha1.YurtIngress, poolname string) bo
 	return false
 }
 
-func (r *YurtIngressReconciler) updateStatus(ying *appsv1alpha1.YurtIngress, ingressCRChanged bool) error {
+func (r *YurtIngressReconciler) updateStatus(ying *appsv1alpha1.YurtIngress, p provider.Provider, ingressCRChanged bool) error {
 	ying.Status.Replicas = ying.Spec.Replicas
 	ying.Status.IngressControllerImage = ying.Spec.IngressControllerImage
 	ying.Status.IngressWebhookCertGenImage = ying.Spec.IngressWebhookCertGenImage
+	ying.Status.WebhookCertManagement = ying.Spec.WebhookCertManagement
 	if !ingressCRChanged {
-		deployments, err := r.getAllDeployments(ying)
-		if err != nil {
-			klog.V(4).Infof("Fail to get all the ingress controller deployments: %v", err)
-			return err
-		}
 		ying.Status.Conditions.IngressReadyPools = nil
 		ying.Status.Conditions.IngressNotReadyPools = nil
 		ying.Status.ReadyNum = 0
-		for _, dply := range deployments {
-			pool := dply.ObjectMeta.GetLabels()[ingressDeploymentLabel]
-			if dply.Status.ReadyReplicas == ying.Spec.Replicas {
-				klog.V(4).Infof("Ingress on pool %s is ready!", pool)
+		for i, pool := range ying.Spec.Pools {
+			ready, condition, err := p.GetPoolReadiness(r.Client, ying, &ying.Spec.Pools[i])
+			if err != nil {
+				klog.V(4).Infof("Fail to get the readiness of ingress on pool %s: %v", pool.Name, err)
+				return err
+			}
+			if ready {
+				klog.V(4).Infof("Ingress on pool %s is ready!", pool.Name)
 				ying.Status.ReadyNum += 1
-				readyPool := getDesiredPool(ying, pool)
-				ying.Status.Conditions.IngressReadyPools = append(ying.Status.Conditions.IngressReadyPools, *readyPool)
+				ying.Status.Conditions.IngressReadyPools = append(ying.Status.Conditions.IngressReadyPools, pool)
 			} else {
-				klog.V(4).Infof("Ingress on pool %s is NOT ready!", pool)
-				condition := getUnreadyDeploymentCondition(dply)
+				klog.V(4).Infof("Ingress on pool %s is NOT ready!", pool.Name)
 				if condition == nil {
-					klog.V(4).Infof("Get deployment/%s conditions nil!", dply.GetName())
+					klog.V(4).Infof("Get ingress on pool %s conditions nil!", pool.Name)
 				} else {
-					notReadyPool := appsv1alpha1.IngressNotReadyPool{Pool: *getDesiredPool(ying, pool), Info: condition}
+					notReadyPool := appsv1alpha1.IngressNotReadyPool{Pool: pool, Info: condition}
 					ying.Status.Conditions.IngressNotReadyPools = append(ying.Status.Conditions.IngressNotReadyPools, notReadyPool)
 				}
 			}
@@ -404,9 +474,12 @@ func (r *YurtIngressReconciler) updateStatus(ying *appsv1alpha1.YurtIngress, ing
 	return updateErr
 }
 
-func (r *YurtIngressReconciler) cleanupIngressResources(instance *appsv1alpha1.YurtIngress) (ctrl.Result, error) {
+func (r *YurtIngressReconciler) cleanupIngressResources(instance *appsv1alpha1.YurtIngress, p provider.Provider) (ctrl.Result, error) {
 	pools := getDesiredPools(instance)
-	isOnly := isOnlyYurtIngressCR(r.Client)
+	namespaceRefs, providerRefs, err := getCommonResourceRefs(r.Client, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
 	if controllerutil.ContainsFinalizer(instance, appsv1alpha1.YurtIngressFinalizer) {
 		controllerutil.RemoveFinalizer(instance, appsv1alpha1.YurtIngressFinalizer)
@@ -416,12 +489,12 @@ func (r *YurtIngressReconciler) cleanupIngressResources(instance *appsv1alpha1.Y
 	}
 	if pools != nil {
 		for _, pool := range pools {
-			if err := yurtapputil.DeleteNginxIngressSpecificResource(r.Client, pool.Name, isOnly); err != nil {
+			if err := p.DeletePoolResource(r.Client, instance, pool.Name, namespaceRefs == 0); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
-		if isOnly {
-			if err := yurtapputil.DeleteNginxIngressCommonResource(r.Client); err != nil {
+		if namespaceRefs == 0 {
+			if err := p.DeleteCommonResource(r.Client, provider.GetNamespace(instance), providerRefs == 0); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
@@ -444,72 +517,42 @@ func prepareDeploymentOwnerReferences(instance *appsv1alpha1.YurtIngress) *metav
 	return &ownerRef
 }
 
-// getAllDeployments returns all of deployments owned by YurtIngress
-func (r *YurtIngressReconciler) getAllDeployments(ying *appsv1alpha1.YurtIngress) ([]*appsv1.Deployment, error) {
-	labelSelector := metav1.LabelSelector{
-		MatchExpressions: []metav1.LabelSelectorRequirement{
-			{
-				Key:      ingressDeploymentLabel,
-				Operator: metav1.LabelSelectorOpExists,
-			},
-		},
-	}
-	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
-	if err != nil {
-		return nil, err
-	}
-
-	dplyList := &appsv1.DeploymentList{}
-	err = r.Client.List(context.TODO(), dplyList, &client.ListOptions{LabelSelector: selector})
-	if err != nil {
-		return nil, err
-	}
-
-	manager, err := refmanager.New(r.Client, &labelSelector, ying, r.Scheme)
-	if err != nil {
-		return nil, err
-	}
-
-	selected := make([]metav1.Object, len(dplyList.Items))
-	for i, dply := range dplyList.Items {
-		selected[i] = dply.DeepCopy()
-	}
-	claimed, err := manager.ClaimOwnedObjects(selected)
-	if err != nil {
-		return nil, err
-	}
-
-	claimedDplys := make([]*appsv1.Deployment, len(claimed))
-	for i, dply := range claimed {
-		claimedDplys[i] = dply.(*appsv1.Deployment)
+// getCommonResourceRefs counts the other YurtIngress instances which still refer to the
+// common resources of the YurtIngress, namespaceRefs counts those deploying pools in the
+// same namespace and providerRefs counts those deploying pools with the same provider in
+// any namespace, which share the cluster scoped resources of the provider.
+func getCommonResourceRefs(c client.Client, instance *appsv1alpha1.YurtIngress) (namespaceRefs, providerRefs int, err error) {
+	ingressList := appsv1alpha1.YurtIngressList{}
+	if err := c.List(context.TODO(), &ingressList, &client.ListOptions{}); err != nil {
+		klog.V(4).Infof("Get yurtingress list err: %v", err)
+		return 0, 0, err
 	}
-	return claimedDplys, nil
-}
-
-func getUnreadyDeploymentCondition(dply *appsv1.Deployment) (info *appsv1alpha1.IngressNotReadyConditionInfo) {
-	len := len(dply.Status.Conditions)
-	if len == 0 {
-		return nil
+	p, namespace := getProviderName(instance), provider.GetNamespace(instance)
+	for i := range ingressList.Items {
+		ying := &ingressList.Items[i]
+		if ying.Name == instance.Name || getProviderName(ying) != p {
+			continue
+		}
+		// the terminating instances are releasing the common resources as well
+		if ying.DeletionTimestamp != nil {
+			continue
+		}
+		if len(ying.Spec.Pools) == 0 && len(getCurrentPools(ying)) == 0 {
+			continue
+		}
+		providerRefs++
+		if provider.GetNamespace(ying) == namespace {
+			namespaceRefs++
+		}
 	}
-	var conditionInfo appsv1alpha1.IngressNotReadyConditionInfo
-	condition := dply.Status.Conditions[len-1]
-	if condition.Type == appsv1.DeploymentReplicaFailure {
-		conditionInfo.Type = appsv1alpha1.IngressFailure
-	} else {
-		conditionInfo.Type = appsv1alpha1.IngressPending
-	}
-	conditionInfo.LastTransitionTime = condition.LastTransitionTime
-	conditionInfo.Message = condition.Message
-	conditionInfo.Reason = condition.Reason
-	return &conditionInfo
+	return namespaceRefs, providerRefs, nil
 }
 
-func isOnlyYurtIngressCR(c client.Client) bool {
-	ingressList := appsv1alpha1.YurtIngressList{}
-	err := c.List(context.TODO(), &ingressList, &client.ListOptions{})
-	if err != nil {
-		klog.V(4).Infof("Get yurtingress list err: %v", err)
-		return false
+// getProviderName returns the provider of the YurtIngress, YurtIngress created
+// before the provider is introduced uses nginx.
+func getProviderName(ying *appsv1alpha1.YurtIngress) appsv1alpha1.IngressProvider {
+	if ying.Spec.Provider == "" {
+		return appsv1alpha1.NginxIngressProvider
 	}
-	return len(ingressList.Items) == 1
+	return ying.Spec.Provider
 }
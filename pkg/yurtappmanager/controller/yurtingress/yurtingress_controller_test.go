@@ -20,8 +20,11 @@ import (
 	"reflect"
 	"testing"
 
-	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
 )
@@ -100,6 +103,112 @@ func TestIsStrArrayEqual(t *testing.T) {
 	}
 }
 
+func TestIsPoolConfigChanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired alpha1.IngressPool
+		current alpha1.IngressPool
+		expect  bool
+	}{
+		{
+			"ingress ips changed",
+			alpha1.IngressPool{Name: "a", IngressIPs: []string{"10.0.0.1"}},
+			alpha1.IngressPool{Name: "a"},
+			false,
+		},
+		{
+			"replicas changed",
+			alpha1.IngressPool{Name: "a", Replicas: utilpointer.Int32Ptr(2)},
+			alpha1.IngressPool{Name: "a"},
+			true,
+		},
+		{
+			"configmap data changed",
+			alpha1.IngressPool{Name: "a", ConfigMapData: map[string]string{"use-gzip": "true"}},
+			alpha1.IngressPool{Name: "a", ConfigMapData: map[string]string{"use-gzip": "false"}},
+			true,
+		},
+		{
+			"unchanged",
+			alpha1.IngressPool{Name: "a", ExtraArgs: []string{"--v=2"}},
+			alpha1.IngressPool{Name: "a", ExtraArgs: []string{"--v=2"}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := isPoolConfigChanged(st.desired, st.current)
+
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestIsWebhookCertManagementChanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired alpha1.WebhookCertManagement
+		current alpha1.WebhookCertManagement
+		expect  bool
+	}{
+		{
+			"legacy yurtingress",
+			alpha1.JobWebhookCertManagement,
+			"",
+			false,
+		},
+		{
+			"switch to controller",
+			alpha1.ControllerWebhookCertManagement,
+			"",
+			true,
+		},
+		{
+			"switch to job",
+			alpha1.JobWebhookCertManagement,
+			alpha1.ControllerWebhookCertManagement,
+			true,
+		},
+		{
+			"unchanged",
+			alpha1.ControllerWebhookCertManagement,
+			alpha1.ControllerWebhookCertManagement,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				ying := &alpha1.YurtIngress{
+					Spec:   alpha1.YurtIngressSpec{WebhookCertManagement: st.desired},
+					Status: alpha1.YurtIngressStatus{WebhookCertManagement: st.current},
+				}
+				get := isWebhookCertManagementChanged(ying)
+
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
 func TestGetPools(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -521,75 +630,93 @@ func TestPrepareDeploymentOwnerReferences(t *testing.T) {
 	}
 }
 
-func TestGetUnreadyDeploymentCondition(t *testing.T) {
-	type Result struct {
-		conditionType alpha1.IngressNotReadyType
+func TestGetCommonResourceRefs(t *testing.T) {
+	newYurtIngress := func(name string, provider alpha1.IngressProvider, namespace string,
+		pools ...string) *alpha1.YurtIngress {
+		ying := &alpha1.YurtIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       alpha1.YurtIngressSpec{Provider: provider, Namespace: namespace},
+		}
+		for _, pool := range pools {
+			ying.Spec.Pools = append(ying.Spec.Pools, alpha1.IngressPool{Name: pool})
+		}
+		return ying
+	}
+	terminating := func(ying *alpha1.YurtIngress) *alpha1.YurtIngress {
+		now := metav1.Now()
+		ying.DeletionTimestamp = &now
+		ying.Finalizers = []string{alpha1.YurtIngressFinalizer}
+		return ying
 	}
 
 	tests := []struct {
-		name   string
-		dply   *appsv1.Deployment
-		expect *Result
+		name      string
+		instance  *alpha1.YurtIngress
+		others    []client.Object
+		expectNs  int
+		expectPro int
 	}{
 		{
-			"nil",
-			&appsv1.Deployment{
-				Status: appsv1.DeploymentStatus{},
-			},
+			"only instance",
+			newYurtIngress("a", alpha1.NginxIngressProvider, "ingress-nginx", "hangzhou"),
 			nil,
+			0,
+			0,
 		},
 		{
-			"fail",
-			&appsv1.Deployment{
-				Status: appsv1.DeploymentStatus{
-					Conditions: []appsv1.DeploymentCondition{
-						{
-							Type: appsv1.DeploymentReplicaFailure,
-						},
-					},
-				},
-			},
-			&Result{
-				conditionType: alpha1.IngressFailure,
-			},
+			"legacy instance in the same namespace",
+			newYurtIngress("a", alpha1.NginxIngressProvider, "ingress-nginx", "hangzhou"),
+			[]client.Object{newYurtIngress("b", "", "", "beijing")},
+			1,
+			1,
 		},
 		{
-			"pending",
-			&appsv1.Deployment{
-				Status: appsv1.DeploymentStatus{
-					Conditions: []appsv1.DeploymentCondition{
-						{
-							Type: appsv1.DeploymentAvailable,
-						},
-					},
-				},
-			},
-			&Result{
-				conditionType: alpha1.IngressPending,
+			"instance in another namespace",
+			newYurtIngress("a", alpha1.NginxIngressProvider, "ingress-nginx", "hangzhou"),
+			[]client.Object{newYurtIngress("b", alpha1.NginxIngressProvider, "ingress-edge", "beijing")},
+			0,
+			1,
+		},
+		{
+			"instance without pools or with another provider",
+			newYurtIngress("a", alpha1.NginxIngressProvider, "ingress-nginx", "hangzhou"),
+			[]client.Object{
+				newYurtIngress("b", alpha1.NginxIngressProvider, "ingress-nginx"),
+				newYurtIngress("c", alpha1.TraefikIngressProvider, "ingress-traefik", "beijing"),
 			},
+			0,
+			0,
+		},
+		{
+			"terminating instance in the same namespace",
+			newYurtIngress("a", alpha1.NginxIngressProvider, "ingress-nginx", "hangzhou"),
+			[]client.Object{terminating(newYurtIngress("b", alpha1.NginxIngressProvider, "ingress-nginx", "beijing"))},
+			0,
+			0,
 		},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
+		st := tt
+		tf := func(t *testing.T) {
 			t.Parallel()
-			t.Logf("\tTestCase: %s", tt.name)
-
-			get := getUnreadyDeploymentCondition(tt.dply)
-			if get == nil {
-				if !reflect.DeepEqual(&get, tt.expect) {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, tt.expect, get)
-				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, tt.expect, get)
-			} else {
-				result := Result{
-					get.Type,
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				scheme := runtime.NewScheme()
+				_ = alpha1.AddToScheme(scheme)
+				c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(st.others, st.instance)...).Build()
+				namespaceRefs, providerRefs, err := getCommonResourceRefs(c, st.instance)
+				if err != nil {
+					t.Fatal(err)
 				}
-				if !reflect.DeepEqual(&result, tt.expect) {
-					t.Fatalf("\t%s\texpect %v, but get %v", failed, tt.expect, result)
+
+				if namespaceRefs != st.expectNs || providerRefs != st.expectPro {
+					t.Fatalf("\t%s\texpect %v, %v, but get %v, %v", failed, st.expectNs, st.expectPro,
+						namespaceRefs, providerRefs)
 				}
-				t.Logf("\t%s\texpect %v, get %v", succeed, tt.expect, result)
+				t.Logf("\t%s\texpect %v, %v, get %v, %v", succeed, st.expectNs, st.expectPro, namespaceRefs, providerRefs)
 			}
-		})
+		}
+		t.Run(st.name, tf)
 	}
 }
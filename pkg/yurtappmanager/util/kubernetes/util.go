@@ -32,11 +32,22 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// AnnotationManagedBy records the namespace is created by yurt-app-manager,
+	// only such namespace is deleted once it is no longer used.
+	AnnotationManagedBy = "ingress.operator.openyurt.io/managed-by"
+	managedByValue      = "yurt-app-manager"
+	// managerRoleName is the owner of the resources created by yurt-app-manager,
+	// the earlier versions only recorded their resources by this owner.
+	managerRoleName = "yurt-app-manager-role"
+)
+
 var deleteOptions *client.DeleteOptions
 
 func init() {
@@ -47,8 +58,12 @@ func init() {
 }
 
 // CreateNamespaceFromYaml creates the Namespace from the yaml template.
-func CreateNamespaceFromYaml(cli client.Client, crTmpl string, ownerRefs []metav1.OwnerReference) error {
-	obj, err := YamlToObject([]byte(crTmpl))
+func CreateNamespaceFromYaml(cli client.Client, crTmpl string, ownerRefs []metav1.OwnerReference, ctx interface{}) error {
+	content, err := SubsituteTemplate(crTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -57,14 +72,22 @@ func CreateNamespaceFromYaml(cli client.Client, crTmpl string, ownerRefs []metav
 		return fmt.Errorf("fail to assert namespace")
 	}
 	ns.ObjectMeta.SetOwnerReferences(ownerRefs)
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[AnnotationManagedBy] = managedByValue
 
 	err = cli.Create(context.Background(), ns)
 	if err != nil {
 		if !apierrors.IsAlreadyExists(err) {
 			return fmt.Errorf("fail to create the namespace/%s: %v", ns.Name, err)
 		}
-		if apierrors.IsAlreadyExists(err) && ns.Status.Phase != corev1.NamespaceActive {
-			return fmt.Errorf("namespace/%s is not active: %v", ns.Name, err)
+		// the existing namespace is used as is, and is not deleted along with the ingress
+		if err := cli.Get(context.Background(), types.NamespacedName{Name: ns.Name}, ns); err != nil {
+			return fmt.Errorf("fail to get the namespace/%s: %v", ns.Name, err)
+		}
+		if ns.Status.Phase != corev1.NamespaceActive {
+			return fmt.Errorf("namespace/%s is not active", ns.Name)
 		}
 	}
 	time.Sleep(time.Second)
@@ -72,9 +95,14 @@ func CreateNamespaceFromYaml(cli client.Client, crTmpl string, ownerRefs []metav
 	return nil
 }
 
-// DeleteNamespaceFromYaml deletes the Namespace from the yaml template.
-func DeleteNamespaceFromYaml(client client.Client, crTmpl string) error {
-	obj, err := YamlToObject([]byte(crTmpl))
+// DeleteNamespaceFromYaml deletes the Namespace from the yaml template, the
+// namespace which is not created by yurt-app-manager is left in place.
+func DeleteNamespaceFromYaml(client client.Client, crTmpl string, ctx interface{}) error {
+	content, err := SubsituteTemplate(crTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -82,6 +110,16 @@ func DeleteNamespaceFromYaml(client client.Client, crTmpl string) error {
 	if !ok {
 		return fmt.Errorf("fail to assert namespace")
 	}
+	if err := client.Get(context.Background(), types.NamespacedName{Name: ns.Name}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to get the namespace/%s: %v", ns.Name, err)
+	}
+	if ns.Annotations[AnnotationManagedBy] != managedByValue && !isOwnedByManager(ns) {
+		klog.V(4).Infof("namespace/%s is not created by %s, skip deleting it", ns.Name, managedByValue)
+		return nil
+	}
 	err = client.Delete(context.Background(), ns)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
@@ -134,8 +172,12 @@ func DeleteClusterRoleFromYaml(client client.Client, crTmpl string) error {
 }
 
 // CreateClusterRoleBindingFromYaml creates the ClusterRoleBinding from the yaml template.
-func CreateClusterRoleBindingFromYaml(client client.Client, crbTmpl string, ownerRefs []metav1.OwnerReference) error {
-	obj, err := YamlToObject([]byte(crbTmpl))
+func CreateClusterRoleBindingFromYaml(client client.Client, crbTmpl string, ownerRefs []metav1.OwnerReference, ctx interface{}) error {
+	content, err := SubsituteTemplate(crbTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -155,8 +197,12 @@ func CreateClusterRoleBindingFromYaml(client client.Client, crbTmpl string, owne
 }
 
 // DeleteClusterRoleBindingFromYaml deletes the ClusterRoleBinding from the yaml template.
-func DeleteClusterRoleBindingFromYaml(client client.Client, crbTmpl string) error {
-	obj, err := YamlToObject([]byte(crbTmpl))
+func DeleteClusterRoleBindingFromYaml(client client.Client, crbTmpl string, ctx interface{}) error {
+	content, err := SubsituteTemplate(crbTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -174,9 +220,48 @@ func DeleteClusterRoleBindingFromYaml(client client.Client, crbTmpl string) erro
 	return nil
 }
 
+// DeleteLegacyClusterRoleBinding deletes the ClusterRoleBinding named by an
+// earlier version of yurt-app-manager, the ClusterRoleBinding which is not
+// owned by yurt-app-manager is left in place.
+func DeleteLegacyClusterRoleBinding(client client.Client, name string) error {
+	crb := &rbacv1.ClusterRoleBinding{}
+	if err := client.Get(context.Background(), types.NamespacedName{Name: name}, crb); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to get the clusterrolebinding/%s: %v", name, err)
+	}
+	if !isOwnedByManager(crb) {
+		klog.V(4).Infof("clusterrolebinding/%s is not created by %s, skip deleting it", name, managedByValue)
+		return nil
+	}
+	err := client.Delete(context.Background(), crb)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("fail to delete the clusterrolebinding/%s: %v", name, err)
+		}
+	}
+	klog.V(4).Infof("clusterrolebinding/%s is deleted", name)
+	return nil
+}
+
+// isOwnedByManager checks if the object is owned by the role of yurt-app-manager.
+func isOwnedByManager(obj metav1.Object) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Name == managerRoleName {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateRoleFromYaml creates the Role from the yaml template.
-func CreateRoleFromYaml(client client.Client, rTmpl string, ownerRefs []metav1.OwnerReference) error {
-	obj, err := YamlToObject([]byte(rTmpl))
+func CreateRoleFromYaml(client client.Client, rTmpl string, ownerRefs []metav1.OwnerReference, ctx interface{}) error {
+	content, err := SubsituteTemplate(rTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -196,8 +281,12 @@ func CreateRoleFromYaml(client client.Client, rTmpl string, ownerRefs []metav1.O
 }
 
 // DeleteRoleFromYaml deletes the Role from the yaml template.
-func DeleteRoleFromYaml(client client.Client, rTmpl string) error {
-	obj, err := YamlToObject([]byte(rTmpl))
+func DeleteRoleFromYaml(client client.Client, rTmpl string, ctx interface{}) error {
+	content, err := SubsituteTemplate(rTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -216,8 +305,12 @@ func DeleteRoleFromYaml(client client.Client, rTmpl string) error {
 }
 
 // CreateRoleBindingFromYaml creates the RoleBinding from the yaml template.
-func CreateRoleBindingFromYaml(client client.Client, rbTmpl string, ownerRefs []metav1.OwnerReference) error {
-	obj, err := YamlToObject([]byte(rbTmpl))
+func CreateRoleBindingFromYaml(client client.Client, rbTmpl string, ownerRefs []metav1.OwnerReference, ctx interface{}) error {
+	content, err := SubsituteTemplate(rbTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -237,8 +330,12 @@ func CreateRoleBindingFromYaml(client client.Client, rbTmpl string, ownerRefs []
 }
 
 // DeleteRoleBindingFromYaml delete the RoleBinding from the yaml template.
-func DeleteRoleBindingFromYaml(client client.Client, rbTmpl string) error {
-	obj, err := YamlToObject([]byte(rbTmpl))
+func DeleteRoleBindingFromYaml(client client.Client, rbTmpl string, ctx interface{}) error {
+	content, err := SubsituteTemplate(rbTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -257,8 +354,12 @@ func DeleteRoleBindingFromYaml(client client.Client, rbTmpl string) error {
 }
 
 // CreateServiceAccountFromYaml creates the ServiceAccount from the yaml template.
-func CreateServiceAccountFromYaml(client client.Client, saTmpl string, ownerRefs []metav1.OwnerReference) error {
-	obj, err := YamlToObject([]byte(saTmpl))
+func CreateServiceAccountFromYaml(client client.Client, saTmpl string, ownerRefs []metav1.OwnerReference, ctx interface{}) error {
+	content, err := SubsituteTemplate(saTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -278,8 +379,12 @@ func CreateServiceAccountFromYaml(client client.Client, saTmpl string, ownerRefs
 }
 
 // DeleteServiceAccountFromYaml deletes the ServiceAccount from the yaml template.
-func DeleteServiceAccountFromYaml(client client.Client, saTmpl string) error {
-	obj, err := YamlToObject([]byte(saTmpl))
+func DeleteServiceAccountFromYaml(client client.Client, saTmpl string, ctx interface{}) error {
+	content, err := SubsituteTemplate(saTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -298,8 +403,12 @@ func DeleteServiceAccountFromYaml(client client.Client, saTmpl string) error {
 }
 
 // CreateConfigMapFromYaml creates the ConfigMap from the yaml template.
-func CreateConfigMapFromYaml(client client.Client, cmTmpl string, ownerRefs []metav1.OwnerReference) error {
-	obj, err := YamlToObject([]byte(cmTmpl))
+func CreateConfigMapFromYaml(client client.Client, cmTmpl string, ownerRefs []metav1.OwnerReference, ctx interface{}) error {
+	content, err := SubsituteTemplate(cmTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -319,8 +428,12 @@ func CreateConfigMapFromYaml(client client.Client, cmTmpl string, ownerRefs []me
 }
 
 // DeleteConfigMapFromYaml deletes the ConfigMap from the yaml template.
-func DeleteConfigMapFromYaml(client client.Client, cmTmpl string) error {
-	obj, err := YamlToObject([]byte(cmTmpl))
+func DeleteConfigMapFromYaml(client client.Client, cmTmpl string, ctx interface{}) error {
+	content, err := SubsituteTemplate(cmTmpl, ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := YamlToObject([]byte(content))
 	if err != nil {
 		return err
 	}
@@ -23,6 +23,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -69,6 +71,59 @@ func validateNodePoolSpecUpdate(spec, oldSpec *appsv1alpha1.NodePoolSpec) field.
 	return nil
 }
 
+// validateNodePoolSelectorConflict validates that the nodes selected by the
+// nodepool are not selected by other nodepools that adopt nodes by selector
+func validateNodePoolSelectorConflict(cli client.Client, np *appsv1alpha1.NodePool) field.ErrorList {
+	if !np.Spec.AdoptBySelector {
+		return nil
+	}
+
+	selectorPath := field.NewPath("spec").Child("selector")
+	if np.Spec.Selector == nil {
+		return field.ErrorList([]*field.Error{
+			field.Required(selectorPath, "selector is required when adoptBySelector is enabled")})
+	}
+	selector, err := metav1.LabelSelectorAsSelector(np.Spec.Selector)
+	if err != nil {
+		return field.ErrorList([]*field.Error{
+			field.Invalid(selectorPath, np.Spec.Selector, err.Error())})
+	}
+
+	nodes := corev1.NodeList{}
+	if err := cli.List(context.TODO(), &nodes,
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return field.ErrorList([]*field.Error{
+			field.InternalError(selectorPath, fmt.Errorf("fail to list nodes, %v", err))})
+	}
+	if len(nodes.Items) == 0 {
+		return nil
+	}
+
+	pools := appsv1alpha1.NodePoolList{}
+	if err := cli.List(context.TODO(), &pools); err != nil {
+		return field.ErrorList([]*field.Error{
+			field.InternalError(selectorPath, fmt.Errorf("fail to list nodepools, %v", err))})
+	}
+
+	var allErrs field.ErrorList
+	for _, pool := range pools.Items {
+		if pool.Name == np.Name || !pool.Spec.AdoptBySelector || pool.Spec.Selector == nil {
+			continue
+		}
+		ps, err := metav1.LabelSelectorAsSelector(pool.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		for _, node := range nodes.Items {
+			if ps.Matches(labels.Set(node.Labels)) {
+				allErrs = append(allErrs, field.Forbidden(selectorPath,
+					fmt.Sprintf("node %s is also selected by nodepool %s", node.Name, pool.Name)))
+			}
+		}
+	}
+	return allErrs
+}
+
 // validateNodePoolDeletion validate the nodepool deletion event, which prevents
 // the default-nodepool from being deleted
 func validateNodePoolDeletion(cli client.Client, np *appsv1alpha1.NodePool) field.ErrorList {
@@ -80,8 +135,22 @@ func validateNodePoolDeletion(cli client.Client, np *appsv1alpha1.NodePool) fiel
 				fmt.Sprintf("default nodepool %s forbidden to delete", np.Name))})
 	}
 
+	// the nodes of the pool are selected by the current nodepool label
+	// if the selector is not specified
+	var selector labels.Selector = labels.SelectorFromSet(labels.Set{
+		appsv1alpha1.LabelCurrentNodePool: np.Name,
+	})
+	if np.Spec.Selector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(np.Spec.Selector); err != nil {
+			return field.ErrorList([]*field.Error{
+				field.Invalid(field.NewPath("spec").Child("selector"),
+					np.Spec.Selector, err.Error())})
+		}
+	}
+
 	if err := cli.List(context.TODO(), &nodes,
-		client.MatchingLabels(np.Spec.Selector.MatchLabels)); err != nil {
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
 		return field.ErrorList([]*field.Error{
 			field.Forbidden(field.NewPath("metadata").Child("name"),
 				"fail to get nodes associated to the pool")})
@@ -56,8 +56,11 @@ func (webhook *NodePoolHandler) Default(ctx context.Context, obj runtime.Object)
 		return apierrors.NewBadRequest(fmt.Sprintf("expected a NodePool but got a %T", obj))
 	}
 
-	np.Spec.Selector = &metav1.LabelSelector{
-		MatchLabels: map[string]string{v1alpha1.LabelCurrentNodePool: np.Name},
+	// keep the user specified selector if the pool adopts nodes by selector
+	if !np.Spec.AdoptBySelector || np.Spec.Selector == nil {
+		np.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{v1alpha1.LabelCurrentNodePool: np.Name},
+		}
 	}
 
 	// add NodePool.Spec.Type to NodePool labels
@@ -80,6 +83,10 @@ func (webhook *NodePoolHandler) ValidateCreate(ctx context.Context, obj runtime.
 		return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("NodePool").GroupKind(), np.Name, allErrs)
 	}
 
+	if allErrs := validateNodePoolSelectorConflict(webhook.Client, np); len(allErrs) > 0 {
+		return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("NodePool").GroupKind(), np.Name, allErrs)
+	}
+
 	return nil
 }
 
@@ -98,6 +105,10 @@ func (webhook *NodePoolHandler) ValidateUpdate(ctx context.Context, oldObj, newO
 		return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("NodePool").GroupKind(), newNp.Name, allErrs)
 	}
 
+	if allErrs := validateNodePoolSelectorConflict(webhook.Client, newNp); len(allErrs) > 0 {
+		return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("NodePool").GroupKind(), newNp.Name, allErrs)
+	}
+
 	return nil
 }
 
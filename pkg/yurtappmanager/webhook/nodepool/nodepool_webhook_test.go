@@ -83,3 +83,83 @@ func TestNodePoolValidator(t *testing.T) {
 	}
 
 }
+
+func TestNodePoolSelectorConflict(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+
+	gpuPool := &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+		Spec: v1alpha1.NodePoolSpec{
+			Selector:        &metav1.LabelSelector{MatchLabels: map[string]string{"hardware": "gpu"}},
+			AdoptBySelector: true,
+		},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node1",
+			Labels: map[string]string{"region": "hangzhou", "hardware": "gpu"},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gpuPool, node).Build()
+	webhook := &NodePoolHandler{
+		Client: cl,
+	}
+
+	np := &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+		Spec: v1alpha1.NodePoolSpec{
+			Selector:        &metav1.LabelSelector{MatchLabels: map[string]string{"region": "hangzhou"}},
+			AdoptBySelector: true,
+		},
+	}
+
+	// the user specified selector should be kept
+	if err := webhook.Default(context.TODO(), np); err != nil {
+		t.Fatal(err)
+	}
+	if np.Spec.Selector.MatchLabels["region"] != "hangzhou" {
+		t.Fatalf("the selector of the pool should not be overwritten, get %v", np.Spec.Selector)
+	}
+
+	if err := webhook.ValidateCreate(context.TODO(), np); err == nil {
+		t.Fatal("pool selecting nodes of other pools should fail")
+	}
+
+	// disabling adoption removes the conflict
+	updatedNp := np.DeepCopy()
+	updatedNp.Spec.AdoptBySelector = false
+	if err := webhook.ValidateUpdate(context.TODO(), np, updatedNp); err != nil {
+		t.Fatal("pool without adoption should update success", err)
+	}
+}
+
+func TestNodePoolDeletionWithoutSelector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	v1alpha1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node1",
+			Labels: map[string]string{v1alpha1.LabelCurrentNodePool: "hangzhou"},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(node).Build()
+	webhook := &NodePoolHandler{
+		Client: cl,
+	}
+
+	// nodepool without selector should be checked by the nodepool label
+	if err := webhook.ValidateDelete(context.TODO(), &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+	}); err == nil {
+		t.Fatal("nonempty pool deletion should fail")
+	}
+	if err := webhook.ValidateDelete(context.TODO(), &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "beijing"},
+	}); err != nil {
+		t.Fatal("empty pool deletion should success", err)
+	}
+}
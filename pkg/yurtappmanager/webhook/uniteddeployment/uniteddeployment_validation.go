@@ -62,6 +62,7 @@ func validateUnitedDeploymentSpec(c client.Client, spec *unitv1alpha1.UnitedDepl
 	} else {
 		allErrs = append(allErrs, validatePoolTemplate(&(spec.WorkloadTemplate), spec, selector, fldPath.Child("workloadTemplate"))...)
 	}
+	allErrs = append(allErrs, validateYurtAppSetOnlyFields(spec, fldPath)...)
 
 	poolNames := sets.String{}
 	for i, pool := range spec.Topology.Pools {
@@ -107,6 +108,60 @@ func validateUnitedDeploymentSpec(c client.Client, spec *unitv1alpha1.UnitedDepl
 	return allErrs
 }
 
+// validateYurtAppSetOnlyFields rejects the fields shared with YurtAppSet which
+// are not supported by the UnitedDeployment controller.
+func validateYurtAppSetOnlyFields(spec *unitv1alpha1.UnitedDeploymentSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	msg := "not supported by UnitedDeployment, use YurtAppSet instead"
+
+	templatePath := fldPath.Child("workloadTemplate")
+	if spec.WorkloadTemplate.DaemonSetTemplate != nil {
+		allErrs = append(allErrs, field.Forbidden(templatePath.Child("daemonSetTemplate"), msg))
+	}
+	if spec.WorkloadTemplate.CustomTemplate != nil {
+		allErrs = append(allErrs, field.Forbidden(templatePath.Child("customTemplate"), msg))
+	}
+
+	topologyPath := fldPath.Child("topology")
+	if spec.Topology.NodePoolSelector != nil {
+		allErrs = append(allErrs, field.Forbidden(topologyPath.Child("nodePoolSelector"), msg))
+	}
+	if spec.Topology.DefaultReplicas != nil {
+		allErrs = append(allErrs, field.Forbidden(topologyPath.Child("defaultReplicas"), msg))
+	}
+	if spec.Topology.DefaultAutoscaler != nil {
+		allErrs = append(allErrs, field.Forbidden(topologyPath.Child("defaultAutoscaler"), msg))
+	}
+
+	for i, pool := range spec.Topology.Pools {
+		poolPath := topologyPath.Child("pools").Index(i)
+		if pool.Percentage != nil {
+			allErrs = append(allErrs, field.Forbidden(poolPath.Child("percentage"), msg))
+		}
+		if pool.Weight != nil {
+			allErrs = append(allErrs, field.Forbidden(poolPath.Child("weight"), msg))
+		}
+		if pool.MinReplicas != nil {
+			allErrs = append(allErrs, field.Forbidden(poolPath.Child("minReplicas"), msg))
+		}
+		if pool.MaxReplicas != nil {
+			allErrs = append(allErrs, field.Forbidden(poolPath.Child("maxReplicas"), msg))
+		}
+		if pool.Elastic {
+			allErrs = append(allErrs, field.Forbidden(poolPath.Child("elastic"), msg))
+		}
+		// the patch of UnitedDeployment is always applied as a strategic merge patch
+		if pool.PatchType != "" && pool.PatchType != unitv1alpha1.StrategicMergePatchType {
+			allErrs = append(allErrs, field.NotSupported(poolPath.Child("patchType"), pool.PatchType,
+				[]string{string(unitv1alpha1.StrategicMergePatchType)}))
+		}
+		if pool.Autoscaler != nil {
+			allErrs = append(allErrs, field.Forbidden(poolPath.Child("autoscaler"), msg))
+		}
+	}
+	return allErrs
+}
+
 // validateUnitedDeployment validates a UnitedDeployment.
 func validateUnitedDeployment(c client.Client, unitedDeployment *unitv1alpha1.UnitedDeployment) field.ErrorList {
 	allErrs := apivalidation.ValidateObjectMeta(&unitedDeployment.ObjectMeta, true, apimachineryvalidation.NameIsDNSSubdomain, field.NewPath("metadata"))
@@ -21,6 +21,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
 )
@@ -85,6 +86,42 @@ func TestUnitedDeploymentValidator(t *testing.T) {
 		t.Fatal("topology dup should not fail")
 	}
 
+	weightPool := defaultAppSet.DeepCopy()
+	weightPool.Spec.Topology.Pools[0].Weight = pointer.Int32Ptr(1)
+	if err := webhook.ValidateCreate(context.TODO(), weightPool); err == nil {
+		t.Fatal("pool weight should fail")
+	}
+
+	poolSelector := defaultAppSet.DeepCopy()
+	poolSelector.Spec.Topology.NodePoolSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "beijing"}}
+	if err := webhook.ValidateCreate(context.TODO(), poolSelector); err == nil {
+		t.Fatal("nodepool selector should fail")
+	}
+
+	daemonSetTemplate := defaultAppSet.DeepCopy()
+	daemonSetTemplate.Spec.WorkloadTemplate.DaemonSetTemplate = &v1alpha1.DaemonSetTemplateSpec{}
+	if err := webhook.ValidateCreate(context.TODO(), daemonSetTemplate); err == nil {
+		t.Fatal("daemonset template should fail")
+	}
+
+	customTemplate := defaultAppSet.DeepCopy()
+	customTemplate.Spec.WorkloadTemplate.CustomTemplate = &v1alpha1.CustomTemplateSpec{}
+	if err := webhook.ValidateCreate(context.TODO(), customTemplate); err == nil {
+		t.Fatal("custom template should fail")
+	}
+
+	jsonPatch := defaultAppSet.DeepCopy()
+	jsonPatch.Spec.Topology.Pools[0].PatchType = v1alpha1.JSONPatchType
+	if err := webhook.ValidateCreate(context.TODO(), jsonPatch); err == nil {
+		t.Fatal("json patch should fail")
+	}
+
+	autoscaledPool := defaultAppSet.DeepCopy()
+	autoscaledPool.Spec.Topology.Pools[0].Autoscaler = &v1alpha1.PoolAutoscaler{MaxReplicas: 3}
+	if err := webhook.ValidateCreate(context.TODO(), autoscaledPool); err == nil {
+		t.Fatal("pool autoscaler should fail")
+	}
+
 	updateAppSet := defaultAppSet.DeepCopy()
 	updateAppSet.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "demo2"}}
 	if err := webhook.ValidateUpdate(context.TODO(), defaultAppSet, updateAppSet); err == nil {
@@ -14,6 +14,7 @@ limitations under the License.
 package yurtappdaemon
 
 import (
+	"encoding/json"
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -23,6 +24,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unversionedvalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	appsvalidation "k8s.io/kubernetes/pkg/apis/apps/validation"
 	"k8s.io/kubernetes/pkg/apis/core"
@@ -59,7 +62,179 @@ func validateYurtAppDaemonSpec(c client.Client, spec *unitv1alpha1.YurtAppDaemon
 	} else {
 		allErrs = append(allErrs, validateWorkLoadTemplate(&(spec.WorkloadTemplate), selector, fldPath.Child("template"))...)
 	}
+	if spec.NodePoolPolicy != nil {
+		allErrs = append(allErrs, validateNodePoolPolicy(spec.NodePoolPolicy, fldPath.Child("nodePoolPolicy"))...)
+	}
+	if spec.ReplicaPolicy != nil {
+		if spec.WorkloadTemplate.DaemonSetTemplate != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("replicaPolicy"),
+				"daemonSetTemplate runs on every node of the nodepool"))
+		}
+		allErrs = append(allErrs, validateReplicaPolicy(spec.ReplicaPolicy, fldPath.Child("replicaPolicy"))...)
+	}
+	allErrs = append(allErrs, validateWorkloadOverrides(spec, fldPath.Child("overrides"))...)
+
+	return allErrs
+}
+
+// validateNodePoolPolicy validates the nodepool policy of the YurtAppDaemon.
+func validateNodePoolPolicy(policy *unitv1alpha1.NodePoolPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, np := range policy.ExcludedNodePools {
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(np, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("excludedNodePools").Index(i), np, msg))
+		}
+	}
+	for i, t := range policy.Types {
+		if t != unitv1alpha1.Edge && t != unitv1alpha1.Cloud {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("types").Index(i), t,
+				[]string{string(unitv1alpha1.Edge), string(unitv1alpha1.Cloud)}))
+		}
+	}
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(policy.MinReadyNodes), fldPath.Child("minReadyNodes"))...)
+	if policy.TeardownGracePeriodSeconds != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*policy.TeardownGracePeriodSeconds),
+			fldPath.Child("teardownGracePeriodSeconds"))...)
+	}
+	return allErrs
+}
+
+// validateReplicaPolicy validates the replica policy of the YurtAppDaemon.
+func validateReplicaPolicy(policy *unitv1alpha1.ReplicaPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch policy.Type {
+	case "", unitv1alpha1.ReplicaPolicyFixed, unitv1alpha1.ReplicaPolicyNodeRatio, unitv1alpha1.ReplicaPolicyReadyNodes:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), policy.Type,
+			[]string{string(unitv1alpha1.ReplicaPolicyFixed), string(unitv1alpha1.ReplicaPolicyNodeRatio),
+				string(unitv1alpha1.ReplicaPolicyReadyNodes)}))
+	}
+	if policy.NodesPerReplica != nil {
+		if policy.Type != unitv1alpha1.ReplicaPolicyNodeRatio {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("nodesPerReplica"),
+				fmt.Sprintf("only used by the %s policy", unitv1alpha1.ReplicaPolicyNodeRatio)))
+		} else if *policy.NodesPerReplica < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("nodesPerReplica"), *policy.NodesPerReplica,
+				"must be greater than or equal to 1"))
+		}
+	}
+	if policy.MinReplicas != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*policy.MinReplicas), fldPath.Child("minReplicas"))...)
+	}
+	if policy.MaxReplicas != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*policy.MaxReplicas), fldPath.Child("maxReplicas"))...)
+	}
+	if policy.MinReplicas != nil && policy.MaxReplicas != nil && *policy.MinReplicas > *policy.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minReplicas"), *policy.MinReplicas,
+			"must be less than or equal to maxReplicas"))
+	}
+	return allErrs
+}
+
+// validateWorkloadOverrides checks that each override selects some nodepools,
+// and its patch can be applied to the workload template.
+func validateWorkloadOverrides(spec *unitv1alpha1.YurtAppDaemonSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, override := range spec.Overrides {
+		overridePath := fldPath.Index(i)
+		if len(override.NodePools) == 0 && override.NodePoolSelector == nil {
+			allErrs = append(allErrs, field.Required(overridePath, "should provide nodePools or nodePoolSelector"))
+		}
+		if override.NodePoolSelector != nil {
+			allErrs = append(allErrs, unversionedvalidation.ValidateLabelSelector(override.NodePoolSelector,
+				overridePath.Child("nodePoolSelector"))...)
+		}
+		if len(override.Patch.Raw) == 0 {
+			allErrs = append(allErrs, field.Required(overridePath.Child("patch"), ""))
+			continue
+		}
+		if patchesWorkloadSelector(override.Patch.Raw) {
+			allErrs = append(allErrs, field.Forbidden(overridePath.Child("patch", "spec", "selector"),
+				"the selector of the workload is managed by YurtAppDaemon"))
+			continue
+		}
+		podSpec, err := dryRunWorkloadPatch(&spec.WorkloadTemplate, override.Patch.Raw)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(overridePath.Child("patch"), string(override.Patch.Raw),
+				fmt.Sprintf("fail to apply patch to the workload template: %v", err)))
+		} else {
+			allErrs = append(allErrs, validateNodePoolPinning(podSpec, overridePath.Child("patch", "spec", "template", "spec"))...)
+		}
+	}
+	return allErrs
+}
+
+// patchesWorkloadSelector checks if the patch touches the selector of the workload.
+func patchesWorkloadSelector(patch []byte) bool {
+	var object struct {
+		Spec map[string]json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(patch, &object); err != nil {
+		return false
+	}
+	_, exist := object.Spec["selector"]
+	return exist
+}
+
+// dryRunWorkloadPatch applies the strategic merge patch to the workload
+// built from the template, checks the patched workload can be decoded and
+// returns the patched pod spec.
+func dryRunWorkloadPatch(template *unitv1alpha1.WorkloadTemplate, patch []byte) (*v1.PodSpec, error) {
+	var workload, patched interface{}
+	var podSpec *v1.PodSpec
+	switch {
+	case template.StatefulSetTemplate != nil:
+		set := &appsv1.StatefulSet{}
+		workload = &appsv1.StatefulSet{ObjectMeta: template.StatefulSetTemplate.ObjectMeta, Spec: template.StatefulSetTemplate.Spec}
+		patched, podSpec = set, &set.Spec.Template.Spec
+	case template.DeploymentTemplate != nil:
+		deploy := &appsv1.Deployment{}
+		workload = &appsv1.Deployment{ObjectMeta: template.DeploymentTemplate.ObjectMeta, Spec: template.DeploymentTemplate.Spec}
+		patched, podSpec = deploy, &deploy.Spec.Template.Spec
+	case template.DaemonSetTemplate != nil:
+		set := &appsv1.DaemonSet{}
+		workload = &appsv1.DaemonSet{ObjectMeta: template.DaemonSetTemplate.ObjectMeta, Spec: template.DaemonSetTemplate.Spec}
+		patched, podSpec = set, &set.Spec.Template.Spec
+	default:
+		return nil, fmt.Errorf("no workload template to apply the patch")
+	}
+
+	original, err := json.Marshal(workload)
+	if err != nil {
+		return nil, err
+	}
+	result, err := strategicpatch.StrategicMergePatch(original, patch, patched)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(result, patched); err != nil {
+		return nil, err
+	}
+	return podSpec, nil
+}
+
+// validateNodePoolPinning checks the user-provided node constraints of the pod template
+// do not contradict the nodepool membership, which is added by YurtAppDaemon for each nodepool.
+func validateNodePoolPinning(podSpec *v1.PodSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if value, ok := podSpec.NodeSelector[unitv1alpha1.LabelCurrentNodePool]; ok {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("nodeSelector").Key(unitv1alpha1.LabelCurrentNodePool), value,
+			"the nodepool of the workload is managed by YurtAppDaemon"))
+	}
 
+	if podSpec.Affinity == nil || podSpec.Affinity.NodeAffinity == nil ||
+		podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return allErrs
+	}
+	termsPath := fldPath.Child("affinity", "nodeAffinity", "requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+	for i, term := range podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for j, expression := range term.MatchExpressions {
+			if expression.Key == unitv1alpha1.LabelCurrentNodePool {
+				allErrs = append(allErrs, field.Invalid(termsPath.Index(i).Child("matchExpressions").Index(j).Child("key"),
+					expression.Key, "the nodepool of the workload is managed by YurtAppDaemon"))
+			}
+		}
+	}
 	return allErrs
 }
 
@@ -74,11 +249,18 @@ func validateWorkLoadTemplate(template *unitv1alpha1.WorkloadTemplate, selector
 	if template.DeploymentTemplate != nil {
 		templateCount++
 	}
+	if template.DaemonSetTemplate != nil {
+		templateCount++
+	}
+
+	if template.CustomTemplate != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("customTemplate"), "customTemplate is not supported by YurtAppDaemon"))
+	}
 
 	if templateCount < 1 {
-		allErrs = append(allErrs, field.Required(fldPath, "should provide one of (statefulSetTemplate/deploymentTemplate)"))
+		allErrs = append(allErrs, field.Required(fldPath, "should provide one of (statefulSetTemplate/deploymentTemplate/daemonSetTemplate)"))
 	} else if templateCount > 1 {
-		allErrs = append(allErrs, field.Invalid(fldPath, template, "should provide only one of (statefulSetTemplate/deploymentTemplate)"))
+		allErrs = append(allErrs, field.Invalid(fldPath, template, "should provide only one of (statefulSetTemplate/deploymentTemplate/daemonSetTemplate)"))
 	}
 
 	if template.StatefulSetTemplate != nil {
@@ -94,6 +276,7 @@ func validateWorkLoadTemplate(template *unitv1alpha1.WorkloadTemplate, selector
 			return allErrs
 		}
 		allErrs = append(allErrs, appsvalidation.ValidatePodTemplateSpecForStatefulSet(coreTemplate, selector, fldPath.Child("statefulSetTemplate", "spec", "template"), apivalidation.PodValidationOptions{})...)
+		allErrs = append(allErrs, validateNodePoolPinning(&sstemplate.Spec, fldPath.Child("statefulSetTemplate", "spec", "template", "spec"))...)
 	}
 
 	if template.DeploymentTemplate != nil {
@@ -112,6 +295,26 @@ func validateWorkLoadTemplate(template *unitv1alpha1.WorkloadTemplate, selector
 		allErrs = append(allErrs, validatePodTemplateSpec(coreTemplate, selector, fldPath.Child("deploymentTemplate", "spec", "template"))...)
 		allErrs = append(allErrs, apivalidation.ValidatePodTemplateSpec(coreTemplate,
 			fldPath.Child("deploymentTemplate", "spec", "template"), apivalidation.PodValidationOptions{})...)
+		allErrs = append(allErrs, validateNodePoolPinning(&template.Spec, fldPath.Child("deploymentTemplate", "spec", "template", "spec"))...)
+	}
+
+	if template.DaemonSetTemplate != nil {
+		labels := labels.Set(template.DaemonSetTemplate.Labels)
+		if !selector.Matches(labels) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("daemonSetTemplate", "metadata", "labels"),
+				template.DaemonSetTemplate.Labels, "`selector` does not match template `labels`"))
+		}
+		allErrs = append(allErrs, validateDaemonSet(template.DaemonSetTemplate, fldPath.Child("daemonSetTemplate"))...)
+		template := template.DaemonSetTemplate.Spec.Template
+		coreTemplate, err := convertPodTemplateSpec(&template)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Root(), template, fmt.Sprintf("Convert_v1_PodTemplateSpec_To_core_PodTemplateSpec failed: %v", err)))
+			return allErrs
+		}
+		allErrs = append(allErrs, validatePodTemplateSpec(coreTemplate, selector, fldPath.Child("daemonSetTemplate", "spec", "template"))...)
+		allErrs = append(allErrs, apivalidation.ValidatePodTemplateSpec(coreTemplate,
+			fldPath.Child("daemonSetTemplate", "spec", "template"), apivalidation.PodValidationOptions{})...)
+		allErrs = append(allErrs, validateNodePoolPinning(&template.Spec, fldPath.Child("daemonSetTemplate", "spec", "template", "spec"))...)
 	}
 
 	return allErrs
@@ -180,6 +383,60 @@ func validateStatefulSet(statefulSet *unitv1alpha1.StatefulSetTemplateSpec, fldP
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("spec", "updateStrategy", "rollingUpdate", "partition"), *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition, "partition in statefulSetTemplate will not be used"))
 	}
 
+	switch statefulSet.Spec.PodManagementPolicy {
+	case "", appsv1.OrderedReadyPodManagement, appsv1.ParallelPodManagement:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("spec", "podManagementPolicy"), statefulSet.Spec.PodManagementPolicy,
+			[]string{string(appsv1.OrderedReadyPodManagement), string(appsv1.ParallelPodManagement)}))
+	}
+	switch statefulSet.Spec.UpdateStrategy.Type {
+	case "", appsv1.RollingUpdateStatefulSetStrategyType, appsv1.OnDeleteStatefulSetStrategyType:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("spec", "updateStrategy", "type"), statefulSet.Spec.UpdateStrategy.Type,
+			[]string{string(appsv1.RollingUpdateStatefulSetStrategyType), string(appsv1.OnDeleteStatefulSetStrategyType)}))
+	}
+
+	// the volume mounts of the containers should refer to either the volumes
+	// of the pod or the volumeClaimTemplates, which are created for each pool
+	volumes := sets.NewString()
+	for _, volume := range statefulSet.Spec.Template.Spec.Volumes {
+		volumes.Insert(volume.Name)
+	}
+	for i, claim := range statefulSet.Spec.VolumeClaimTemplates {
+		claimPath := fldPath.Child("spec", "volumeClaimTemplates").Index(i).Child("metadata", "name")
+		if len(claim.Name) == 0 {
+			allErrs = append(allErrs, field.Required(claimPath, ""))
+			continue
+		}
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(claim.Name, false) {
+			allErrs = append(allErrs, field.Invalid(claimPath, claim.Name, msg))
+		}
+		if volumes.Has(claim.Name) {
+			allErrs = append(allErrs, field.Duplicate(claimPath, claim.Name))
+		}
+		volumes.Insert(claim.Name)
+	}
+	for i, container := range statefulSet.Spec.Template.Spec.Containers {
+		for j, mount := range container.VolumeMounts {
+			if !volumes.Has(mount.Name) {
+				allErrs = append(allErrs, field.NotFound(fldPath.Child("spec", "template", "spec", "containers").Index(i).
+					Child("volumeMounts").Index(j).Child("name"), mount.Name))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateDaemonSet validates the update strategy of the DaemonSet template.
+func validateDaemonSet(daemonSet *unitv1alpha1.DaemonSetTemplateSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch daemonSet.Spec.UpdateStrategy.Type {
+	case "", appsv1.RollingUpdateDaemonSetStrategyType, appsv1.OnDeleteDaemonSetStrategyType:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("spec", "updateStrategy", "type"), daemonSet.Spec.UpdateStrategy.Type,
+			[]string{string(appsv1.RollingUpdateDaemonSetStrategyType), string(appsv1.OnDeleteDaemonSetStrategyType)}))
+	}
 	return allErrs
 }
 
@@ -66,6 +66,9 @@ func (webhook *YurtAppDaemonHandler) Default(ctx context.Context, obj runtime.Ob
 	if deployTem != nil {
 		deployTem.Spec.Selector = daemon.Spec.Selector
 	}
+	if daemonSetTem := daemon.Spec.WorkloadTemplate.DaemonSetTemplate; daemonSetTem != nil {
+		daemonSetTem.Spec.Selector = daemon.Spec.Selector
+	}
 
 	return nil
 }
@@ -21,6 +21,8 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilpointer "k8s.io/utils/pointer"
 
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
 )
@@ -82,3 +84,419 @@ func TestYurtAppSetValidator(t *testing.T) {
 	}
 
 }
+
+func TestYurtAppDaemonStatefulSetValidator(t *testing.T) {
+	tests := []struct {
+		name   string
+		mounts []corev1.VolumeMount
+		claims []string
+		valid  bool
+	}{
+		{
+			"mount volume claim templates",
+			[]corev1.VolumeMount{{Name: "data", MountPath: "/data"}},
+			[]string{"data"},
+			true,
+		},
+		{
+			"mount unknown volume",
+			[]corev1.VolumeMount{{Name: "logs", MountPath: "/logs"}},
+			[]string{"data"},
+			false,
+		},
+		{
+			"duplicated volume claim templates",
+			nil,
+			[]string{"data", "data"},
+			false,
+		},
+	}
+
+	webhook := &YurtAppDaemonHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				daemon := defaultAppDaemon.DeepCopy()
+				deployTemplate := daemon.Spec.WorkloadTemplate.DeploymentTemplate
+				daemon.Spec.WorkloadTemplate.DeploymentTemplate = nil
+				daemon.Spec.WorkloadTemplate.StatefulSetTemplate = &v1alpha1.StatefulSetTemplateSpec{
+					ObjectMeta: deployTemplate.ObjectMeta,
+					Spec: appsv1.StatefulSetSpec{
+						ServiceName: "demo",
+						Template:    deployTemplate.Spec.Template,
+					},
+				}
+				stsSpec := &daemon.Spec.WorkloadTemplate.StatefulSetTemplate.Spec
+				stsSpec.Template.Spec.Containers[0].VolumeMounts = st.mounts
+				for _, claim := range st.claims {
+					stsSpec.VolumeClaimTemplates = append(stsSpec.VolumeClaimTemplates,
+						corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: claim}})
+				}
+				if err := webhook.Default(context.TODO(), daemon); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), daemon)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppDaemonOverridesValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		override v1alpha1.WorkloadOverride
+		valid    bool
+	}{
+		{
+			"override replicas of nodepools",
+			v1alpha1.WorkloadOverride{
+				NodePools: []string{"hangzhou"},
+				Patch:     runtime.RawExtension{Raw: []byte(`{"spec":{"replicas":3}}`)},
+			},
+			true,
+		},
+		{
+			"override image of selected nodepools",
+			v1alpha1.WorkloadOverride{
+				NodePoolSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}},
+				Patch: runtime.RawExtension{Raw: []byte(
+					`{"spec":{"template":{"spec":{"containers":[{"name":"demo","image":"mirror/nginx"}]}}}}`)},
+			},
+			true,
+		},
+		{
+			"no nodepools to override",
+			v1alpha1.WorkloadOverride{
+				Patch: runtime.RawExtension{Raw: []byte(`{"spec":{"replicas":3}}`)},
+			},
+			false,
+		},
+		{
+			"patch does not match the workload",
+			v1alpha1.WorkloadOverride{
+				NodePools: []string{"hangzhou"},
+				Patch:     runtime.RawExtension{Raw: []byte(`{"spec":{"replicas":"three"}}`)},
+			},
+			false,
+		},
+		{
+			"patch overrides the selector",
+			v1alpha1.WorkloadOverride{
+				NodePools: []string{"hangzhou"},
+				Patch:     runtime.RawExtension{Raw: []byte(`{"spec":{"selector":{"matchLabels":{"app":"other"}}}}`)},
+			},
+			false,
+		},
+	}
+
+	webhook := &YurtAppDaemonHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				daemon := defaultAppDaemon.DeepCopy()
+				daemon.Spec.Overrides = []v1alpha1.WorkloadOverride{st.override}
+				if err := webhook.Default(context.TODO(), daemon); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), daemon)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppDaemonReplicaPolicyValidator(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *v1alpha1.ReplicaPolicy
+		valid  bool
+	}{
+		{
+			"node ratio with bounds",
+			&v1alpha1.ReplicaPolicy{
+				Type:            v1alpha1.ReplicaPolicyNodeRatio,
+				NodesPerReplica: utilpointer.Int32Ptr(10),
+				MinReplicas:     utilpointer.Int32Ptr(1),
+				MaxReplicas:     utilpointer.Int32Ptr(5),
+			},
+			true,
+		},
+		{
+			"nodes per replica of ready nodes policy",
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyReadyNodes, NodesPerReplica: utilpointer.Int32Ptr(10)},
+			false,
+		},
+		{
+			"zero nodes per replica",
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyNodeRatio, NodesPerReplica: utilpointer.Int32Ptr(0)},
+			false,
+		},
+		{
+			"min replicas exceed max replicas",
+			&v1alpha1.ReplicaPolicy{MinReplicas: utilpointer.Int32Ptr(3), MaxReplicas: utilpointer.Int32Ptr(2)},
+			false,
+		},
+	}
+
+	webhook := &YurtAppDaemonHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				daemon := defaultAppDaemon.DeepCopy()
+				daemon.Spec.ReplicaPolicy = st.policy
+				if err := webhook.Default(context.TODO(), daemon); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), daemon)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppDaemonNodePoolPinningValidator(t *testing.T) {
+	requiredAffinity := func(key string) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: key, Operator: corev1.NodeSelectorOpIn, Values: []string{"hangzhou"}},
+					},
+				}},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name         string
+		nodeSelector map[string]string
+		affinity     *corev1.Affinity
+		overrides    []v1alpha1.WorkloadOverride
+		valid        bool
+	}{
+		{
+			"user node constraints",
+			map[string]string{"kubernetes.io/arch": "arm64"},
+			requiredAffinity("gpu"),
+			nil,
+			true,
+		},
+		{
+			"nodeSelector pins a nodepool",
+			map[string]string{v1alpha1.LabelCurrentNodePool: "hangzhou"},
+			nil,
+			nil,
+			false,
+		},
+		{
+			"node affinity pins a nodepool",
+			nil,
+			requiredAffinity(v1alpha1.LabelCurrentNodePool),
+			nil,
+			false,
+		},
+		{
+			"override pins a nodepool",
+			nil,
+			nil,
+			[]v1alpha1.WorkloadOverride{{
+				NodePools: []string{"hangzhou"},
+				Patch: runtime.RawExtension{Raw: []byte(
+					`{"spec":{"template":{"spec":{"nodeSelector":{"apps.openyurt.io/nodepool":"beijing"}}}}}`)},
+			}},
+			false,
+		},
+	}
+
+	webhook := &YurtAppDaemonHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				daemon := defaultAppDaemon.DeepCopy()
+				daemon.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.NodeSelector = st.nodeSelector
+				daemon.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Affinity = st.affinity
+				daemon.Spec.Overrides = st.overrides
+				if err := webhook.Default(context.TODO(), daemon); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), daemon)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppDaemonNodePoolPolicyValidator(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *v1alpha1.NodePoolPolicy
+		valid  bool
+	}{
+		{
+			"edge nodepools with ready nodes",
+			&v1alpha1.NodePoolPolicy{
+				ExcludedNodePools:          []string{"hangzhou"},
+				Types:                      []v1alpha1.NodePoolType{v1alpha1.Edge},
+				MinReadyNodes:              2,
+				TeardownGracePeriodSeconds: utilpointer.Int32Ptr(300),
+			},
+			true,
+		},
+		{
+			"invalid excluded nodepool name",
+			&v1alpha1.NodePoolPolicy{ExcludedNodePools: []string{"Hang_Zhou"}},
+			false,
+		},
+		{
+			"unsupported nodepool type",
+			&v1alpha1.NodePoolPolicy{Types: []v1alpha1.NodePoolType{"Fog"}},
+			false,
+		},
+		{
+			"negative min ready nodes",
+			&v1alpha1.NodePoolPolicy{MinReadyNodes: -1},
+			false,
+		},
+		{
+			"negative teardown grace period",
+			&v1alpha1.NodePoolPolicy{TeardownGracePeriodSeconds: utilpointer.Int32Ptr(-1)},
+			false,
+		},
+	}
+
+	webhook := &YurtAppDaemonHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				daemon := defaultAppDaemon.DeepCopy()
+				daemon.Spec.NodePoolPolicy = st.policy
+				if err := webhook.Default(context.TODO(), daemon); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), daemon)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppDaemonDaemonSetValidator(t *testing.T) {
+	newDaemonSetTemplate := func(strategy appsv1.DaemonSetUpdateStrategyType) *v1alpha1.DaemonSetTemplateSpec {
+		return &v1alpha1.DaemonSetTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+			Spec: appsv1.DaemonSetSpec{
+				UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: strategy},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "agent", Image: "agent"}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		template      *v1alpha1.DaemonSetTemplateSpec
+		replicaPolicy *v1alpha1.ReplicaPolicy
+		overrides     []v1alpha1.WorkloadOverride
+		valid         bool
+	}{
+		{
+			"daemonset template",
+			newDaemonSetTemplate(appsv1.RollingUpdateDaemonSetStrategyType),
+			nil,
+			[]v1alpha1.WorkloadOverride{{
+				NodePools: []string{"hangzhou"},
+				Patch: runtime.RawExtension{Raw: []byte(
+					`{"spec":{"template":{"spec":{"containers":[{"name":"agent","image":"mirror/agent"}]}}}}`)},
+			}},
+			true,
+		},
+		{
+			"unsupported update strategy",
+			newDaemonSetTemplate("Partition"),
+			nil,
+			nil,
+			false,
+		},
+		{
+			"replica policy of daemonset",
+			newDaemonSetTemplate(appsv1.RollingUpdateDaemonSetStrategyType),
+			&v1alpha1.ReplicaPolicy{Type: v1alpha1.ReplicaPolicyReadyNodes},
+			nil,
+			false,
+		},
+	}
+
+	webhook := &YurtAppDaemonHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				daemon := defaultAppDaemon.DeepCopy()
+				daemon.Spec.WorkloadTemplate = v1alpha1.WorkloadTemplate{DaemonSetTemplate: st.template}
+				daemon.Spec.ReplicaPolicy = st.replicaPolicy
+				daemon.Spec.Overrides = st.overrides
+				if err := webhook.Default(context.TODO(), daemon); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), daemon)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppDaemonCustomTemplateValidator(t *testing.T) {
+	webhook := &YurtAppDaemonHandler{}
+
+	daemon := defaultAppDaemon.DeepCopy()
+	daemon.Spec.WorkloadTemplate.CustomTemplate = &v1alpha1.CustomTemplateSpec{
+		Definition: "cloneset",
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+	}
+	if err := webhook.Default(context.TODO(), daemon); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhook.ValidateCreate(context.TODO(), daemon); err == nil {
+		t.Fatal("yurtappdaemon with custom template should fail")
+	}
+}
@@ -18,7 +18,10 @@ limitations under the License.
 package yurtappset
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -28,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unversionedvalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog"
@@ -38,8 +42,20 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappset/adapter"
 )
 
+// dryRunScheme is used to set the owner reference when dry-running the pool patches
+var dryRunScheme = runtime.NewScheme()
+
+// dryRunReplicas is the replicas of the dry-run workloads, which tells if the
+// replicas are modified by the patch.
+const dryRunReplicas = math.MaxInt32
+
+func init() {
+	_ = unitv1alpha1.AddToScheme(dryRunScheme)
+}
+
 // ValidateYurtAppSetSpec tests if required fields in the YurtAppSet spec are set.
 func validateYurtAppSetSpec(c client.Client, spec *unitv1alpha1.YurtAppSetSpec, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -53,9 +69,6 @@ func validateYurtAppSetSpec(c client.Client, spec *unitv1alpha1.YurtAppSetSpec,
 		}
 	}
 
-	klog.Infof("sel:%v, label: %v\n", spec.Selector, spec.WorkloadTemplate.DeploymentTemplate.Labels)
-	klog.Infof("templatePath:%s", fldPath.Child("workloadTemplate").String())
-
 	selector, err := metav1.LabelSelectorAsSelector(spec.Selector)
 	if err != nil {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), spec.Selector, ""))
@@ -104,6 +117,293 @@ func validateYurtAppSetSpec(c client.Client, spec *unitv1alpha1.YurtAppSetSpec,
 
 	}
 
+	if spec.Selector != nil {
+		allErrs = append(allErrs, validatePoolPatches(c, spec, fldPath.Child("topology", "pools"))...)
+	}
+
+	if spec.Topology.NodePoolSelector != nil {
+		allErrs = append(allErrs, unversionedvalidation.ValidateLabelSelector(spec.Topology.NodePoolSelector,
+			fldPath.Child("topology", "nodePoolSelector"))...)
+	}
+	if spec.Topology.DefaultReplicas != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*spec.Topology.DefaultReplicas),
+			fldPath.Child("topology", "defaultReplicas"))...)
+	}
+
+	allErrs = append(allErrs, validateYurtAppSetReplicas(spec, fldPath)...)
+	allErrs = append(allErrs, validateYurtAppSetAutoscalers(spec, fldPath)...)
+	if spec.RolloutStrategy != nil {
+		allErrs = append(allErrs, validateRolloutStrategy(spec.RolloutStrategy, fldPath.Child("rolloutStrategy"))...)
+	}
+	return allErrs
+}
+
+// validateRolloutStrategy validates the waves of the rollout strategy, a pool
+// can only be listed in one wave, and the pools not listed in any wave are
+// grouped in the implicit `default` wave.
+func validateRolloutStrategy(strategy *unitv1alpha1.RolloutStrategy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	waveNames := sets.NewString()
+	poolNames := sets.NewString()
+	for i, wave := range strategy.Waves {
+		wavePath := fldPath.Child("waves").Index(i)
+		if len(wave.Name) == 0 {
+			allErrs = append(allErrs, field.Required(wavePath.Child("name"), ""))
+		} else {
+			for _, msg := range apimachineryvalidation.NameIsDNSLabel(wave.Name, false) {
+				allErrs = append(allErrs, field.Invalid(wavePath.Child("name"), wave.Name, msg))
+			}
+		}
+		if wave.Name == unitv1alpha1.DefaultRolloutWaveName {
+			allErrs = append(allErrs, field.Invalid(wavePath.Child("name"), wave.Name,
+				"is reserved for the pools not listed in any wave"))
+		}
+		if waveNames.Has(wave.Name) {
+			allErrs = append(allErrs, field.Duplicate(wavePath.Child("name"), wave.Name))
+		}
+		waveNames.Insert(wave.Name)
+
+		for j, pool := range wave.Pools {
+			if poolNames.Has(pool) {
+				allErrs = append(allErrs, field.Duplicate(wavePath.Child("pools").Index(j), pool))
+			}
+			poolNames.Insert(pool)
+		}
+	}
+
+	if strategy.MaxUnavailablePools != nil && *strategy.MaxUnavailablePools < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxUnavailablePools"), *strategy.MaxUnavailablePools,
+			"must be greater than or equal to 1"))
+	}
+	if strategy.ProgressDeadlineSeconds != nil && *strategy.ProgressDeadlineSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("progressDeadlineSeconds"), *strategy.ProgressDeadlineSeconds,
+			"must be greater than or equal to 1"))
+	}
+	return allErrs
+}
+
+// validateYurtAppSetReplicas validates the replicas settings of the pools, and
+// checks if the replicas of the YurtAppSet can be distributed between the
+// pools without breaking their bounds.
+func validateYurtAppSetReplicas(spec *unitv1alpha1.YurtAppSetSpec, fldPath *field.Path) field.ErrorList {
+	if spec.WorkloadTemplate.DaemonSetTemplate != nil {
+		return validateDaemonSetReplicas(spec, fldPath)
+	}
+
+	allErrs := field.ErrorList{}
+	if spec.Replicas != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*spec.Replicas), fldPath.Child("replicas"))...)
+	}
+
+	var (
+		lower, upper    int64
+		unbounded       bool
+		elasticPools    int
+		totalPercentage int64
+	)
+	// the pools selected by the nodepool selector are autoscaled only if the
+	// default autoscaler is set
+	autoscaledOnly := len(spec.Topology.Pools) > 0
+	if spec.Topology.NodePoolSelector != nil {
+		autoscaledOnly = spec.Topology.DefaultAutoscaler != nil
+	}
+	for i, pool := range spec.Topology.Pools {
+		poolPath := fldPath.Child("topology", "pools").Index(i)
+		for _, f := range []struct {
+			name  string
+			value *int32
+		}{
+			{"replicas", pool.Replicas},
+			{"percentage", pool.Percentage},
+			{"weight", pool.Weight},
+			{"minReplicas", pool.MinReplicas},
+			{"maxReplicas", pool.MaxReplicas},
+		} {
+			if f.value != nil {
+				allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*f.value), poolPath.Child(f.name))...)
+			}
+		}
+
+		var strategies []string
+		if pool.Replicas != nil {
+			strategies = append(strategies, "replicas")
+		}
+		if pool.Percentage != nil {
+			strategies = append(strategies, "percentage")
+		}
+		if pool.Weight != nil {
+			strategies = append(strategies, "weight")
+		}
+		if pool.Elastic {
+			strategies = append(strategies, "elastic")
+		}
+		if len(strategies) > 1 {
+			allErrs = append(allErrs, field.Invalid(poolPath, strings.Join(strategies, ","),
+				"only one of replicas, percentage, weight and elastic can be specified"))
+		}
+		if spec.Replicas == nil && (pool.Percentage != nil || pool.Weight != nil || pool.Elastic ||
+			pool.MinReplicas != nil || pool.MaxReplicas != nil) {
+			allErrs = append(allErrs, field.Required(fldPath.Child("replicas"),
+				fmt.Sprintf("replicas must be set to distribute replicas to pool %s", pool.Name)))
+		}
+
+		if pool.Percentage != nil {
+			if *pool.Percentage > 100 {
+				allErrs = append(allErrs, field.Invalid(poolPath.Child("percentage"), *pool.Percentage,
+					"must be less than or equal to 100"))
+			}
+			totalPercentage += int64(*pool.Percentage)
+		}
+		if pool.Elastic {
+			elasticPools++
+		}
+		if pool.MinReplicas != nil && pool.MaxReplicas != nil && *pool.MinReplicas > *pool.MaxReplicas {
+			allErrs = append(allErrs, field.Invalid(poolPath.Child("minReplicas"), *pool.MinReplicas,
+				"must be less than or equal to maxReplicas"))
+		}
+		if pool.Replicas != nil && spec.Replicas != nil &&
+			((pool.MinReplicas != nil && *pool.Replicas < *pool.MinReplicas) ||
+				(pool.MaxReplicas != nil && *pool.Replicas > *pool.MaxReplicas)) {
+			allErrs = append(allErrs, field.Invalid(poolPath.Child("replicas"), *pool.Replicas,
+				"must be in the range of minReplicas and maxReplicas"))
+		}
+
+		// calculate the range of the replicas that the pool can hold, the
+		// autoscaled pools take no share of the replicas
+		if spec.Replicas == nil || pool.Autoscaler != nil {
+			continue
+		}
+		autoscaledOnly = false
+		switch {
+		case pool.Replicas != nil:
+			lower += int64(*pool.Replicas)
+			upper += int64(*pool.Replicas)
+		case pool.Percentage != nil:
+			replicas := int64(*spec.Replicas) * int64(*pool.Percentage) / 100
+			if pool.MinReplicas != nil && replicas < int64(*pool.MinReplicas) {
+				replicas = int64(*pool.MinReplicas)
+			}
+			if pool.MaxReplicas != nil && replicas > int64(*pool.MaxReplicas) {
+				replicas = int64(*pool.MaxReplicas)
+			}
+			lower += replicas
+			upper += replicas
+		default:
+			if pool.MinReplicas != nil {
+				lower += int64(*pool.MinReplicas)
+			}
+			if pool.Weight != nil && *pool.Weight == 0 && !pool.Elastic {
+				// the pool with zero weight only holds its lower bound
+				if pool.MinReplicas != nil {
+					upper += int64(*pool.MinReplicas)
+				}
+			} else if pool.MaxReplicas != nil {
+				upper += int64(*pool.MaxReplicas)
+			} else {
+				unbounded = true
+			}
+		}
+	}
+
+	if elasticPools > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("topology", "pools"), elasticPools,
+			"at most one pool can be elastic"))
+	}
+	if totalPercentage > 100 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("topology", "pools"), totalPercentage,
+			"the sum of the percentage of pools must be less than or equal to 100"))
+	}
+	if spec.Replicas != nil && autoscaledOnly {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("replicas"),
+			"the replicas of the autoscaled pools are decided by their autoscalers"))
+	} else if spec.Replicas != nil {
+		if lower > int64(*spec.Replicas) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), *spec.Replicas,
+				fmt.Sprintf("must be greater than or equal to %d replicas required by the pools", lower)))
+		}
+		// the pools selected by the nodepool selector are unknown until
+		// the YurtAppSet is reconciled
+		if !unbounded && spec.Topology.NodePoolSelector == nil && upper < int64(*spec.Replicas) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), *spec.Replicas,
+				fmt.Sprintf("must be less than or equal to %d replicas that the pools can hold", upper)))
+		}
+	}
+	return allErrs
+}
+
+// validateDaemonSetReplicas rejects the replicas settings of a YurtAppSet with
+// DaemonSet template, the replicas of the DaemonSet pools are decided by the
+// nodes of the pools.
+func validateDaemonSetReplicas(spec *unitv1alpha1.YurtAppSetSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	msg := "not supported by DaemonSet template"
+	if spec.Replicas != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("replicas"), msg))
+	}
+	if spec.Topology.DefaultReplicas != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("topology", "defaultReplicas"), msg))
+	}
+	for i, pool := range spec.Topology.Pools {
+		poolPath := fldPath.Child("topology", "pools").Index(i)
+		for _, f := range []struct {
+			name  string
+			value *int32
+		}{
+			{"replicas", pool.Replicas},
+			{"percentage", pool.Percentage},
+			{"weight", pool.Weight},
+			{"minReplicas", pool.MinReplicas},
+			{"maxReplicas", pool.MaxReplicas},
+		} {
+			if f.value != nil {
+				allErrs = append(allErrs, field.Forbidden(poolPath.Child(f.name), msg))
+			}
+		}
+		if pool.Elastic {
+			allErrs = append(allErrs, field.Forbidden(poolPath.Child("elastic"), msg))
+		}
+	}
+	return allErrs
+}
+
+// validateYurtAppSetAutoscalers validates the autoscalers of the pools. The
+// pools of DaemonSet can not be autoscaled.
+func validateYurtAppSetAutoscalers(spec *unitv1alpha1.YurtAppSetSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	type poolAutoscaler struct {
+		path       *field.Path
+		autoscaler *unitv1alpha1.PoolAutoscaler
+	}
+	var autoscalers []poolAutoscaler
+	if spec.Topology.DefaultAutoscaler != nil {
+		autoscalers = append(autoscalers, poolAutoscaler{fldPath.Child("topology", "defaultAutoscaler"), spec.Topology.DefaultAutoscaler})
+	}
+	for i, pool := range spec.Topology.Pools {
+		if pool.Autoscaler != nil {
+			autoscalers = append(autoscalers, poolAutoscaler{fldPath.Child("topology", "pools").Index(i).Child("autoscaler"), pool.Autoscaler})
+		}
+	}
+
+	for _, pa := range autoscalers {
+		path, autoscaler := pa.path, pa.autoscaler
+		if spec.WorkloadTemplate.DaemonSetTemplate != nil {
+			allErrs = append(allErrs, field.Forbidden(path, "the pools of daemonSetTemplate can not be autoscaled"))
+			continue
+		}
+		if autoscaler.MaxReplicas < 1 {
+			allErrs = append(allErrs, field.Invalid(path.Child("maxReplicas"), autoscaler.MaxReplicas,
+				"must be greater than or equal to 1"))
+		}
+		if autoscaler.MinReplicas != nil {
+			if *autoscaler.MinReplicas < 1 {
+				allErrs = append(allErrs, field.Invalid(path.Child("minReplicas"), *autoscaler.MinReplicas,
+					"must be greater than or equal to 1"))
+			} else if *autoscaler.MinReplicas > autoscaler.MaxReplicas {
+				allErrs = append(allErrs, field.Invalid(path.Child("minReplicas"), *autoscaler.MinReplicas,
+					"must be less than or equal to maxReplicas"))
+			}
+		}
+	}
 	return allErrs
 }
 
@@ -185,11 +485,17 @@ func validatePoolTemplate(template *unitv1alpha1.WorkloadTemplate, spec *unitv1a
 	if template.DeploymentTemplate != nil {
 		templateCount++
 	}
+	if template.DaemonSetTemplate != nil {
+		templateCount++
+	}
+	if template.CustomTemplate != nil {
+		templateCount++
+	}
 
 	if templateCount < 1 {
-		allErrs = append(allErrs, field.Required(fldPath, "should provide one of (statefulSetTemplate/deploymentTemplate/daemonSetTemplate)"))
+		allErrs = append(allErrs, field.Required(fldPath, "should provide one of (statefulSetTemplate/deploymentTemplate/daemonSetTemplate/customTemplate)"))
 	} else if templateCount > 1 {
-		allErrs = append(allErrs, field.Invalid(fldPath, template, "should provide only one of (statefulSetTemplate/deploymentTemplate/daemonSetTemplate)"))
+		allErrs = append(allErrs, field.Invalid(fldPath, template, "should provide only one of (statefulSetTemplate/deploymentTemplate/daemonSetTemplate/customTemplate)"))
 	}
 
 	if template.StatefulSetTemplate != nil {
@@ -228,9 +534,131 @@ func validatePoolTemplate(template *unitv1alpha1.WorkloadTemplate, spec *unitv1a
 			fldPath.Child("deploymentTemplate", "spec", "template"), apivalidation.PodValidationOptions{})...)
 	}
 
+	if template.DaemonSetTemplate != nil {
+		labels := labels.Set(template.DaemonSetTemplate.Labels)
+		if !selector.Matches(labels) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("daemonSetTemplate", "metadata", "labels"),
+				template.DaemonSetTemplate.Labels, "`selector` does not match template `labels`"))
+		}
+		allErrs = append(allErrs, validateDaemonSet(template.DaemonSetTemplate, fldPath.Child("daemonSetTemplate"))...)
+		template := template.DaemonSetTemplate.Spec.Template
+		coreTemplate, err := convertPodTemplateSpec(&template)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Root(), template, fmt.Sprintf("Convert_v1_PodTemplateSpec_To_core_PodTemplateSpec failed: %v", err)))
+			return allErrs
+		}
+		allErrs = append(allErrs, validatePodTemplateSpec(coreTemplate, selector, fldPath.Child("daemonSetTemplate", "spec", "template"))...)
+		allErrs = append(allErrs, apivalidation.ValidatePodTemplateSpec(coreTemplate,
+			fldPath.Child("daemonSetTemplate", "spec", "template"), apivalidation.PodValidationOptions{})...)
+	}
+
+	if template.CustomTemplate != nil {
+		labels := labels.Set(template.CustomTemplate.Labels)
+		if !selector.Matches(labels) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("customTemplate", "metadata", "labels"),
+				template.CustomTemplate.Labels, "`selector` does not match template `labels`"))
+		}
+		allErrs = append(allErrs, validateCustomTemplate(template.CustomTemplate, fldPath.Child("customTemplate"))...)
+	}
+
 	return allErrs
 }
 
+// validateCustomTemplate validates the definition reference and the spec of the custom template.
+// The fields of the spec depend on the workload kind, so only the spec is required to be an object.
+func validateCustomTemplate(custom *unitv1alpha1.CustomTemplateSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(custom.Definition) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("definition"), ""))
+	} else {
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(custom.Definition, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("definition"), custom.Definition, msg))
+		}
+	}
+
+	if len(custom.Spec.Raw) > 0 {
+		spec := map[string]interface{}{}
+		if err := json.Unmarshal(custom.Spec.Raw, &spec); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("spec"), string(custom.Spec.Raw),
+				fmt.Sprintf("spec should be an object: %v", err)))
+		}
+	}
+	return allErrs
+}
+
+// validatePoolPatches checks the patch type of each pool, and dry-runs the
+// patch by applying the pool template to an empty workload in the way the
+// YurtAppSet controller does, so that the patch which can not be applied is
+// rejected at admission.
+func validatePoolPatches(c client.Client, spec *unitv1alpha1.YurtAppSetSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	var poolAdapter adapter.Adapter
+	template := spec.WorkloadTemplate
+	switch {
+	case template.StatefulSetTemplate != nil:
+		poolAdapter = &adapter.StatefulSetAdapter{Scheme: dryRunScheme}
+	case template.DeploymentTemplate != nil:
+		poolAdapter = &adapter.DeploymentAdapter{Scheme: dryRunScheme}
+	case template.DaemonSetTemplate != nil:
+		poolAdapter = &adapter.DaemonSetAdapter{Scheme: dryRunScheme}
+	case template.CustomTemplate != nil:
+		poolAdapter = &adapter.UnstructuredAdapter{Scheme: dryRunScheme,
+			Definition: getWorkloadDefinition(c, template.CustomTemplate.Definition)}
+	}
+
+	yas := &unitv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Name: "dry-run"}, Spec: *spec.DeepCopy()}
+	if yas.Spec.Selector.MatchLabels == nil {
+		yas.Spec.Selector.MatchLabels = map[string]string{}
+	}
+	for i, pool := range spec.Topology.Pools {
+		switch pool.PatchType {
+		case "", unitv1alpha1.StrategicMergePatchType, unitv1alpha1.JSONPatchType, unitv1alpha1.MergePatchType:
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Index(i).Child("patchType"), pool.PatchType,
+				[]string{string(unitv1alpha1.StrategicMergePatchType), string(unitv1alpha1.JSONPatchType),
+					string(unitv1alpha1.MergePatchType)}))
+			continue
+		}
+		if pool.Patch == nil || poolAdapter == nil {
+			continue
+		}
+
+		obj := poolAdapter.NewResourceObject()
+		if err := poolAdapter.ApplyPoolTemplate(yas, pool.Name, "dry-run", dryRunReplicas, obj); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("patch"), string(pool.Patch.Raw),
+				fmt.Sprintf("fail to apply the patch to the workload template: %v", err)))
+			continue
+		}
+
+		// the replicas of the autoscaled pool are decided by the autoscaler
+		if pool.Autoscaler != nil && template.DaemonSetTemplate == nil {
+			details, err := poolAdapter.GetDetails(obj.(metav1.Object))
+			if err != nil || details.Replicas != dryRunReplicas {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("patch"), string(pool.Patch.Raw),
+					"the patch of the autoscaled pool should not modify the replicas"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// getWorkloadDefinition returns the WorkloadDefinition of the custom template.
+// The definition may be created after the YurtAppSet, so the default field
+// paths are used for the dry run if it is not found.
+func getWorkloadDefinition(c client.Client, name string) *unitv1alpha1.WorkloadDefinition {
+	definition := &unitv1alpha1.WorkloadDefinition{}
+	if c != nil {
+		if err := c.Get(context.TODO(), client.ObjectKey{Name: name}, definition); err == nil {
+			return definition
+		}
+	}
+	return &unitv1alpha1.WorkloadDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       unitv1alpha1.WorkloadDefinitionSpec{APIVersion: "dry-run/v1", Kind: "Workload"},
+	}
+}
+
 func validatePodTemplateSpec(template *core.PodTemplateSpec, selector labels.Selector, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if template == nil {
@@ -261,6 +689,18 @@ func validateStatefulSet(statefulSet *unitv1alpha1.StatefulSetTemplateSpec, fldP
 	return allErrs
 }
 
+// validateDaemonSet validates the update strategy of the DaemonSet template.
+func validateDaemonSet(daemonSet *unitv1alpha1.DaemonSetTemplateSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch daemonSet.Spec.UpdateStrategy.Type {
+	case "", appsv1.RollingUpdateDaemonSetStrategyType, appsv1.OnDeleteDaemonSetStrategyType:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("spec", "updateStrategy", "type"), daemonSet.Spec.UpdateStrategy.Type,
+			[]string{string(appsv1.RollingUpdateDaemonSetStrategyType), string(appsv1.OnDeleteDaemonSetStrategyType)}))
+	}
+	return allErrs
+}
+
 func validateDeployment(deployment *unitv1alpha1.DeploymentTemplateSpec, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if deployment.Spec.Replicas != nil {
@@ -66,6 +66,9 @@ func (webhook *YurtAppSetHandler) Default(ctx context.Context, obj runtime.Objec
 	if deployTem != nil {
 		deployTem.Spec.Selector = appset.Spec.Selector
 	}
+	if daemonSetTem := appset.Spec.WorkloadTemplate.DaemonSetTemplate; daemonSetTem != nil {
+		daemonSetTem.Spec.Selector = appset.Spec.Selector
+	}
 
 	return nil
 }
@@ -21,6 +21,8 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilpointer "k8s.io/utils/pointer"
 
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
 )
@@ -91,3 +93,484 @@ func TestYurtAppSetValidator(t *testing.T) {
 		t.Fatal("workload selector change should fail")
 	}
 }
+
+func TestYurtAppSetReplicasValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas *int32
+		pools    []v1alpha1.Pool
+		valid    bool
+	}{
+		{
+			"weighted pools",
+			utilpointer.Int32Ptr(10),
+			[]v1alpha1.Pool{
+				{Name: "beijing", Weight: utilpointer.Int32Ptr(2)},
+				{Name: "hangzhou", Percentage: utilpointer.Int32Ptr(30)},
+				{Name: "cloud", Elastic: true},
+			},
+			true,
+		},
+		{
+			"weight without replicas of yurtappset",
+			nil,
+			[]v1alpha1.Pool{{Name: "beijing", Weight: utilpointer.Int32Ptr(2)}},
+			false,
+		},
+		{
+			"both replicas and weight are set",
+			utilpointer.Int32Ptr(10),
+			[]v1alpha1.Pool{{Name: "beijing", Replicas: utilpointer.Int32Ptr(2), Weight: utilpointer.Int32Ptr(2)}},
+			false,
+		},
+		{
+			"percentage exceeds 100",
+			utilpointer.Int32Ptr(10),
+			[]v1alpha1.Pool{
+				{Name: "beijing", Percentage: utilpointer.Int32Ptr(60)},
+				{Name: "hangzhou", Percentage: utilpointer.Int32Ptr(60)},
+			},
+			false,
+		},
+		{
+			"multiple elastic pools",
+			utilpointer.Int32Ptr(10),
+			[]v1alpha1.Pool{{Name: "beijing", Elastic: true}, {Name: "hangzhou", Elastic: true}},
+			false,
+		},
+		{
+			"min replicas exceed replicas of yurtappset",
+			utilpointer.Int32Ptr(3),
+			[]v1alpha1.Pool{
+				{Name: "beijing", MinReplicas: utilpointer.Int32Ptr(2)},
+				{Name: "hangzhou", Replicas: utilpointer.Int32Ptr(2)},
+			},
+			false,
+		},
+		{
+			"max replicas can not hold replicas of yurtappset",
+			utilpointer.Int32Ptr(10),
+			[]v1alpha1.Pool{
+				{Name: "beijing", MaxReplicas: utilpointer.Int32Ptr(4)},
+				{Name: "hangzhou", Weight: utilpointer.Int32Ptr(1), MaxReplicas: utilpointer.Int32Ptr(4)},
+			},
+			false,
+		},
+		{
+			"autoscaled pools take no share of replicas",
+			utilpointer.Int32Ptr(4),
+			[]v1alpha1.Pool{
+				{Name: "beijing", MaxReplicas: utilpointer.Int32Ptr(4)},
+				{Name: "hangzhou", Autoscaler: &v1alpha1.PoolAutoscaler{MaxReplicas: 5}},
+			},
+			true,
+		},
+		{
+			"all pools are autoscaled",
+			utilpointer.Int32Ptr(4),
+			[]v1alpha1.Pool{
+				{Name: "beijing", Autoscaler: &v1alpha1.PoolAutoscaler{MaxReplicas: 5}},
+				{Name: "hangzhou", Autoscaler: &v1alpha1.PoolAutoscaler{MaxReplicas: 5}},
+			},
+			false,
+		},
+	}
+
+	webhook := &YurtAppSetHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				appset := defaultAppSet.DeepCopy()
+				appset.Spec.Replicas = st.replicas
+				appset.Spec.Topology = v1alpha1.Topology{Pools: st.pools}
+				if err := webhook.Default(context.TODO(), appset); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), appset)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppSetRolloutStrategyValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy *v1alpha1.RolloutStrategy
+		valid    bool
+	}{
+		{
+			"staged rollout",
+			&v1alpha1.RolloutStrategy{
+				Waves: []v1alpha1.RolloutWave{
+					{Name: "canary", Pools: []string{"beijing"}, Pause: true},
+					{Name: "east", Pools: []string{"hangzhou", "shanghai"}},
+				},
+				MaxUnavailablePools: utilpointer.Int32Ptr(2),
+			},
+			true,
+		},
+		{
+			"pool is listed in multiple waves",
+			&v1alpha1.RolloutStrategy{
+				Waves: []v1alpha1.RolloutWave{
+					{Name: "canary", Pools: []string{"beijing"}},
+					{Name: "east", Pools: []string{"beijing", "hangzhou"}},
+				},
+			},
+			false,
+		},
+		{
+			"duplicated wave names",
+			&v1alpha1.RolloutStrategy{
+				Waves: []v1alpha1.RolloutWave{
+					{Name: "canary", Pools: []string{"beijing"}},
+					{Name: "canary", Pools: []string{"hangzhou"}},
+				},
+			},
+			false,
+		},
+		{
+			"reserved wave name",
+			&v1alpha1.RolloutStrategy{
+				Waves: []v1alpha1.RolloutWave{{Name: v1alpha1.DefaultRolloutWaveName, Pools: []string{"beijing"}}},
+			},
+			false,
+		},
+		{
+			"zero max unavailable pools",
+			&v1alpha1.RolloutStrategy{MaxUnavailablePools: utilpointer.Int32Ptr(0)},
+			false,
+		},
+		{
+			"zero progress deadline",
+			&v1alpha1.RolloutStrategy{ProgressDeadlineSeconds: utilpointer.Int32Ptr(0)},
+			false,
+		},
+	}
+
+	webhook := &YurtAppSetHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				appset := defaultAppSet.DeepCopy()
+				appset.Spec.RolloutStrategy = st.strategy
+				if err := webhook.Default(context.TODO(), appset); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), appset)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppSetDaemonSetValidator(t *testing.T) {
+	webhook := &YurtAppSetHandler{}
+
+	appset := defaultAppSet.DeepCopy()
+	appset.Spec.WorkloadTemplate = v1alpha1.WorkloadTemplate{
+		DaemonSetTemplate: &v1alpha1.DaemonSetTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+			Spec: appsv1.DaemonSetSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "agent", Image: "agent"}},
+					},
+				},
+			},
+		},
+	}
+	if err := webhook.Default(context.TODO(), appset); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhook.ValidateCreate(context.TODO(), appset); err != nil {
+		t.Fatal("yurtappset with daemonset template should create success", err)
+	}
+
+	mismatch := appset.DeepCopy()
+	mismatch.Spec.WorkloadTemplate.DaemonSetTemplate.Spec.Template.Labels = map[string]string{"app": "other"}
+	if err := webhook.ValidateCreate(context.TODO(), mismatch); err == nil {
+		t.Fatal("pod template labels not matching the selector should fail")
+	}
+
+	multiple := appset.DeepCopy()
+	multiple.Spec.WorkloadTemplate.DeploymentTemplate = defaultAppSet.Spec.WorkloadTemplate.DeploymentTemplate.DeepCopy()
+	if err := webhook.ValidateCreate(context.TODO(), multiple); err == nil {
+		t.Fatal("multiple templates should fail")
+	}
+
+	replicas := appset.DeepCopy()
+	replicas.Spec.Replicas = utilpointer.Int32Ptr(3)
+	if err := webhook.ValidateCreate(context.TODO(), replicas); err == nil {
+		t.Fatal("replicas of daemonset template should fail")
+	}
+
+	defaultReplicas := appset.DeepCopy()
+	defaultReplicas.Spec.Topology.DefaultReplicas = utilpointer.Int32Ptr(1)
+	if err := webhook.ValidateCreate(context.TODO(), defaultReplicas); err == nil {
+		t.Fatal("default replicas of daemonset template should fail")
+	}
+
+	poolReplicas := appset.DeepCopy()
+	poolReplicas.Spec.Topology.Pools = []v1alpha1.Pool{{Name: "beijing", Weight: utilpointer.Int32Ptr(1)}}
+	if err := webhook.ValidateCreate(context.TODO(), poolReplicas); err == nil {
+		t.Fatal("pool replicas settings of daemonset template should fail")
+	}
+}
+
+func TestYurtAppSetCustomTemplateValidator(t *testing.T) {
+	webhook := &YurtAppSetHandler{}
+
+	appset := defaultAppSet.DeepCopy()
+	appset.Spec.WorkloadTemplate = v1alpha1.WorkloadTemplate{
+		CustomTemplate: &v1alpha1.CustomTemplateSpec{
+			Definition: "cloneset",
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+			Spec:       runtime.RawExtension{Raw: []byte(`{"updateStrategy":{"type":"InPlaceIfPossible"}}`)},
+		},
+	}
+	if err := webhook.Default(context.TODO(), appset); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhook.ValidateCreate(context.TODO(), appset); err != nil {
+		t.Fatal("yurtappset with custom template should create success", err)
+	}
+
+	noDefinition := appset.DeepCopy()
+	noDefinition.Spec.WorkloadTemplate.CustomTemplate.Definition = ""
+	if err := webhook.ValidateCreate(context.TODO(), noDefinition); err == nil {
+		t.Fatal("custom template without definition should fail")
+	}
+
+	notObject := appset.DeepCopy()
+	notObject.Spec.WorkloadTemplate.CustomTemplate.Spec = runtime.RawExtension{Raw: []byte(`["replicas"]`)}
+	if err := webhook.ValidateCreate(context.TODO(), notObject); err == nil {
+		t.Fatal("custom template spec which is not an object should fail")
+	}
+
+	patched := appset.DeepCopy()
+	patched.Spec.Topology.Pools[0].PatchType = v1alpha1.JSONPatchType
+	patched.Spec.Topology.Pools[0].Patch = &runtime.RawExtension{
+		Raw: []byte(`[{"op":"replace","path":"/spec/updateStrategy/type","value":"ReCreate"}]`)}
+	if err := webhook.ValidateCreate(context.TODO(), patched); err != nil {
+		t.Fatal("custom template with json patch should create success", err)
+	}
+	patched.Spec.Topology.Pools[0].Patch = &runtime.RawExtension{
+		Raw: []byte(`[{"op":"remove","path":"/spec/paused"}]`)}
+	if err := webhook.ValidateCreate(context.TODO(), patched); err == nil {
+		t.Fatal("json patch removing missing field should fail")
+	}
+
+	mismatch := appset.DeepCopy()
+	mismatch.Spec.WorkloadTemplate.CustomTemplate.Labels = map[string]string{"app": "other"}
+	if err := webhook.ValidateCreate(context.TODO(), mismatch); err == nil {
+		t.Fatal("template labels not matching the selector should fail")
+	}
+}
+
+func TestYurtAppSetPoolPatchValidator(t *testing.T) {
+	tests := []struct {
+		name      string
+		patchType v1alpha1.PatchType
+		patch     string
+		valid     bool
+	}{
+		{
+			"strategic merge patch",
+			"",
+			`{"spec":{"template":{"spec":{"containers":[{"name":"demo","image":"nginx:1.19"}]}}}}`,
+			true,
+		},
+		{
+			"strategic merge patch with wrong field type",
+			v1alpha1.StrategicMergePatchType,
+			`{"spec":{"replicas":"three"}}`,
+			false,
+		},
+		{
+			"json patch",
+			v1alpha1.JSONPatchType,
+			`[{"op":"replace","path":"/spec/template/spec/containers/0/image","value":"nginx:1.19"},` +
+				`{"op":"add","path":"/spec/template/spec/containers/0/args","value":["--v=2"]}]`,
+			true,
+		},
+		{
+			"json patch on missing path",
+			v1alpha1.JSONPatchType,
+			`[{"op":"remove","path":"/spec/template/spec/containers/1"}]`,
+			false,
+		},
+		{
+			"json patch which is not a list of operations",
+			v1alpha1.JSONPatchType,
+			`{"spec":{"replicas":3}}`,
+			false,
+		},
+		{
+			"merge patch",
+			v1alpha1.MergePatchType,
+			`{"spec":{"template":{"spec":{"containers":[{"name":"demo","image":"nginx:1.19"}]}}}}`,
+			true,
+		},
+		{
+			"unsupported patch type",
+			"Overwrite",
+			`{"spec":{"replicas":3}}`,
+			false,
+		},
+	}
+
+	webhook := &YurtAppSetHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				appset := defaultAppSet.DeepCopy()
+				appset.Spec.Topology.Pools[0].PatchType = st.patchType
+				appset.Spec.Topology.Pools[0].Patch = &runtime.RawExtension{Raw: []byte(st.patch)}
+				if err := webhook.Default(context.TODO(), appset); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), appset)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppSetAutoscalerValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoscaler *v1alpha1.PoolAutoscaler
+		patchType  v1alpha1.PatchType
+		patch      string
+		valid      bool
+	}{
+		{
+			"autoscaler",
+			&v1alpha1.PoolAutoscaler{MinReplicas: utilpointer.Int32Ptr(2), MaxReplicas: 5},
+			"",
+			"",
+			true,
+		},
+		{
+			"autoscaler without maxReplicas",
+			&v1alpha1.PoolAutoscaler{},
+			"",
+			"",
+			false,
+		},
+		{
+			"minReplicas greater than maxReplicas",
+			&v1alpha1.PoolAutoscaler{MinReplicas: utilpointer.Int32Ptr(6), MaxReplicas: 5},
+			"",
+			"",
+			false,
+		},
+		{
+			"zero minReplicas",
+			&v1alpha1.PoolAutoscaler{MinReplicas: utilpointer.Int32Ptr(0), MaxReplicas: 5},
+			"",
+			"",
+			false,
+		},
+		{
+			"patch of the autoscaled pool",
+			&v1alpha1.PoolAutoscaler{MaxReplicas: 5},
+			v1alpha1.MergePatchType,
+			`{"spec":{"minReadySeconds":10}}`,
+			true,
+		},
+		{
+			"patch modifying the replicas of the autoscaled pool",
+			&v1alpha1.PoolAutoscaler{MaxReplicas: 5},
+			"",
+			`{"spec":{"replicas":3}}`,
+			false,
+		},
+		{
+			"json patch modifying the replicas of the autoscaled pool",
+			&v1alpha1.PoolAutoscaler{MaxReplicas: 5},
+			v1alpha1.JSONPatchType,
+			`[{"op":"replace","path":"/spec/replicas","value":3}]`,
+			false,
+		},
+		{
+			"patch modifying the replicas of the pool without autoscaler",
+			nil,
+			"",
+			`{"spec":{"replicas":3}}`,
+			true,
+		},
+	}
+
+	webhook := &YurtAppSetHandler{}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				appset := defaultAppSet.DeepCopy()
+				appset.Spec.Topology.Pools[0].Autoscaler = st.autoscaler
+				if st.patch != "" {
+					appset.Spec.Topology.Pools[0].PatchType = st.patchType
+					appset.Spec.Topology.Pools[0].Patch = &runtime.RawExtension{Raw: []byte(st.patch)}
+				}
+				if err := webhook.Default(context.TODO(), appset); err != nil {
+					t.Fatal(err)
+				}
+				err := webhook.ValidateCreate(context.TODO(), appset)
+				if (err == nil) != st.valid {
+					t.Fatalf("expect valid %v, but get error %v", st.valid, err)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestYurtAppSetDaemonSetAutoscalerValidator(t *testing.T) {
+	webhook := &YurtAppSetHandler{}
+
+	appset := defaultAppSet.DeepCopy()
+	appset.Spec.WorkloadTemplate = v1alpha1.WorkloadTemplate{
+		DaemonSetTemplate: &v1alpha1.DaemonSetTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+			Spec: appsv1.DaemonSetSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "agent", Image: "agent"}},
+					},
+				},
+			},
+		},
+	}
+	appset.Spec.Topology.DefaultAutoscaler = &v1alpha1.PoolAutoscaler{MaxReplicas: 5}
+	if err := webhook.Default(context.TODO(), appset); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhook.ValidateCreate(context.TODO(), appset); err == nil {
+		t.Fatal("the pools of daemonset template should not be autoscaled")
+	}
+}
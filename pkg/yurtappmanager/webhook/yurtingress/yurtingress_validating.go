@@ -18,7 +18,10 @@ package yurtingress
 
 import (
 	"context"
+	"net"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -28,6 +31,38 @@ import (
 
 // validateYurtIngressSpec validates the yurt ingress spec.
 func validateYurtIngressSpec(c client.Client, ingressName string, spec *appsv1alpha1.YurtIngressSpec, isdelete bool) field.ErrorList {
+	if !isdelete {
+		switch spec.Provider {
+		case appsv1alpha1.NginxIngressProvider, appsv1alpha1.TraefikIngressProvider:
+		default:
+			return field.ErrorList([]*field.Error{
+				field.NotSupported(field.NewPath("spec").Child("provider"), spec.Provider,
+					[]string{string(appsv1alpha1.NginxIngressProvider), string(appsv1alpha1.TraefikIngressProvider)})})
+		}
+		if spec.Provider != appsv1alpha1.NginxIngressProvider {
+			if spec.WebhookCertManagement != "" {
+				return field.ErrorList([]*field.Error{
+					field.Forbidden(field.NewPath("spec").Child("webhookCertManagement"),
+						"webhookCertManagement is only supported by the nginx provider")})
+			}
+			for i, pool := range spec.Pools {
+				if len(pool.ConfigMapData) > 0 {
+					return field.ErrorList([]*field.Error{
+						field.Forbidden(field.NewPath("spec").Child("pools").Index(i).Child("configMapData"),
+							"configMapData is only supported by the nginx provider")})
+				}
+			}
+		}
+		if allErrs := validateYurtIngressNamespace(c, ingressName, spec); len(allErrs) > 0 {
+			return allErrs
+		}
+		for i := range spec.Pools {
+			fldPath := field.NewPath("spec").Child("pools").Index(i).Child("exposure")
+			if allErrs := validateIngressPoolExposure(spec.Pools[i].Exposure, fldPath); len(allErrs) > 0 {
+				return allErrs
+			}
+		}
+	}
 	if len(spec.Pools) > 0 {
 		var err error
 		var errmsg string
@@ -91,7 +126,90 @@ func validateYurtIngressSpec(c client.Client, ingressName string, spec *appsv1al
 	return nil
 }
 
+// validateYurtIngressNamespace validates the namespace of the ingress controllers, which
+// can be shared by the YurtIngress instances of the same provider only.
+func validateYurtIngressNamespace(c client.Client, ingressName string, spec *appsv1alpha1.YurtIngressSpec) field.ErrorList {
+	fldPath := field.NewPath("spec").Child("namespace")
+	if msgs := validation.IsDNS1123Label(spec.Namespace); len(msgs) > 0 {
+		return field.ErrorList([]*field.Error{field.Invalid(fldPath, spec.Namespace, strings.Join(msgs, ", "))})
+	}
+	ingressList := appsv1alpha1.YurtIngressList{}
+	if err := c.List(context.TODO(), &ingressList, &client.ListOptions{}); err != nil {
+		errmsg := "List YurtIngressList error!"
+		klog.Errorf(errmsg)
+		return field.ErrorList([]*field.Error{field.Forbidden(fldPath, errmsg)})
+	}
+	for i := range ingressList.Items {
+		ingress := ingressList.Items[i].DeepCopy()
+		if ingress.Name == ingressName {
+			continue
+		}
+		// YurtIngress created before the namespace is introduced uses the default namespace
+		appsv1alpha1.SetDefaultsYurtIngress(ingress)
+		if ingress.Spec.Namespace == spec.Namespace && ingress.Spec.Provider != spec.Provider {
+			errmsg := "namespace \"" + spec.Namespace + "\" is used by the " + string(ingress.Spec.Provider) +
+				" provider of \"" + ingress.Name + "\" already!"
+			klog.Errorf(errmsg)
+			return field.ErrorList([]*field.Error{field.Forbidden(fldPath, errmsg)})
+		}
+	}
+	return nil
+}
+
+// validateIngressPoolExposure validates the exposure fields are used by the expose mode.
+func validateIngressPoolExposure(exposure *appsv1alpha1.IngressPoolExposure, fldPath *field.Path) field.ErrorList {
+	if exposure == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	switch exposure.Mode {
+	case "", appsv1alpha1.NodePortExposeMode, appsv1alpha1.LoadBalancerExposeMode:
+		if exposure.HTTPNodePort != 0 && exposure.HTTPNodePort == exposure.HTTPSNodePort {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("httpsNodePort"), exposure.HTTPSNodePort))
+		}
+	case appsv1alpha1.HostNetworkExposeMode, appsv1alpha1.HostPortExposeMode:
+		if exposure.HTTPNodePort != 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("httpNodePort"),
+				"node port is not used by the "+string(exposure.Mode)+" mode"))
+		}
+		if exposure.HTTPSNodePort != 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("httpsNodePort"),
+				"node port is not used by the "+string(exposure.Mode)+" mode"))
+		}
+		if exposure.ExternalTrafficPolicy != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("externalTrafficPolicy"),
+				"externalTrafficPolicy is not used by the "+string(exposure.Mode)+" mode"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("mode"), exposure.Mode, []string{
+			string(appsv1alpha1.NodePortExposeMode), string(appsv1alpha1.LoadBalancerExposeMode),
+			string(appsv1alpha1.HostNetworkExposeMode), string(appsv1alpha1.HostPortExposeMode)}))
+	}
+	if exposure.LoadBalancerIP != "" {
+		if exposure.Mode != appsv1alpha1.LoadBalancerExposeMode {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("loadBalancerIP"),
+				"loadBalancerIP is only used by the LoadBalancer mode"))
+		} else if net.ParseIP(exposure.LoadBalancerIP) == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("loadBalancerIP"), exposure.LoadBalancerIP,
+				"must be a valid IP address"))
+		}
+	}
+	return allErrs
+}
+
 func validateYurtIngressSpecUpdate(c client.Client, ingressName string, spec *appsv1alpha1.YurtIngressSpec, oldSpec *appsv1alpha1.YurtIngressSpec) field.ErrorList {
+	// YurtIngress created before the provider and the namespace are introduced
+	// uses nginx and the default namespace
+	old := &appsv1alpha1.YurtIngress{Spec: *oldSpec.DeepCopy()}
+	appsv1alpha1.SetDefaultsYurtIngress(old)
+	if spec.Provider != old.Spec.Provider {
+		return field.ErrorList([]*field.Error{
+			field.Forbidden(field.NewPath("spec").Child("provider"), "provider is not allowed to be updated")})
+	}
+	if spec.Namespace != old.Spec.Namespace {
+		return field.ErrorList([]*field.Error{
+			field.Forbidden(field.NewPath("spec").Child("namespace"), "namespace is not allowed to be updated")})
+	}
 	return validateYurtIngressSpec(c, ingressName, spec, false)
 }
 
@@ -82,3 +82,206 @@ func TestYurtAppSetValidator(t *testing.T) {
 	}
 
 }
+
+func TestYurtIngressProviderValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	bjNp := &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "beijing",
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bjNp).Build()
+	webhook := &YurtIngressHandler{Client: client}
+
+	traefik := defaultYurtIngress.DeepCopy()
+	traefik.Spec.Provider = v1alpha1.TraefikIngressProvider
+	traefik.Spec.IngressControllerImage = ""
+	traefik.Spec.IngressWebhookCertGenImage = ""
+	traefik.Spec.WebhookCertManagement = ""
+	if err := webhook.Default(context.TODO(), traefik); err != nil {
+		t.Fatal(err)
+	}
+	if traefik.Spec.IngressControllerImage != "docker.io/library/traefik:v2.6.1" || traefik.Spec.IngressWebhookCertGenImage != "" {
+		t.Fatalf("unexpected defaulted images %s, %s", traefik.Spec.IngressControllerImage, traefik.Spec.IngressWebhookCertGenImage)
+	}
+	if err := webhook.ValidateCreate(context.TODO(), traefik); err != nil {
+		t.Fatal("should create success", err)
+	}
+
+	traefikConfig := traefik.DeepCopy()
+	traefikConfig.Spec.Pools[0].ConfigMapData = map[string]string{"use-gzip": "true"}
+	if err := webhook.ValidateCreate(context.TODO(), traefikConfig); err == nil {
+		t.Fatal("should create fail for configMapData of traefik")
+	}
+
+	traefikCert := traefik.DeepCopy()
+	traefikCert.Spec.WebhookCertManagement = v1alpha1.ControllerWebhookCertManagement
+	if err := webhook.ValidateCreate(context.TODO(), traefikCert); err == nil {
+		t.Fatal("should create fail for webhookCertManagement of traefik")
+	}
+
+	unsupported := defaultYurtIngress.DeepCopy()
+	unsupported.Spec.Provider = "haproxy"
+	if err := webhook.ValidateCreate(context.TODO(), unsupported); err == nil {
+		t.Fatal("should create fail for unsupported provider")
+	}
+
+	legacy := defaultYurtIngress.DeepCopy()
+	legacy.Spec.Provider = ""
+	nginx := defaultYurtIngress.DeepCopy()
+	if err := webhook.Default(context.TODO(), nginx); err != nil {
+		t.Fatal(err)
+	}
+	if nginx.Spec.WebhookCertManagement != v1alpha1.JobWebhookCertManagement {
+		t.Fatalf("unexpected defaulted webhookCertManagement %s", nginx.Spec.WebhookCertManagement)
+	}
+	if err := webhook.ValidateUpdate(context.TODO(), legacy, nginx); err != nil {
+		t.Fatal("should update success", err)
+	}
+	if err := webhook.ValidateUpdate(context.TODO(), nginx, traefik); err == nil {
+		t.Fatal("should update fail when provider is changed")
+	}
+
+	moved := nginx.DeepCopy()
+	moved.Spec.Namespace = "ingress-edge"
+	if err := webhook.ValidateUpdate(context.TODO(), moved, legacy); err == nil {
+		t.Fatal("should update fail when namespace is changed")
+	}
+}
+
+func TestYurtIngressNamespaceValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	bjNp := &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "beijing",
+		},
+	}
+	hzNp := &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "hangzhou",
+		},
+	}
+	// a YurtIngress created before the namespace is introduced
+	legacy := &v1alpha1.YurtIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy"},
+		Spec:       v1alpha1.YurtIngressSpec{Pools: []v1alpha1.IngressPool{{Name: "beijing"}}},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bjNp, hzNp, legacy).Build()
+	webhook := &YurtIngressHandler{Client: client}
+
+	newYurtIngress := func(provider v1alpha1.IngressProvider, namespace, pool string) *v1alpha1.YurtIngress {
+		ying := &v1alpha1.YurtIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: "fooboo"},
+			Spec: v1alpha1.YurtIngressSpec{
+				Provider:  provider,
+				Namespace: namespace,
+				Pools:     []v1alpha1.IngressPool{{Name: pool}},
+			},
+		}
+		if err := webhook.Default(context.TODO(), ying); err != nil {
+			t.Fatal(err)
+		}
+		return ying
+	}
+
+	if err := webhook.ValidateCreate(context.TODO(), newYurtIngress(v1alpha1.NginxIngressProvider, "", "hangzhou")); err != nil {
+		t.Fatal("should create success in the namespace of the same provider", err)
+	}
+	if err := webhook.ValidateCreate(context.TODO(), newYurtIngress(v1alpha1.NginxIngressProvider, "", "beijing")); err == nil {
+		t.Fatal("should create fail for the pool owned by another YurtIngress")
+	}
+	if err := webhook.ValidateCreate(context.TODO(),
+		newYurtIngress(v1alpha1.TraefikIngressProvider, "ingress-nginx", "hangzhou")); err == nil {
+		t.Fatal("should create fail in the namespace of another provider")
+	}
+	if err := webhook.ValidateCreate(context.TODO(),
+		newYurtIngress(v1alpha1.TraefikIngressProvider, "Ingress_Edge", "hangzhou")); err == nil {
+		t.Fatal("should create fail for invalid namespace")
+	}
+	if err := webhook.ValidateCreate(context.TODO(),
+		newYurtIngress(v1alpha1.TraefikIngressProvider, "ingress-edge", "hangzhou")); err != nil {
+		t.Fatal("should create success in a custom namespace", err)
+	}
+}
+
+func TestYurtIngressExposureValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	bjNp := &v1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "beijing",
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bjNp).Build()
+	webhook := &YurtIngressHandler{Client: client}
+
+	tests := []struct {
+		name     string
+		exposure *v1alpha1.IngressPoolExposure
+		valid    bool
+	}{
+		{
+			"default mode with fixed node ports",
+			&v1alpha1.IngressPoolExposure{HTTPNodePort: 30080, HTTPSNodePort: 30443, ExternalTrafficPolicy: "Local"},
+			true,
+		},
+		{
+			"duplicated node ports",
+			&v1alpha1.IngressPoolExposure{Mode: v1alpha1.NodePortExposeMode, HTTPNodePort: 30080, HTTPSNodePort: 30080},
+			false,
+		},
+		{
+			"load balancer ip",
+			&v1alpha1.IngressPoolExposure{Mode: v1alpha1.LoadBalancerExposeMode, LoadBalancerIP: "192.168.0.100"},
+			true,
+		},
+		{
+			"invalid load balancer ip",
+			&v1alpha1.IngressPoolExposure{Mode: v1alpha1.LoadBalancerExposeMode, LoadBalancerIP: "foo"},
+			false,
+		},
+		{
+			"load balancer ip of node port mode",
+			&v1alpha1.IngressPoolExposure{Mode: v1alpha1.NodePortExposeMode, LoadBalancerIP: "192.168.0.100"},
+			false,
+		},
+		{
+			"host network",
+			&v1alpha1.IngressPoolExposure{Mode: v1alpha1.HostNetworkExposeMode},
+			true,
+		},
+		{
+			"node port of host port mode",
+			&v1alpha1.IngressPoolExposure{Mode: v1alpha1.HostPortExposeMode, HTTPNodePort: 30080},
+			false,
+		},
+		{
+			"external traffic policy of host network mode",
+			&v1alpha1.IngressPoolExposure{Mode: v1alpha1.HostNetworkExposeMode, ExternalTrafficPolicy: "Local"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		ying := defaultYurtIngress.DeepCopy()
+		ying.Spec.Pools = []v1alpha1.IngressPool{{Name: "beijing", Exposure: tt.exposure}}
+		if err := webhook.Default(context.TODO(), ying); err != nil {
+			t.Fatal(err)
+		}
+		err := webhook.ValidateCreate(context.TODO(), ying)
+		if tt.valid && err != nil {
+			t.Fatalf("%s: should create success, %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Fatalf("%s: should create fail", tt.name)
+		}
+	}
+}